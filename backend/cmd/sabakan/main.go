@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/sweetfish329/sabakan/backend/internal/config"
 	"github.com/sweetfish329/sabakan/backend/internal/container"
 	"github.com/sweetfish329/sabakan/backend/internal/db"
+	"github.com/sweetfish329/sabakan/backend/internal/games"
 	"github.com/sweetfish329/sabakan/backend/internal/logger"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
 	"github.com/sweetfish329/sabakan/backend/internal/server"
 )
 
@@ -57,20 +62,93 @@ func main() {
 	}
 	logger.Info("Database seeding completed")
 
+	// Load custom game definitions
+	if cfg.GamesDir != "" {
+		loaded, err := games.LoadDir(cfg.GamesDir)
+		if err != nil {
+			logger.Warn("Failed to load some game definitions", "error", err)
+		}
+		logger.Info("Custom games loaded", "dir", cfg.GamesDir, "games", loaded)
+	}
+
 	// Initialize Container Service
-	containerService := container.NewService(cfg.Podman.SocketPath)
-	logger.Info("Container service initialized", "socket", cfg.Podman.SocketPath)
+	containerService, err := container.NewRuntime(cfg.Runtime, cfg.SocketPath(), container.ConnectOptions{})
+	if err != nil {
+		logger.Error("Failed to initialize container runtime", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Container service initialized", "runtime", cfg.Runtime, "socket", cfg.SocketPath())
+
+	// Connect to remote nodes
+	nodes := container.NewPool(containerService)
+	var remoteNodes []models.Node
+	if err := db.GetDB().Find(&remoteNodes).Error; err != nil {
+		logger.Warn("Failed to load nodes", "error", err)
+	}
+	for _, node := range remoteNodes {
+		if err := nodes.Connect(node); err != nil {
+			logger.Warn("Failed to connect to node", "node", node.Name, "error", err)
+			continue
+		}
+		logger.Info("Node connected", "node", node.Name, "endpoint", node.Endpoint)
+	}
+
+	// Record resource usage history of running game servers
+	sampler := container.NewSampler(db.GetDB(), nodes)
+	go sampler.Run(context.Background())
+
+	// Keep game server status in sync with container events
+	statusBroker := container.NewStatusBroker()
+	watcher := container.NewWatcher(db.GetDB(), nodes)
+	watcher.SetStatusBroker(statusBroker)
+
+	// Check game server images for updates
+	if cfg.Podman.UpdateCheckInterval > 0 {
+		interval := time.Duration(cfg.Podman.UpdateCheckInterval) * time.Hour
+		updateChecker := container.NewUpdateChecker(db.GetDB(), nodes, interval)
+		go updateChecker.Run(context.Background())
+	}
+
+	// Initialize Session Store (REDIS_URL overrides the config file)
+	redisURL := cfg.Redis.URL
+	if url := os.Getenv("REDIS_URL"); url != "" {
+		redisURL = url
+	}
+	var sessionStore redis.SessionStore
+	if redisURL != "" {
+		redisClient, err := redis.NewClientWithURL(redisURL)
+		if err != nil {
+			logger.Warn("Failed to connect to Redis, keeping sessions in memory", "error", err)
+		} else {
+			sessionStore = redis.NewRedisSessionStore(redisClient)
+			logger.Info("Redis session store initialized")
+		}
+	}
+	if sessionStore == nil {
+		sessionStore = redis.NewMemorySessionStore(redis.DefaultCleanupInterval)
+		logger.Info("In-memory session store initialized")
+	}
 
 	// Create server dependencies
 	deps := &server.Dependencies{
-		ContainerService: containerService,
-		DB:               db.GetDB(),
-		Config:           cfg,
-		SessionStore:     nil, // Redis session store (optional, can be nil for now)
+		Nodes:        nodes,
+		DB:           db.GetDB(),
+		Config:       cfg,
+		SessionStore: sessionStore,
+		StatusBroker: statusBroker,
+		Watcher:      watcher,
 	}
 
 	// Initialize and Start Server
 	s := server.New(deps)
+
+	// Reconcile once the readiness prober is registered, so that servers that
+	// were starting are probed again
+	if err := watcher.Reconcile(context.Background()); err != nil {
+		logger.Warn("Failed to reconcile game server status", "error", err)
+	}
+	go watcher.Run(context.Background())
+
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	logger.Info("Starting server", "address", addr)
 	s.Logger.Fatal(s.Start(addr))
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APITokenPrefix marks personal API tokens so that they can be told apart from JWTs.
+const APITokenPrefix = "sbk_"
+
+// APITokenDisplayLength is how many leading characters of an API token are kept for display.
+const APITokenDisplayLength = 8
+
+// apiTokenBytes is the number of random bytes in an API token.
+const apiTokenBytes = 32
+
+// GenerateAPIToken creates a new personal API token.
+// It returns the token, which is shown to its owner only once, and its hash for storage.
+func GenerateAPIToken() (token, hash string, err error) {
+	b := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAPIToken(token), nil
+}
+
+// HashAPIToken returns the hash under which an API token is stored.
+func HashAPIToken(token string) string {
+	return HashToken(token)
+}
+
+// HashToken returns the hash under which a token, such as an API or refresh
+// token, is stored. The tokens are random or signed, so a fast hash is enough
+// to look them up.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken reports whether a bearer token is a personal API token.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// Scope returns the scope granting an action on a resource, such as "game_server:read".
+func Scope(resource, action string) string {
+	return resource + ":" + action
+}
+
+// HasScope reports whether scopes allow an action on a resource.
+// The system:admin scope allows every action.
+func HasScope(scopes []string, resource, action string) bool {
+	want := Scope(resource, action)
+	admin := Scope("system", "admin")
+	for _, scope := range scopes {
+		if scope == want || scope == admin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+	t.Run("should generate a prefixed token and its hash", func(t *testing.T) {
+		token, hash, err := GenerateAPIToken()
+
+		require.NoError(t, err)
+		assert.True(t, IsAPIToken(token))
+		assert.Equal(t, HashAPIToken(token), hash)
+		assert.NotContains(t, hash, token)
+	})
+
+	t.Run("should generate different tokens", func(t *testing.T) {
+		token1, _, err1 := GenerateAPIToken()
+		token2, _, err2 := GenerateAPIToken()
+
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		assert.NotEqual(t, token1, token2)
+	})
+}
+
+func TestIsAPIToken(t *testing.T) {
+	t.Run("should not treat JWTs as API tokens", func(t *testing.T) {
+		manager := NewJWTManager("test-secret-key-for-api-tokens-32!", 0, 0)
+		token, _, err := manager.GenerateAccessToken(1, "user")
+
+		require.NoError(t, err)
+		assert.False(t, IsAPIToken(token))
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	t.Run("should allow actions in the scopes", func(t *testing.T) {
+		scopes := []string{"game_server:read", "game_server:start"}
+
+		assert.True(t, HasScope(scopes, "game_server", "read"))
+		assert.True(t, HasScope(scopes, "game_server", "start"))
+		assert.False(t, HasScope(scopes, "game_server", "delete"))
+		assert.False(t, HasScope(nil, "game_server", "read"))
+	})
+
+	t.Run("should allow every action with system:admin", func(t *testing.T) {
+		assert.True(t, HasScope([]string{"system:admin"}, "mod", "delete"))
+	})
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"time"
 
@@ -29,6 +31,27 @@ type RefreshTokenClaims struct {
 	jwt.RegisteredClaims
 }
 
+// ChallengePurpose is what a challenge token allows its holder to do.
+type ChallengePurpose string
+
+const (
+	// ChallengeVerify lets a user who entered their password finish login with a second factor.
+	ChallengeVerify ChallengePurpose = "2fa_verify"
+	// ChallengeEnroll lets a user who entered their password set up the second
+	// factor their role requires before finishing login.
+	ChallengeEnroll ChallengePurpose = "2fa_enroll"
+)
+
+// ChallengeTokenExpiry is how long the second login step may take.
+const ChallengeTokenExpiry = 5 * time.Minute
+
+// ChallengeTokenClaims represents the claims in a challenge token.
+type ChallengeTokenClaims struct {
+	UserID  uint             `json:"user_id"`
+	Purpose ChallengePurpose `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
 // JWTManager handles JWT token generation and validation.
 type JWTManager struct {
 	secret             []byte
@@ -45,6 +68,11 @@ func NewJWTManager(secret string, accessExpiry, refreshExpiry time.Duration) *JW
 	}
 }
 
+// RefreshTokenExpiry returns how long refresh tokens are valid.
+func (m *JWTManager) RefreshTokenExpiry() time.Duration {
+	return m.refreshTokenExpiry
+}
+
 // GenerateAccessToken creates a new access token for the given user.
 func (m *JWTManager) GenerateAccessToken(userID uint, username string) (string, string, error) {
 	jti := uuid.New().String()
@@ -143,3 +171,56 @@ func (m *JWTManager) ValidateRefreshToken(tokenString string) (*RefreshTokenClai
 
 	return claims, nil
 }
+
+// challengeSecret derives the key of challenge tokens from the JWT secret, so
+// that a challenge token can never pass as an access or refresh token.
+func (m *JWTManager) challengeSecret() []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte("sabakan-challenge"))
+	return mac.Sum(nil)
+}
+
+// GenerateChallengeToken creates a short-lived token for the second step of a login.
+func (m *JWTManager) GenerateChallengeToken(userID uint, purpose ChallengePurpose) (string, error) {
+	now := time.Now()
+
+	claims := ChallengeTokenClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "sabakan",
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(m.challengeSecret())
+}
+
+// ValidateChallengeToken validates a challenge token issued for purpose and returns its claims.
+func (m *JWTManager) ValidateChallengeToken(tokenString string, purpose ChallengePurpose) (*ChallengeTokenClaims, error) {
+	if tokenString == "" {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeTokenClaims{}, func(_ *jwt.Token) (any, error) {
+		return m.challengeSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ChallengeTokenClaims)
+	if !ok || !token.Valid || claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -141,3 +141,45 @@ func TestValidateRefreshToken(t *testing.T) {
 		assert.Nil(t, claims)
 	})
 }
+
+func TestChallengeToken(t *testing.T) {
+	manager := NewJWTManager(testSecret, 15*time.Minute, 7*24*time.Hour)
+
+	t.Run("should validate a challenge token for its purpose", func(t *testing.T) {
+		token, err := manager.GenerateChallengeToken(42, ChallengeVerify)
+		require.NoError(t, err)
+
+		claims, err := manager.ValidateChallengeToken(token, ChallengeVerify)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), claims.UserID)
+		assert.Equal(t, ChallengeVerify, claims.Purpose)
+	})
+
+	t.Run("should reject a challenge token for another purpose", func(t *testing.T) {
+		token, err := manager.GenerateChallengeToken(42, ChallengeEnroll)
+		require.NoError(t, err)
+
+		_, err = manager.ValidateChallengeToken(token, ChallengeVerify)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should not accept challenge tokens as access tokens", func(t *testing.T) {
+		token, err := manager.GenerateChallengeToken(42, ChallengeVerify)
+		require.NoError(t, err)
+
+		_, err = manager.ValidateAccessToken(token)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should not accept access tokens as challenge tokens", func(t *testing.T) {
+		token, _, err := manager.GenerateAccessToken(42, "user")
+		require.NoError(t, err)
+
+		_, err = manager.ValidateChallengeToken(token, ChallengeVerify)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPPeriod is how long a TOTP code is valid (RFC 6238 time step).
+	TOTPPeriod = 30 * time.Second
+	// TOTPDigits is the number of digits of a TOTP code.
+	TOTPDigits = 6
+	// totpModulo is 10^TOTPDigits.
+	totpModulo = 1_000_000
+	// TOTPIssuer names Sabakan in authenticator apps.
+	TOTPIssuer = "Sabakan"
+	// totpSecretBytes is the size of a TOTP secret, the HMAC-SHA1 key size recommended by RFC 4226.
+	totpSecretBytes = 20
+	// totpSkew is how many time steps a code may be off to allow for clock drift.
+	totpSkew = 1
+)
+
+// RecoveryCodeCount is the number of recovery codes generated at a time.
+const RecoveryCodeCount = 10
+
+// recoveryCodeLength is the number of characters of a recovery code, excluding the separator.
+const recoveryCodeLength = 10
+
+// recoveryCodeAlphabet has 32 characters, so that every byte maps to one
+// without bias. Characters that are easily confused are left out.
+const recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+// totpEncoding is the base32 encoding authenticator apps expect for secrets.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret creates a random base32 TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI returns the otpauth URI that authenticator apps import, usually as a QR code.
+func TOTPURI(account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + TOTPIssuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// TOTPStep returns the TOTP time step of t.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// TOTPCode returns the TOTP code of a secret for a time step.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%totpModulo), nil
+}
+
+// ValidateTOTP checks a TOTP code at time t, allowing for one time step of
+// clock drift. Codes of steps up to lastStep were already used and are
+// rejected. It returns the step of the code, to be passed as lastStep next time.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	now := TOTPStep(t)
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes creates one-time codes for signing in without the authenticator app.
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := make([]byte, recoveryCodeLength)
+		for j, v := range b {
+			code[j] = recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)]
+		}
+		half := recoveryCodeLength / 2
+		codes[i] = string(code[:half]) + "-" + string(code[half:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode returns the hash under which a recovery code is stored.
+// Case, spaces and dashes are ignored so that codes can be typed loosely.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	return HashToken(normalized)
+}
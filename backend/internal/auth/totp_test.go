@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors ("12345678901234567890") in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	t.Run("should match the RFC 6238 test vectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:          "287082",
+			1111111109:  "081804",
+			1111111111:  "050471",
+			1234567890:  "005924",
+			2000000000:  "279037",
+			20000000000: "353130",
+		}
+		for unix, want := range vectors {
+			code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(unix, 0)))
+
+			require.NoError(t, err)
+			assert.Equal(t, want, code, unix)
+		}
+	})
+
+	t.Run("should reject secrets that are not base32", func(t *testing.T) {
+		_, err := TOTPCode("not base32!", 1)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	step := TOTPStep(now)
+	code, err := TOTPCode(secret, step)
+	require.NoError(t, err)
+
+	t.Run("should accept the current code and return its step", func(t *testing.T) {
+		got, ok := ValidateTOTP(secret, code, now, 0)
+
+		assert.True(t, ok)
+		assert.Equal(t, step, got)
+	})
+
+	t.Run("should accept codes one step off", func(t *testing.T) {
+		previous, err := TOTPCode(secret, step-1)
+		require.NoError(t, err)
+
+		_, ok := ValidateTOTP(secret, previous, now, 0)
+
+		assert.True(t, ok)
+	})
+
+	t.Run("should reject codes two steps off", func(t *testing.T) {
+		old, err := TOTPCode(secret, step-2)
+		require.NoError(t, err)
+
+		_, ok := ValidateTOTP(secret, old, now, 0)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should reject codes that were already used", func(t *testing.T) {
+		_, ok := ValidateTOTP(secret, code, now, step)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should reject malformed codes", func(t *testing.T) {
+		_, ok := ValidateTOTP(secret, "12345", now, 0)
+
+		assert.False(t, ok)
+	})
+}
+
+func TestTOTPURI(t *testing.T) {
+	t.Run("should build an otpauth URI for authenticator apps", func(t *testing.T) {
+		uri, err := url.Parse(TOTPURI("admin", rfcSecret))
+
+		require.NoError(t, err)
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, "/Sabakan:admin", uri.Path)
+		assert.Equal(t, rfcSecret, uri.Query().Get("secret"))
+		assert.Equal(t, "Sabakan", uri.Query().Get("issuer"))
+		assert.Equal(t, "6", uri.Query().Get("digits"))
+	})
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	t.Run("should generate distinct codes", func(t *testing.T) {
+		codes, err := GenerateRecoveryCodes()
+
+		require.NoError(t, err)
+		assert.Len(t, codes, RecoveryCodeCount)
+		seen := map[string]bool{}
+		for _, code := range codes {
+			assert.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+			assert.False(t, seen[code])
+			seen[code] = true
+		}
+	})
+
+	t.Run("should hash codes regardless of case and dashes", func(t *testing.T) {
+		assert.Equal(t, HashRecoveryCode("abcde-fghij"), HashRecoveryCode("ABCDE FGHIJ"))
+		assert.Equal(t, HashRecoveryCode("abcde-fghij"), HashRecoveryCode("abcdefghij"))
+		assert.NotEqual(t, HashRecoveryCode("abcde-fghij"), HashRecoveryCode("abcde-fghik"))
+	})
+}
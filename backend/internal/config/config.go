@@ -10,20 +10,37 @@ import (
 
 // SystemConfig represents the system-wide configuration.
 type SystemConfig struct {
+	// GamesDir is the directory containing custom game definitions (*.toml).
+	GamesDir string `toml:"games_dir"`
+	// BackupsDir is the directory volume backups are written to.
+	BackupsDir string `toml:"backups_dir"`
+	// Runtime is the container engine game servers run on: "podman" or "docker".
+	Runtime  string         `toml:"runtime"`
 	Server   ServerConfig   `toml:"server"`
 	Database DatabaseConfig `toml:"database"`
 	Logging  LoggingConfig  `toml:"logging"`
 	Podman   PodmanConfig   `toml:"podman"`
+	Docker   DockerConfig   `toml:"docker"`
+	Ports    PortsConfig    `toml:"ports"`
 	JWT      JWTConfig      `toml:"jwt"`
 	Redis    RedisConfig    `toml:"redis"`
 	Auth     AuthConfig     `toml:"auth"`
 	OAuth    OAuthConfig    `toml:"oauth"`
+	// RateLimit limits the requests of each client IP per route group.
+	RateLimit RateLimitConfig `toml:"rate_limit"`
 }
 
 // ServerConfig contains HTTP server settings.
 type ServerConfig struct {
 	Host string `toml:"host"`
 	Port int    `toml:"port"`
+	// TrustedProxies are the IPs or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header is trusted. Without any, the client IP is the
+	// address of the connection.
+	TrustedProxies []string `toml:"trusted_proxies"`
+	// AllowedOrigins are the origins of the frontend that may open WebSocket
+	// connections to the API, e.g. "http://localhost:4200".
+	AllowedOrigins []string `toml:"allowed_origins"`
 }
 
 // DatabaseConfig contains database connection settings.
@@ -43,6 +60,31 @@ type PodmanConfig struct {
 	// For rootful: unix:///run/podman/podman.sock
 	// For rootless: unix://$XDG_RUNTIME_DIR/podman/podman.sock
 	SocketPath string `toml:"socket_path"`
+	// UpdateCheckInterval is how often game server images are checked for updates, in hours.
+	// Set to 0 to disable update checks.
+	UpdateCheckInterval int `toml:"update_check_interval"`
+}
+
+// DockerConfig contains Docker Engine connection settings.
+type DockerConfig struct {
+	// SocketPath is the path to the Docker socket (e.g. unix:///var/run/docker.sock).
+	SocketPath string `toml:"socket_path"`
+}
+
+// PortsConfig contains host port allocation settings.
+type PortsConfig struct {
+	// RangeStart and RangeEnd bound the host ports allocated to game servers
+	// whose default port is taken or that request host port 0.
+	RangeStart int `toml:"range_start"`
+	RangeEnd   int `toml:"range_end"`
+}
+
+// SocketPath returns the socket of the configured container runtime.
+func (c *SystemConfig) SocketPath() string {
+	if c.Runtime == "docker" {
+		return c.Docker.SocketPath
+	}
+	return c.Podman.SocketPath
 }
 
 // JWTConfig contains JWT authentication settings.
@@ -60,6 +102,16 @@ type RedisConfig struct {
 // AuthConfig contains authentication settings.
 type AuthConfig struct {
 	AllowRegistration bool `toml:"allow_registration"` // Whether to allow new user registration
+	// Require2FAMinPriority makes two-factor authentication mandatory for roles
+	// with at least this priority (admin is 100, moderator 50). 0 disables it.
+	Require2FAMinPriority int `toml:"require_2fa_min_priority"`
+}
+
+// RateLimitConfig contains the requests allowed per minute from each client IP.
+// 0 disables the limit of a route group.
+type RateLimitConfig struct {
+	Auth int `toml:"auth"` // Login, token refresh and 2FA under /auth
+	API  int `toml:"api"`  // Everything under /api
 }
 
 // OAuthConfig contains OAuth provider settings.
@@ -79,6 +131,7 @@ type OAuthProviderConfig struct {
 type GameConfig struct {
 	Game      GameInfo      `toml:"game"`
 	Container ContainerInfo `toml:"container"`
+	Readiness ReadinessInfo `toml:"readiness"`
 	Mods      []ModInfo     `toml:"mods"`
 }
 
@@ -87,6 +140,8 @@ type GameInfo struct {
 	ID          string `toml:"id"`
 	Name        string `toml:"name"`
 	Description string `toml:"description"`
+	Icon        string `toml:"icon"`     // Material icon name
+	DocsURL     string `toml:"docs_url"` // Link to the server image documentation
 }
 
 // ContainerInfo contains container runtime configuration.
@@ -95,6 +150,17 @@ type ContainerInfo struct {
 	Ports   []string          `toml:"ports"`
 	Volumes []string          `toml:"volumes"`
 	Env     map[string]string `toml:"env"`
+	// MinMemoryMB and MinCPUs are the smallest resource limits servers of the game accept.
+	MinMemoryMB int     `toml:"min_memory_mb"`
+	MinCPUs     float64 `toml:"min_cpus"`
+}
+
+// ReadinessInfo describes how to tell that a server of the game accepts players.
+// Set either Log or Port; servers count as ready once started when both are empty.
+type ReadinessInfo struct {
+	Log     string `toml:"log"`     // Regular expression matching the log line printed when ready
+	Port    string `toml:"port"`    // Name of the port to connect to
+	Timeout int    `toml:"timeout"` // Seconds the game may take to become ready
 }
 
 // ModInfo represents a single mod entry.
@@ -123,9 +189,13 @@ func LoadSystemConfig(path string) (*SystemConfig, error) {
 // DefaultSystemConfig returns the default system configuration.
 func DefaultSystemConfig() *SystemConfig {
 	return &SystemConfig{
+		GamesDir:   "./games",
+		BackupsDir: "./backups",
+		Runtime:    "podman",
 		Server: ServerConfig{
-			Host: "0.0.0.0",
-			Port: 1323,
+			Host:           "0.0.0.0",
+			Port:           1323,
+			AllowedOrigins: []string{"http://localhost:4200"},
 		},
 		Database: DatabaseConfig{
 			Path: "./sabakan.db",
@@ -135,7 +205,15 @@ func DefaultSystemConfig() *SystemConfig {
 			Format: "text",
 		},
 		Podman: PodmanConfig{
-			SocketPath: "unix:///run/podman/podman.sock",
+			SocketPath:          "unix:///run/podman/podman.sock",
+			UpdateCheckInterval: 6, // 6 hours
+		},
+		Docker: DockerConfig{
+			SocketPath: "unix:///var/run/docker.sock",
+		},
+		Ports: PortsConfig{
+			RangeStart: 30000,
+			RangeEnd:   39999,
 		},
 		JWT: JWTConfig{
 			Secret:             "change-this-secret-in-production-32bytes!",
@@ -148,6 +226,10 @@ func DefaultSystemConfig() *SystemConfig {
 		Auth: AuthConfig{
 			AllowRegistration: true,
 		},
+		RateLimit: RateLimitConfig{
+			Auth: 30,
+			API:  600,
+		},
 		OAuth: OAuthConfig{
 			Google: OAuthProviderConfig{
 				RedirectURL: "http://localhost:1323/auth/oauth/google/callback",
@@ -14,6 +14,8 @@ func TestLoadSystemConfig_Success(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.toml")
 	content := `
+games_dir = "/srv/sabakan/games"
+
 [server]
 host = "127.0.0.1"
 port = 8080
@@ -33,6 +35,7 @@ format = "json"
 
 	// Assert
 	require.NoError(t, err)
+	assert.Equal(t, "/srv/sabakan/games", cfg.GamesDir)
 	assert.Equal(t, "127.0.0.1", cfg.Server.Host)
 	assert.Equal(t, 8080, cfg.Server.Port)
 	assert.Equal(t, "./test.db", cfg.Database.Path)
@@ -76,6 +79,8 @@ func TestDefaultSystemConfig(t *testing.T) {
 	assert.Equal(t, "./sabakan.db", cfg.Database.Path)
 	assert.Equal(t, "info", cfg.Logging.Level)
 	assert.Equal(t, "text", cfg.Logging.Format)
+	assert.Equal(t, 30, cfg.RateLimit.Auth)
+	assert.Equal(t, 600, cfg.RateLimit.API)
 }
 
 func TestLoadGameConfig_Success(t *testing.T) {
@@ -153,3 +158,18 @@ func TestSaveGameConfig_Success(t *testing.T) {
 	assert.Equal(t, cfg.Container.Image, loaded.Container.Image)
 	assert.Len(t, loaded.Mods, 1)
 }
+
+func TestSystemConfig_SocketPath(t *testing.T) {
+	t.Run("should use the Podman socket by default", func(t *testing.T) {
+		cfg := DefaultSystemConfig()
+
+		assert.Equal(t, "unix:///run/podman/podman.sock", cfg.SocketPath())
+	})
+
+	t.Run("should use the Docker socket when the runtime is docker", func(t *testing.T) {
+		cfg := DefaultSystemConfig()
+		cfg.Runtime = "docker"
+
+		assert.Equal(t, "unix:///var/run/docker.sock", cfg.SocketPath())
+	})
+}
@@ -0,0 +1,201 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// Session is an interactive connection to a container process.
+// Writes go to the process's standard input; output is read with ReadOutput.
+type Session struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Write sends data to the standard input of the attached process.
+func (s *Session) Write(p []byte) (int, error) {
+	return s.conn.Write(p)
+}
+
+// ReadOutput reads the process output and calls fn for every line
+// until the connection is closed or fn returns an error.
+func (s *Session) ReadOutput(fn func(models.ContainerLogEntry) error) error {
+	return readLogStream(s.reader, fn)
+}
+
+// Close closes the session.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
+
+// ExecResult holds the output of a finished exec session.
+type ExecResult struct {
+	// ExitCode is the exit code of the command.
+	ExitCode int `json:"exitCode"`
+	// Stdout is the standard output of the command.
+	Stdout string `json:"stdout"`
+	// Stderr is the standard error of the command.
+	Stderr string `json:"stderr"`
+}
+
+// Attach attaches to the main process of a running container.
+// The container must have been created with an open standard input.
+func (s *apiClient) Attach(ctx context.Context, id string) (*Session, error) {
+	endpoint := fmt.Sprintf("%s/containers/%s/attach?stream=true&stdin=true&stdout=true&stderr=true", s.prefix, url.PathEscape(id))
+	conn, reader, err := s.hijack(ctx, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to container %s: %w", id, err)
+	}
+	return &Session{conn: conn, reader: reader}, nil
+}
+
+// ExecAttach starts a command in a running container and returns an
+// interactive session connected to its standard streams.
+func (s *apiClient) ExecAttach(ctx context.Context, id string, cmd []string) (*Session, error) {
+	execID, err := s.createExec(ctx, id, cmd, true)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, reader, err := s.startExec(ctx, execID)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{conn: conn, reader: reader}, nil
+}
+
+// Exec runs a command in a running container and waits for it to finish.
+func (s *apiClient) Exec(ctx context.Context, id string, cmd []string) (*ExecResult, error) {
+	execID, err := s.createExec(ctx, id, cmd, false)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, reader, err := s.startExec(ctx, execID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Close the connection when ctx ends so that reads do not block forever.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	var stdout, stderr strings.Builder
+	err = readLogStream(reader, func(entry models.ContainerLogEntry) error {
+		out := &stdout
+		if entry.Stream == "stderr" {
+			out = &stderr
+		}
+		out.WriteString(entry.Message)
+		out.WriteByte('\n')
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("failed to read exec output: %w", err)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	exitCode, err := s.execExitCode(ctx, execID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExecResult{
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// createExec creates an exec session in a container and returns its ID.
+func (s *apiClient) createExec(ctx context.Context, id string, cmd []string, stdin bool) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"AttachStdin":  stdin,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          cmd,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode exec config: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/containers/%s/exec", s.prefix, url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL(endpoint), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec in container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to create exec in container %s: status %d: %s", id, resp.StatusCode, string(respBody))
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// startExec starts an exec session and returns the hijacked connection.
+func (s *apiClient) startExec(ctx context.Context, execID string) (net.Conn, *bufio.Reader, error) {
+	body := []byte(`{"Detach":false,"Tty":false}`)
+	endpoint := fmt.Sprintf("%s/exec/%s/start", s.prefix, url.PathEscape(execID))
+	conn, reader, err := s.hijack(ctx, endpoint, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start exec %s: %w", execID, err)
+	}
+	return conn, reader, nil
+}
+
+// execExitCode returns the exit code of a finished exec session.
+func (s *apiClient) execExitCode(ctx context.Context, execID string) (int, error) {
+	endpoint := fmt.Sprintf("%s/exec/%s/json", s.prefix, url.PathEscape(execID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec %s: %w", execID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var data struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return data.ExitCode, nil
+}
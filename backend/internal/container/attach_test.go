@@ -0,0 +1,92 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// hijackHandler upgrades the connection and hands it to fn.
+func hijackHandler(t *testing.T, fn func(rw *bufio.ReadWriter)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "tcp", r.Header.Get("Upgrade"))
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		_ = rw.Flush()
+		fn(rw)
+	}
+}
+
+func TestService_Exec(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v5.0.0/libpod/containers/mc/exec", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Cmd []string `json:"Cmd"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"rcon-cli", "list"}, body.Cmd)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":"exec1"}`))
+	})
+	mux.HandleFunc("/v5.0.0/libpod/exec/exec1/start", hijackHandler(t, func(rw *bufio.ReadWriter) {
+		_, _ = rw.Write(frame(streamStdout, "There are 0 players online\n"))
+		_, _ = rw.Write(frame(streamStderr, "warning\n"))
+		_ = rw.Flush()
+	}))
+	mux.HandleFunc("/v5.0.0/libpod/exec/exec1/json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"ExitCode":3}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	svc := NewService(ts.URL)
+	result, err := svc.Exec(context.Background(), "mc", []string{"rcon-cli", "list"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "There are 0 players online\n", result.Stdout)
+	assert.Equal(t, "warning\n", result.Stderr)
+}
+
+func TestService_Attach(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v5.0.0/libpod/containers/mc/attach", hijackHandler(t, func(rw *bufio.ReadWriter) {
+		// Echo one line of input back as output
+		line, err := rw.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, _ = rw.Write(frame(streamStdout, "> "+line))
+		_ = rw.Flush()
+	}))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	svc := NewService(ts.URL)
+	session, err := svc.Attach(context.Background(), "mc")
+	require.NoError(t, err)
+	defer session.Close()
+
+	_, err = session.Write([]byte("say hello\n"))
+	require.NoError(t, err)
+
+	var entries []models.ContainerLogEntry
+	err = session.ReadOutput(func(entry models.ContainerLogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "stdout", entries[0].Stream)
+	assert.Equal(t, "> say hello", entries[0].Message)
+}
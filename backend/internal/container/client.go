@@ -0,0 +1,274 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// apiClient is an HTTP client for a container engine API listening on a
+// unix socket or TCP address. It is shared by the runtime implementations.
+type apiClient struct {
+	socketPath string
+	baseURL    string
+	// prefix is prepended to endpoints shared by the APIs, e.g. "/v5.0.0/libpod".
+	prefix string
+	client *http.Client
+	// streamClient has no overall timeout and is used for long-lived streams.
+	streamClient *http.Client
+	// dial opens a raw connection to the API, used for hijacked connections.
+	dial func(ctx context.Context) (net.Conn, error)
+}
+
+// ConnectOptions configures how a runtime connects to a remote API.
+// The zero value connects without client certificates or SSH keys.
+type ConnectOptions struct {
+	// TLSCACert is the PEM file of the CA that signed the API's certificate (https:// endpoints).
+	TLSCACert string
+	// TLSCert and TLSKey are the PEM files of the client certificate (https:// endpoints).
+	TLSCert string
+	TLSKey  string
+	// TLSInsecure skips verification of the API's certificate.
+	TLSInsecure bool
+	// SSHKeyPath is the private key used for ssh:// endpoints.
+	// The SSH agent at $SSH_AUTH_SOCK is used when empty.
+	SSHKeyPath string
+	// SSHKnownHosts is the known_hosts file ssh:// hosts are verified against.
+	// Defaults to ~/.ssh/known_hosts.
+	SSHKnownHosts string
+}
+
+// newAPIClient creates an API client for the specified socket path whose
+// shared endpoints start with prefix.
+// socketPath should be in the format "unix:///path/to/socket", "tcp://host:port",
+// "http(s)://host:port" or "ssh://user@host[:port]/path/to/socket".
+// Invalid TLS or SSH settings are reported when the API is first used.
+func newAPIClient(socketPath, prefix string, opts ConnectOptions) apiClient {
+	// Parse the socket path to determine the transport type
+	u, err := url.Parse(socketPath)
+	if err != nil {
+		// Default to unix socket
+		u = &url.URL{Scheme: "unix", Path: socketPath}
+	}
+
+	var transport *http.Transport
+	var baseURL string
+	var dial func(ctx context.Context) (net.Conn, error)
+
+	switch u.Scheme {
+	case "unix":
+		// Unix socket transport - use dummy host for URL
+		path := u.Path
+		if path == "" {
+			// Handle "unix:///path" format
+			path = u.Host + u.Path
+		}
+		dial = func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		baseURL = "http://d" // Dummy host for unix socket
+	case "ssh":
+		// The API socket is reached through a connection to the remote host
+		if tunnel, err := newSSHTunnel(u, opts); err != nil {
+			dial = failingDialer(err)
+		} else {
+			dial = tunnel.dial
+		}
+		baseURL = "http://" + u.Hostname()
+	case "https":
+		baseURL = strings.TrimSuffix(socketPath, "/")
+		if tlsConfig, err := clientTLSConfig(u.Hostname(), opts); err != nil {
+			dial = failingDialer(err)
+		} else {
+			dial = tlsDialer(baseURL, tlsConfig)
+		}
+		// The dialer already performs the TLS handshake
+		transport = &http.Transport{
+			DialTLSContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dial(ctx)
+			},
+		}
+	case "http", "tcp":
+		// TCP/HTTP transport - use actual URL
+		transport = &http.Transport{}
+		if u.Scheme == "tcp" {
+			baseURL = "http://" + u.Host
+		} else {
+			baseURL = strings.TrimSuffix(socketPath, "/")
+		}
+	default:
+		// Assume http URL
+		transport = &http.Transport{}
+		baseURL = strings.TrimSuffix(socketPath, "/")
+	}
+
+	if transport == nil {
+		transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dial(ctx)
+			},
+		}
+	}
+	if dial == nil {
+		dial = tcpDialer(baseURL)
+	}
+
+	return apiClient{
+		socketPath: socketPath,
+		baseURL:    baseURL,
+		prefix:     prefix,
+		dial:       dial,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+		streamClient: &http.Client{
+			Transport: transport,
+		},
+	}
+}
+
+// failingDialer returns a dial function that always fails with err.
+func failingDialer(err error) func(ctx context.Context) (net.Conn, error) {
+	return func(context.Context) (net.Conn, error) {
+		return nil, err
+	}
+}
+
+// clientTLSConfig builds the TLS configuration for an https:// endpoint.
+func clientTLSConfig(serverName string, opts ConnectOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: opts.TLSInsecure,
+	}
+	if opts.TLSCACert != "" {
+		pem, err := os.ReadFile(opts.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.TLSCACert)
+		}
+		config.RootCAs = pool
+	}
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCert, opts.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// tlsDialer returns a dial function for an https:// base URL using config.
+func tlsDialer(baseURL string, config *tls.Config) func(ctx context.Context) (net.Conn, error) {
+	return func(ctx context.Context) (net.Conn, error) {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		d := tls.Dialer{Config: config}
+		return d.DialContext(ctx, "tcp", host)
+	}
+}
+
+// tcpDialer returns a dial function for the host of an HTTP(S) base URL.
+func tcpDialer(baseURL string) func(ctx context.Context) (net.Conn, error) {
+	return func(ctx context.Context) (net.Conn, error) {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		host := u.Host
+		if u.Port() == "" {
+			if u.Scheme == "https" {
+				host = net.JoinHostPort(u.Hostname(), "443")
+			} else {
+				host = net.JoinHostPort(u.Hostname(), "80")
+			}
+		}
+
+		if u.Scheme == "https" {
+			d := tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+			return d.DialContext(ctx, "tcp", host)
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", host)
+	}
+}
+
+// Host returns the host name that published container ports are reachable on.
+// It is the API host for remote endpoints and the loopback address for unix sockets.
+func (s *apiClient) Host() string {
+	u, err := url.Parse(s.baseURL)
+	if err != nil || u.Hostname() == "" || u.Hostname() == "d" {
+		return "127.0.0.1"
+	}
+	return u.Hostname()
+}
+
+// apiURL constructs the full URL for an API endpoint.
+func (s *apiClient) apiURL(path string) string {
+	return s.baseURL + path
+}
+
+// hijack sends a POST request asking the API to upgrade the connection to a
+// raw stream, and returns the connection once the API accepted it.
+func (s *apiClient) hijack(ctx context.Context, endpoint string, body []byte) (net.Conn, *bufio.Reader, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL(endpoint), bytes.NewReader(body))
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusSwitchingProtocols:
+		return conn, reader, nil
+	case http.StatusOK:
+		// The API did not upgrade the connection and streams the output as the response body.
+		return conn, bufio.NewReader(resp.Body), nil
+	default:
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		conn.Close()
+		return nil, nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+}
@@ -0,0 +1,541 @@
+// Package containertest provides an in-memory container runtime for tests.
+package containertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// Runtime is an in-memory container.Runtime.
+// Every call is recorded in Calls, and a method fails with the error in Errors
+// registered under its name (e.g. "Start") or with the error of its context.
+type Runtime struct {
+	mu sync.Mutex
+
+	// Containers holds the containers by ID.
+	Containers map[string]*models.Container
+	// Settings holds the resource limits and restart policy of the containers by ID.
+	Settings map[string]container.UpdateOptions
+	// CreateOptions holds the options the containers were created with by ID.
+	CreateOptions map[string]container.CreateOptions
+	// Volumes holds the labels of the named volumes by name.
+	Volumes map[string]map[string]string
+	// Images holds the images by reference or ID.
+	Images map[string]*models.Image
+	// LogEntries holds the log lines of the containers by ID.
+	LogEntries map[string][]models.ContainerLogEntry
+	// StatSamples holds the stats returned for the containers by ID.
+	StatSamples map[string]models.ContainerStats
+	// QueuedEvents are delivered to Events subscribers.
+	QueuedEvents []models.ContainerEvent
+
+	// Calls records the calls made, e.g. "Start container1".
+	Calls []string
+	// Errors makes the named methods fail with the given error.
+	Errors map[string]error
+	// CreateID is the ID returned by Create. IDs are generated when empty.
+	CreateID string
+	// ExecFunc handles Exec calls. Exec fails with container.ErrNotSupported when nil.
+	ExecFunc func(id string, cmd []string) (*container.ExecResult, error)
+	// HostName is returned by Host.
+	HostName string
+	// HostInfo is returned by Info.
+	HostInfo models.HostInfo
+
+	nextID int
+}
+
+var _ container.Runtime = (*Runtime)(nil)
+
+// New returns an empty runtime.
+func New() *Runtime {
+	return &Runtime{
+		Containers:    make(map[string]*models.Container),
+		Settings:      make(map[string]container.UpdateOptions),
+		CreateOptions: make(map[string]container.CreateOptions),
+		Volumes:       make(map[string]map[string]string),
+		Images:        make(map[string]*models.Image),
+		LogEntries:    make(map[string][]models.ContainerLogEntry),
+		StatSamples:   make(map[string]models.ContainerStats),
+		Errors:        make(map[string]error),
+		HostName:      "localhost",
+	}
+}
+
+// record records a call and returns the error registered for method, or the
+// context's error once ctx is done like a request to a real engine.
+// The caller must hold mu.
+func (r *Runtime) record(ctx context.Context, method string, args ...any) error {
+	call := method
+	for _, a := range args {
+		call += fmt.Sprintf(" %v", a)
+	}
+	r.Calls = append(r.Calls, call)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Errors[method]
+}
+
+// CallLog returns a copy of the recorded calls.
+func (r *Runtime) CallLog() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.Calls...)
+}
+
+// find returns a container by ID or name. The caller must hold mu.
+func (r *Runtime) find(id string) (*models.Container, error) {
+	if c, ok := r.Containers[id]; ok {
+		return c, nil
+	}
+	for _, c := range r.Containers {
+		if c.Name == id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("container %s not found", id)
+}
+
+// Host returns HostName.
+func (r *Runtime) Host() string {
+	return r.HostName
+}
+
+// Info returns HostInfo.
+func (r *Runtime) Info(ctx context.Context) (*models.HostInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Info"); err != nil {
+		return nil, err
+	}
+	info := r.HostInfo
+	return &info, nil
+}
+
+// List returns all containers.
+func (r *Runtime) List(ctx context.Context) ([]models.Container, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+	result := make([]models.Container, 0, len(r.Containers))
+	for _, c := range r.Containers {
+		result = append(result, clone(c))
+	}
+	return result, nil
+}
+
+// clone copies a container so that callers cannot change the stored one.
+func clone(c *models.Container) models.Container {
+	result := *c
+	result.Labels = maps.Clone(c.Labels)
+	result.Env = maps.Clone(c.Env)
+	return result
+}
+
+// Get returns a container by ID or name.
+func (r *Runtime) Get(ctx context.Context, id string) (*models.Container, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Get", id); err != nil {
+		return nil, err
+	}
+	c, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	result := clone(c)
+	return &result, nil
+}
+
+// Create adds a created container.
+func (r *Runtime) Create(ctx context.Context, opts container.CreateOptions) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Create", opts.Name); err != nil {
+		return "", err
+	}
+	id := r.CreateID
+	if id == "" {
+		r.nextID++
+		id = fmt.Sprintf("container%d", r.nextID)
+	}
+	r.Containers[id] = &models.Container{
+		ID:      id,
+		Name:    opts.Name,
+		Image:   opts.Image,
+		State:   models.StateCreated,
+		Created: time.Now(),
+		Ports:   opts.Ports,
+		Labels:  opts.Labels,
+		Env:     opts.Env,
+	}
+	r.Settings[id] = container.UpdateOptions{Resources: opts.Resources, RestartPolicy: opts.RestartPolicy}
+	r.CreateOptions[id] = opts
+	return id, nil
+}
+
+// Update replaces the settings of a container.
+func (r *Runtime) Update(ctx context.Context, id string, opts container.UpdateOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Update", id); err != nil {
+		return err
+	}
+	c, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	r.Settings[c.ID] = opts
+	return nil
+}
+
+// Start marks a container as running.
+func (r *Runtime) Start(ctx context.Context, id string) error {
+	return r.setState(ctx, "Start", id, models.StateRunning)
+}
+
+// Stop marks a container as exited.
+func (r *Runtime) Stop(ctx context.Context, id string, _ uint) error {
+	return r.setState(ctx, "Stop", id, models.StateExited)
+}
+
+func (r *Runtime) setState(ctx context.Context, method, id string, state models.ContainerState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, method, id); err != nil {
+		return err
+	}
+	c, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	c.State = state
+	return nil
+}
+
+// Wait returns the exit code of a stopped container immediately.
+func (r *Runtime) Wait(ctx context.Context, id string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Wait", id); err != nil {
+		return 0, err
+	}
+	c, err := r.find(id)
+	if err != nil {
+		return 0, err
+	}
+	return c.ExitCode, nil
+}
+
+// Remove deletes a container. Removing a missing container succeeds.
+func (r *Runtime) Remove(ctx context.Context, id string, _ bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Remove", id); err != nil {
+		return err
+	}
+	if c, err := r.find(id); err == nil {
+		delete(r.Containers, c.ID)
+	}
+	return nil
+}
+
+// Rename changes the name of a container.
+func (r *Runtime) Rename(ctx context.Context, id, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Rename", id, name); err != nil {
+		return err
+	}
+	c, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	c.Name = name
+	return nil
+}
+
+// Logs returns the last lines of the container's LogEntries.
+func (r *Runtime) Logs(ctx context.Context, id string, lines int) ([]models.ContainerLogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Logs", id); err != nil {
+		return nil, err
+	}
+	entries := r.LogEntries[id]
+	if lines > 0 && len(entries) > lines {
+		entries = entries[len(entries)-lines:]
+	}
+	return append([]models.ContainerLogEntry{}, entries...), nil
+}
+
+// StreamLogs calls fn with the container's LogEntries and returns.
+func (r *Runtime) StreamLogs(ctx context.Context, id string, lines int, fn func(models.ContainerLogEntry) error) error {
+	entries, err := r.Logs(ctx, id, lines)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Attach is not supported by the fake runtime.
+func (r *Runtime) Attach(ctx context.Context, id string) (*container.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Attach", id); err != nil {
+		return nil, err
+	}
+	return nil, container.ErrNotSupported
+}
+
+// ExecAttach is not supported by the fake runtime.
+func (r *Runtime) ExecAttach(ctx context.Context, id string, _ []string) (*container.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "ExecAttach", id); err != nil {
+		return nil, err
+	}
+	return nil, container.ErrNotSupported
+}
+
+// Exec runs ExecFunc.
+func (r *Runtime) Exec(ctx context.Context, id string, cmd []string) (*container.ExecResult, error) {
+	r.mu.Lock()
+	err := r.record(ctx, "Exec", id)
+	exec := r.ExecFunc
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if exec == nil {
+		return nil, container.ErrNotSupported
+	}
+	return exec(id, cmd)
+}
+
+// Stats returns the container's StatSamples.
+func (r *Runtime) Stats(ctx context.Context, id string) (*models.ContainerStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "Stats", id); err != nil {
+		return nil, err
+	}
+	stats, ok := r.StatSamples[id]
+	if !ok {
+		return nil, fmt.Errorf("no stats for container %s", id)
+	}
+	stats.Timestamp = time.Now()
+	return &stats, nil
+}
+
+// StreamStats calls fn with the container's StatSamples every interval until ctx is canceled.
+func (r *Runtime) StreamStats(ctx context.Context, id string, interval time.Duration, fn func(models.ContainerStats) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stats, err := r.Stats(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(*stats); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Events delivers the QueuedEvents and then blocks until ctx is canceled.
+func (r *Runtime) Events(ctx context.Context, fn func(models.ContainerEvent) error) error {
+	r.mu.Lock()
+	err := r.record(ctx, "Events")
+	events := r.QueuedEvents
+	r.QueuedEvents = nil
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// VolumeExists reports whether a volume is in Volumes.
+func (r *Runtime) VolumeExists(ctx context.Context, name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "VolumeExists", name); err != nil {
+		return false, err
+	}
+	_, ok := r.Volumes[name]
+	return ok, nil
+}
+
+// CreateVolume adds a volume.
+func (r *Runtime) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "CreateVolume", name); err != nil {
+		return err
+	}
+	r.Volumes[name] = labels
+	return nil
+}
+
+// EnsureVolume adds a volume unless it exists.
+func (r *Runtime) EnsureVolume(ctx context.Context, name string, labels map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "EnsureVolume", name); err != nil {
+		return err
+	}
+	if _, ok := r.Volumes[name]; !ok {
+		r.Volumes[name] = labels
+	}
+	return nil
+}
+
+// RemoveVolume deletes a volume.
+func (r *Runtime) RemoveVolume(ctx context.Context, name string, _ bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "RemoveVolume", name); err != nil {
+		return err
+	}
+	delete(r.Volumes, name)
+	return nil
+}
+
+// ExportVolume writes the volume name to w in place of an archive.
+func (r *Runtime) ExportVolume(ctx context.Context, name string, w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "ExportVolume", name); err != nil {
+		return err
+	}
+	if _, ok := r.Volumes[name]; !ok {
+		return fmt.Errorf("volume %s not found", name)
+	}
+	_, err := io.WriteString(w, name)
+	return err
+}
+
+// ListImages returns the distinct images in Images.
+func (r *Runtime) ListImages(ctx context.Context) ([]models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "ListImages"); err != nil {
+		return nil, err
+	}
+	seen := make(map[*models.Image]bool)
+	result := []models.Image{}
+	for _, i := range r.Images {
+		if !seen[i] {
+			seen[i] = true
+			result = append(result, *i)
+		}
+	}
+	return result, nil
+}
+
+// GetImage returns an image by reference or ID.
+func (r *Runtime) GetImage(ctx context.Context, name string) (*models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "GetImage", name); err != nil {
+		return nil, err
+	}
+	i, ok := r.Images[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", container.ErrImageNotFound, name)
+	}
+	result := *i
+	return &result, nil
+}
+
+// PullImage adds the image to Images unless it is already present and returns its ID.
+func (r *Runtime) PullImage(ctx context.Context, reference string, fn func(models.ImagePullProgress) error) (string, error) {
+	r.mu.Lock()
+	err := r.record(ctx, "PullImage", reference)
+	image, ok := r.Images[reference]
+	if err == nil && !ok {
+		image = &models.Image{ID: "sha256:" + reference, Tags: []string{reference}, Digests: []string{}, Created: time.Now()}
+		r.Images[reference] = image
+		r.Images[image.ID] = image
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if fn != nil {
+		if err := fn(models.ImagePullProgress{Message: "Pulled " + reference}); err != nil {
+			return "", err
+		}
+	}
+	return image.ID, nil
+}
+
+// RemoveImage deletes an image and every reference to it. Images used by
+// containers are only removed with force.
+func (r *Runtime) RemoveImage(ctx context.Context, name string, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "RemoveImage", name); err != nil {
+		return err
+	}
+	image, ok := r.Images[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", container.ErrImageNotFound, name)
+	}
+	if image.Containers > 0 && !force {
+		return fmt.Errorf("%w: %s", container.ErrImageInUse, name)
+	}
+	for k, i := range r.Images {
+		if i == image {
+			delete(r.Images, k)
+		}
+	}
+	return nil
+}
+
+// PruneImages deletes the dangling images.
+func (r *Runtime) PruneImages(ctx context.Context) (*models.ImagePruneReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record(ctx, "PruneImages"); err != nil {
+		return nil, err
+	}
+	report := &models.ImagePruneReport{Deleted: []string{}}
+	for k, i := range r.Images {
+		if i.Dangling {
+			delete(r.Images, k)
+			if k == i.ID {
+				report.Deleted = append(report.Deleted, i.ID)
+				report.ReclaimedSpace += uint64(i.Size)
+			}
+		}
+	}
+	return report, nil
+}
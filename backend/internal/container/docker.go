@@ -0,0 +1,498 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// dockerAPIPrefix is the Docker Engine API version used for all requests.
+const dockerAPIPrefix = "/v1.43"
+
+// DockerService is the Docker Engine implementation of Runtime.
+// Logs, attach and exec share the implementation with Podman, whose
+// compatible endpoints follow the same protocol.
+type DockerService struct {
+	apiClient
+}
+
+// NewDockerService creates a new Docker container service with the specified socket path.
+// socketPath should be in the format "unix:///var/run/docker.sock", "tcp://host:port", or "http://host:port".
+func NewDockerService(socketPath string) *DockerService {
+	return &DockerService{apiClient: newAPIClient(socketPath, dockerAPIPrefix, ConnectOptions{})}
+}
+
+// call sends a request to the Docker API and returns the response if its
+// status is one of ok. Otherwise the response body is reported in the error.
+func (d *DockerService) call(ctx context.Context, client *http.Client, method, endpoint string, body any, ok ...int) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.apiURL(dockerAPIPrefix+endpoint), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(ok, resp.StatusCode) {
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp, fmt.Errorf("status %d: %s", resp.StatusCode, string(data))
+	}
+	return resp, nil
+}
+
+// decode reads a JSON response body into v and closes it.
+func decode(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// dockerListContainer represents a container in the Docker list response.
+type dockerListContainer struct {
+	ID      string   `json:"Id"`
+	Names   []string `json:"Names"`
+	Image   string   `json:"Image"`
+	State   string   `json:"State"`
+	Status  string   `json:"Status"`
+	Created int64    `json:"Created"`
+	Ports   []struct {
+		IP          string `json:"IP"`
+		PrivatePort uint16 `json:"PrivatePort"`
+		PublicPort  uint16 `json:"PublicPort"`
+		Type        string `json:"Type"`
+	} `json:"Ports"`
+	Labels map[string]string `json:"Labels"`
+}
+
+func (c *dockerListContainer) toModel() models.Container {
+	name := ""
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	ports := make([]models.PortMapping, 0, len(c.Ports))
+	for _, p := range c.Ports {
+		if p.PublicPort == 0 {
+			continue
+		}
+		ports = append(ports, models.PortMapping{
+			HostIP:        p.IP,
+			HostPort:      p.PublicPort,
+			ContainerPort: p.PrivatePort,
+			Protocol:      p.Type,
+		})
+	}
+
+	return models.Container{
+		ID:      c.ID,
+		Name:    name,
+		Image:   c.Image,
+		State:   mapState(c.State),
+		Status:  c.Status,
+		Created: time.Unix(c.Created, 0),
+		Ports:   ports,
+		Labels:  c.Labels,
+	}
+}
+
+// dockerCreateSpec is the subset of the Docker container config used to create containers.
+type dockerCreateSpec struct {
+	Image        string              `json:"Image"`
+	Env          []string            `json:"Env,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	OpenStdin    bool                `json:"OpenStdin,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	HostConfig   struct {
+		dockerResources
+		PortBindings  map[string][]dockerPortBinding `json:"PortBindings,omitempty"`
+		Mounts        []dockerMount                  `json:"Mounts,omitempty"`
+		RestartPolicy *dockerRestartPolicy           `json:"RestartPolicy,omitempty"`
+	} `json:"HostConfig"`
+}
+
+// dockerResources holds the resource limits of a Docker container. Zero values mean unlimited.
+type dockerResources struct {
+	Memory     int64 `json:"Memory"`
+	MemorySwap int64 `json:"MemorySwap,omitempty"`
+	NanoCpus   int64 `json:"NanoCpus"`
+	CPUShares  int64 `json:"CpuShares,omitempty"`
+	PidsLimit  int64 `json:"PidsLimit"`
+}
+
+type dockerRestartPolicy struct {
+	Name string `json:"Name"`
+}
+
+// newDockerResources converts limits to Docker resources.
+func newDockerResources(limits models.ResourceLimits) dockerResources {
+	r := dockerResources{
+		Memory:    int64(limits.MemoryMB) << 20,
+		NanoCpus:  int64(limits.CPUs * 1e9),
+		CPUShares: int64(limits.CPUShares),
+		PidsLimit: limits.PidsLimit,
+	}
+	if r.Memory > 0 {
+		// Without a swap limit Docker rejects raising the memory limit above the current swap limit
+		r.MemorySwap = -1
+	}
+	return r
+}
+
+type dockerPortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type dockerMount struct {
+	Type   string `json:"Type"`
+	Source string `json:"Source"`
+	Target string `json:"Target"`
+}
+
+// List returns all containers.
+func (d *DockerService) List(ctx context.Context) ([]models.Container, error) {
+	resp, err := d.call(ctx, d.client, http.MethodGet, "/containers/json?all=true", nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var dockerContainers []dockerListContainer
+	if err := decode(resp, &dockerContainers); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Container, 0, len(dockerContainers))
+	for _, c := range dockerContainers {
+		result = append(result, c.toModel())
+	}
+	return result, nil
+}
+
+// Get returns a specific container by ID or name.
+func (d *DockerService) Get(ctx context.Context, id string) (*models.Container, error) {
+	resp, err := d.call(ctx, d.client, http.MethodGet, fmt.Sprintf("/containers/%s/json", url.PathEscape(id)), nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container %s: %w", id, err)
+	}
+
+	// Docker inspect data has the same layout as Podman's compatible format
+	var data podmanInspectData
+	if err := decode(resp, &data); err != nil {
+		return nil, err
+	}
+
+	c := data.toModel()
+	c.Name = strings.TrimPrefix(c.Name, "/")
+	return &c, nil
+}
+
+// Create creates a new container and returns its ID.
+// The container is created but not started.
+func (d *DockerService) Create(ctx context.Context, opts CreateOptions) (string, error) {
+	spec := dockerCreateSpec{
+		Image:     opts.Image,
+		Labels:    opts.Labels,
+		OpenStdin: opts.Stdin,
+	}
+	spec.HostConfig.dockerResources = newDockerResources(opts.Resources)
+	if opts.RestartPolicy != "" {
+		spec.HostConfig.RestartPolicy = &dockerRestartPolicy{Name: opts.RestartPolicy}
+	}
+	for k, v := range opts.Env {
+		spec.Env = append(spec.Env, k+"="+v)
+	}
+	sort.Strings(spec.Env)
+	for _, p := range opts.Ports {
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		key := fmt.Sprintf("%d/%s", p.ContainerPort, protocol)
+		if spec.ExposedPorts == nil {
+			spec.ExposedPorts = make(map[string]struct{})
+			spec.HostConfig.PortBindings = make(map[string][]dockerPortBinding)
+		}
+		spec.ExposedPorts[key] = struct{}{}
+		spec.HostConfig.PortBindings[key] = append(spec.HostConfig.PortBindings[key], dockerPortBinding{
+			HostIP:   p.HostIP,
+			HostPort: fmt.Sprint(p.HostPort),
+		})
+	}
+	for _, v := range opts.Volumes {
+		spec.HostConfig.Mounts = append(spec.HostConfig.Mounts, dockerMount{
+			Type:   "volume",
+			Source: v.Name,
+			Target: v.Dest,
+		})
+	}
+
+	endpoint := "/containers/create"
+	if opts.Name != "" {
+		endpoint += "?name=" + url.QueryEscape(opts.Name)
+	}
+	resp, err := d.call(ctx, d.client, http.MethodPost, endpoint, spec, http.StatusCreated, http.StatusOK)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container %s: %w", opts.Name, err)
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decode(resp, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// Update changes the resource limits and restart policy of a container,
+// applying them immediately if it is running. Docker leaves memory and CPU
+// limits unchanged when they are 0, so removing them takes effect when the
+// container is recreated.
+func (d *DockerService) Update(ctx context.Context, id string, opts UpdateOptions) error {
+	policy := opts.RestartPolicy
+	if policy == "" {
+		policy = models.RestartPolicyNo
+	}
+	body := struct {
+		dockerResources
+		RestartPolicy dockerRestartPolicy `json:"RestartPolicy"`
+	}{newDockerResources(opts.Resources), dockerRestartPolicy{Name: policy}}
+	if body.PidsLimit == 0 {
+		body.PidsLimit = -1
+	}
+
+	resp, err := d.call(ctx, d.client, http.MethodPost, fmt.Sprintf("/containers/%s/update", url.PathEscape(id)), body, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to update container %s: %w", id, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Start starts a container by ID or name.
+func (d *DockerService) Start(ctx context.Context, id string) error {
+	// 304 means already started, which is acceptable
+	resp, err := d.call(ctx, d.client, http.MethodPost, fmt.Sprintf("/containers/%s/start", url.PathEscape(id)), nil,
+		http.StatusNoContent, http.StatusOK, http.StatusNotModified)
+	if err != nil {
+		return fmt.Errorf("failed to start container %s: %w", id, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Stop stops a container by ID or name with optional timeout in seconds.
+func (d *DockerService) Stop(ctx context.Context, id string, timeout uint) error {
+	endpoint := fmt.Sprintf("/containers/%s/stop", url.PathEscape(id))
+	if timeout > 0 {
+		endpoint = fmt.Sprintf("%s?t=%d", endpoint, timeout)
+	}
+
+	// 304 means already stopped, which is acceptable
+	resp, err := d.call(ctx, d.client, http.MethodPost, endpoint, nil, http.StatusNoContent, http.StatusOK, http.StatusNotModified)
+	if err != nil {
+		return fmt.Errorf("failed to stop container %s: %w", id, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Wait blocks until a container has stopped and returns its exit code.
+func (d *DockerService) Wait(ctx context.Context, id string) (int, error) {
+	endpoint := fmt.Sprintf("/containers/%s/wait?condition=not-running", url.PathEscape(id))
+	resp, err := d.call(ctx, d.streamClient, http.MethodPost, endpoint, nil, http.StatusOK)
+	if err != nil {
+		return 0, fmt.Errorf("failed to wait for container %s: %w", id, err)
+	}
+
+	var result struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	if err := decode(resp, &result); err != nil {
+		return 0, err
+	}
+	return result.StatusCode, nil
+}
+
+// Remove removes a container by ID or name.
+// When force is true, a running container is killed before removal.
+func (d *DockerService) Remove(ctx context.Context, id string, force bool) error {
+	// 404 means already removed, which is acceptable
+	endpoint := fmt.Sprintf("/containers/%s?force=%t", url.PathEscape(id), force)
+	resp, err := d.call(ctx, d.client, http.MethodDelete, endpoint, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return fmt.Errorf("failed to remove container %s: %w", id, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Rename changes the name of a container.
+func (d *DockerService) Rename(ctx context.Context, id, name string) error {
+	endpoint := fmt.Sprintf("/containers/%s/rename?name=%s", url.PathEscape(id), url.QueryEscape(name))
+	resp, err := d.call(ctx, d.client, http.MethodPost, endpoint, nil, http.StatusNoContent, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to rename container %s: %w", id, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// VolumeExists reports whether a named volume exists.
+func (d *DockerService) VolumeExists(ctx context.Context, name string) (bool, error) {
+	resp, err := d.call(ctx, d.client, http.MethodGet, "/volumes/"+url.PathEscape(name), nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return false, fmt.Errorf("failed to check volume %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// CreateVolume creates a named volume with the given labels.
+func (d *DockerService) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	body := map[string]any{"Name": name, "Labels": labels}
+	resp, err := d.call(ctx, d.client, http.MethodPost, "/volumes/create", body, http.StatusCreated, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to create volume %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// EnsureVolume creates a named volume unless it already exists.
+func (d *DockerService) EnsureVolume(ctx context.Context, name string, labels map[string]string) error {
+	exists, err := d.VolumeExists(ctx, name)
+	if err != nil || exists {
+		return err
+	}
+	return d.CreateVolume(ctx, name, labels)
+}
+
+// RemoveVolume removes a named volume.
+func (d *DockerService) RemoveVolume(ctx context.Context, name string, force bool) error {
+	// 404 means already removed, which is acceptable
+	endpoint := fmt.Sprintf("/volumes/%s?force=%t", url.PathEscape(name), force)
+	resp, err := d.call(ctx, d.client, http.MethodDelete, endpoint, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// dockerVolumeUser is a container in the Docker list response with its mounts.
+type dockerVolumeUser struct {
+	ID     string `json:"Id"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Name        string `json:"Name"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+}
+
+// ExportVolume writes the contents of a named volume to w as a tar archive.
+// The Docker Engine API cannot export volumes, so the volume is read through
+// the archive endpoint of a container mounting it, such as the stopped
+// container of its game server.
+func (d *DockerService) ExportVolume(ctx context.Context, name string, w io.Writer) error {
+	filters, err := json.Marshal(map[string][]string{"volume": {name}})
+	if err != nil {
+		return fmt.Errorf("failed to encode filters: %w", err)
+	}
+	resp, err := d.call(ctx, d.client, http.MethodGet, "/containers/json?all=true&filters="+url.QueryEscape(string(filters)), nil, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to export volume %s: %w", name, err)
+	}
+	var users []dockerVolumeUser
+	if err := decode(resp, &users); err != nil {
+		return err
+	}
+
+	var id, dest string
+	for _, user := range users {
+		for _, m := range user.Mounts {
+			if m.Type == "volume" && m.Name == name {
+				id, dest = user.ID, m.Destination
+				break
+			}
+		}
+		if id != "" {
+			break
+		}
+	}
+	if id == "" {
+		return fmt.Errorf("failed to export volume %s: no container mounts it", name)
+	}
+
+	// Volumes can be large, so do not apply the default timeout
+	endpoint := fmt.Sprintf("/containers/%s/archive?path=%s", url.PathEscape(id), url.QueryEscape(dest))
+	resp, err = d.call(ctx, d.streamClient, http.MethodGet, endpoint, nil, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to export volume %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if err := stripArchiveRoot(resp.Body, w); err != nil {
+		return fmt.Errorf("failed to export volume %s: %w", name, err)
+	}
+	return nil
+}
+
+// stripArchiveRoot copies a tar archive of a directory without the directory
+// itself, so that the archive holds the volume contents at its root like a
+// Podman volume export.
+func stripArchiveRoot(r io.Reader, w io.Writer) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		_, rest, _ := strings.Cut(hdr.Name, "/")
+		if rest == "" {
+			continue
+		}
+		hdr.Name = rest
+		if hdr.Typeflag == tar.TypeLink {
+			_, hdr.Linkname, _ = strings.Cut(hdr.Linkname, "/")
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,235 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// dockerImageSummary is a Docker image list entry.
+type dockerImageSummary struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     int64    `json:"Created"`
+	Size        int64    `json:"Size"`
+	Containers  int      `json:"Containers"`
+}
+
+func (i *dockerImageSummary) toModel() models.Image {
+	tags := dockerTags(i.RepoTags)
+	return models.Image{
+		ID:         i.ID,
+		Digest:     firstDigest(i.RepoDigests),
+		Tags:       tags,
+		Digests:    nonNil(i.RepoDigests),
+		Size:       i.Size,
+		Created:    time.Unix(i.Created, 0),
+		Dangling:   len(tags) == 0,
+		Containers: max(i.Containers, 0),
+	}
+}
+
+// dockerImageInspect is the subset of a Docker image inspect response we use.
+type dockerImageInspect struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     string   `json:"Created"`
+	Size        int64    `json:"Size"`
+}
+
+func (i *dockerImageInspect) toModel() models.Image {
+	created, _ := time.Parse(time.RFC3339Nano, i.Created)
+	tags := dockerTags(i.RepoTags)
+	return models.Image{
+		ID:       i.ID,
+		Digest:   firstDigest(i.RepoDigests),
+		Tags:     tags,
+		Digests:  nonNil(i.RepoDigests),
+		Size:     i.Size,
+		Created:  created,
+		Dangling: len(tags) == 0,
+	}
+}
+
+// dockerPullMessage is a line of the Docker image pull stream.
+type dockerPullMessage struct {
+	Status   string `json:"status"`
+	ID       string `json:"id"`
+	Progress string `json:"progress"`
+	Error    string `json:"error"`
+}
+
+// dockerTags returns the tags of an image without the "<none>:<none>"
+// placeholder Docker reports for untagged images.
+func dockerTags(tags []string) []string {
+	result := []string{}
+	for _, t := range tags {
+		if t != "<none>:<none>" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// firstDigest returns the manifest digest of the first repository digest.
+func firstDigest(repoDigests []string) string {
+	if len(repoDigests) == 0 {
+		return ""
+	}
+	_, digest, _ := strings.Cut(repoDigests[0], "@")
+	return digest
+}
+
+// splitTag splits a reference into its name and tag or digest.
+// A reference without either resolves to the "latest" tag.
+func splitTag(reference string) (string, string) {
+	if name, digest, ok := strings.Cut(reference, "@"); ok {
+		return name, digest
+	}
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i], reference[i+1:]
+	}
+	return reference, "latest"
+}
+
+// ListImages returns all images in the local store.
+func (d *DockerService) ListImages(ctx context.Context) ([]models.Image, error) {
+	resp, err := d.call(ctx, d.client, http.MethodGet, "/images/json", nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	var summaries []dockerImageSummary
+	if err := decode(resp, &summaries); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Image, 0, len(summaries))
+	for _, i := range summaries {
+		result = append(result, i.toModel())
+	}
+	return result, nil
+}
+
+// GetImage returns an image by ID or reference.
+func (d *DockerService) GetImage(ctx context.Context, name string) (*models.Image, error) {
+	endpoint := fmt.Sprintf("/images/%s/json", url.PathEscape(name))
+	resp, err := d.call(ctx, d.client, http.MethodGet, endpoint, nil, http.StatusOK)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", name, err)
+	}
+
+	var data dockerImageInspect
+	if err := decode(resp, &data); err != nil {
+		return nil, err
+	}
+
+	image := data.toModel()
+	return &image, nil
+}
+
+// PullImage pulls an image from its registry and calls fn with every progress
+// message. It returns the ID of the pulled image.
+func (d *DockerService) PullImage(ctx context.Context, reference string, fn func(models.ImagePullProgress) error) (string, error) {
+	// Without an explicit tag Docker pulls every tag of the repository
+	name, tag := splitTag(reference)
+
+	endpoint := fmt.Sprintf("/images/create?fromImage=%s&tag=%s", url.QueryEscape(name), url.QueryEscape(tag))
+	// Large images take far longer than the default timeout
+	resp, err := d.call(ctx, d.streamClient, http.MethodPost, endpoint, nil, http.StatusOK)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull image %s: %w", reference, err)
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg dockerPullMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+
+		if msg.Error != "" {
+			return "", fmt.Errorf("failed to pull image %s: %s", reference, msg.Error)
+		}
+		text := strings.TrimSpace(strings.Join([]string{msg.ID, msg.Status, msg.Progress}, " "))
+		if text != "" && fn != nil {
+			if err := fn(models.ImagePullProgress{Message: text}); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// The pull stream does not carry the image ID, so look it up
+	pulled := name + ":" + tag
+	if strings.Contains(tag, ":") {
+		pulled = name + "@" + tag
+	}
+	image, err := d.GetImage(ctx, pulled)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull image %s: %w", reference, err)
+	}
+	return image.ID, nil
+}
+
+// RemoveImage removes an image. Images used by containers are only removed when force is set.
+func (d *DockerService) RemoveImage(ctx context.Context, name string, force bool) error {
+	endpoint := fmt.Sprintf("/images/%s?force=%t", url.PathEscape(name), force)
+	resp, err := d.call(ctx, d.client, http.MethodDelete, endpoint, nil, http.StatusOK, http.StatusNoContent)
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %s", ErrImageNotFound, name)
+		case http.StatusConflict:
+			return fmt.Errorf("%w: %s", ErrImageInUse, name)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove image %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PruneImages removes dangling images.
+func (d *DockerService) PruneImages(ctx context.Context) (*models.ImagePruneReport, error) {
+	endpoint := "/images/prune?filters=" + url.QueryEscape(`{"dangling":["true"]}`)
+	resp, err := d.call(ctx, d.client, http.MethodPost, endpoint, nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune images: %w", err)
+	}
+
+	var report struct {
+		ImagesDeleted []struct {
+			Deleted string `json:"Deleted"`
+		} `json:"ImagesDeleted"`
+		SpaceReclaimed uint64 `json:"SpaceReclaimed"`
+	}
+	if err := decode(resp, &report); err != nil {
+		return nil, err
+	}
+
+	result := &models.ImagePruneReport{Deleted: []string{}, ReclaimedSpace: report.SpaceReclaimed}
+	for _, i := range report.ImagesDeleted {
+		if i.Deleted != "" {
+			result.Deleted = append(result.Deleted, i.Deleted)
+		}
+	}
+	return result, nil
+}
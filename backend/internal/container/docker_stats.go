@@ -0,0 +1,226 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// dockerStats is the Docker Engine representation of container stats.
+type dockerStats struct {
+	ID       string    `json:"id"`
+	Read     time.Time `json:"read"`
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage  uint64   `json:"total_usage"`
+			PercpuUsage []uint64 `json:"percpu_usage"`
+		} `json:"cpu_usage"`
+		SystemUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs  uint32 `json:"online_cpus"`
+	} `json:"cpu_stats"`
+	PreCPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+		SystemUsage uint64 `json:"system_cpu_usage"`
+	} `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// toModel converts Docker stats to a models.ContainerStats taken at t.
+// CPU usage is computed the same way as `docker stats` does.
+func (d *dockerStats) toModel(id string, t time.Time) models.ContainerStats {
+	stats := models.ContainerStats{
+		ContainerID: d.ID,
+		Timestamp:   t,
+		MemoryLimit: d.MemoryStats.Limit,
+		PIDs:        d.PidsStats.Current,
+	}
+	if stats.ContainerID == "" {
+		stats.ContainerID = id
+	}
+
+	cpuDelta := float64(d.CPUStats.CPUUsage.TotalUsage) - float64(d.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(d.CPUStats.SystemUsage) - float64(d.PreCPUStats.SystemUsage)
+	cpus := float64(d.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(d.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// Page cache is reclaimable and not reported as used memory
+	stats.MemoryUsage = d.MemoryStats.Usage
+	cache := d.MemoryStats.Stats["inactive_file"]
+	if cache == 0 {
+		cache = d.MemoryStats.Stats["cache"]
+	}
+	if cache < stats.MemoryUsage {
+		stats.MemoryUsage -= cache
+	}
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+
+	for _, n := range d.Networks {
+		stats.NetworkRx += n.RxBytes
+		stats.NetworkTx += n.TxBytes
+	}
+	for _, b := range d.BlkioStats.IOServiceBytesRecursive {
+		switch b.Op {
+		case "read", "Read":
+			stats.BlockRead += b.Value
+		case "write", "Write":
+			stats.BlockWrite += b.Value
+		}
+	}
+	return stats
+}
+
+// Stats returns a single resource usage sample of a running container.
+func (d *DockerService) Stats(ctx context.Context, id string) (*models.ContainerStats, error) {
+	endpoint := fmt.Sprintf("/containers/%s/stats?stream=false", url.PathEscape(id))
+	resp, err := d.call(ctx, d.client, http.MethodGet, endpoint, nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats of container %s: %w", id, err)
+	}
+
+	var data dockerStats
+	if err := decode(resp, &data); err != nil {
+		return nil, err
+	}
+
+	stats := data.toModel(id, time.Now())
+	return &stats, nil
+}
+
+// StreamStats samples a running container every interval and calls fn with
+// each sample until ctx is canceled, the container stops or fn returns an error.
+// Docker emits a sample every second, so samples are dropped until interval has passed.
+func (d *DockerService) StreamStats(ctx context.Context, id string, interval time.Duration, fn func(models.ContainerStats) error) error {
+	endpoint := fmt.Sprintf("/containers/%s/stats?stream=true", url.PathEscape(id))
+	resp, err := d.call(ctx, d.streamClient, http.MethodGet, endpoint, nil, http.StatusOK)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to stream stats of container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	var last time.Time
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var data dockerStats
+		if err := decoder.Decode(&data); err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode stats: %w", err)
+		}
+
+		now := time.Now()
+		if !last.IsZero() && now.Sub(last) < interval {
+			continue
+		}
+		last = now
+
+		if err := fn(data.toModel(id, now)); err != nil {
+			return err
+		}
+	}
+}
+
+// dockerEvent is a Docker Engine event as returned by the events endpoint.
+type dockerEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+// toModel converts a Docker event to a models.ContainerEvent.
+// Docker reports health changes as "health_status: <status>" actions.
+func (e *dockerEvent) toModel() models.ContainerEvent {
+	event := models.ContainerEvent{
+		ContainerID: e.Actor.ID,
+		Name:        e.Actor.Attributes["name"],
+		Action:      e.Action,
+		Attributes:  e.Actor.Attributes,
+		Time:        time.Unix(0, e.TimeNano),
+	}
+	if code, err := strconv.Atoi(e.Actor.Attributes["exitCode"]); err == nil {
+		event.ExitCode = code
+	}
+	if status, ok := strings.CutPrefix(e.Action, "health_status: "); ok {
+		event.Action = "health_status"
+		event.HealthStatus = status
+	}
+	return event
+}
+
+// Events follows the container events of the API and calls fn for every
+// event until ctx is canceled, the stream ends or fn returns an error.
+// A stream that ends while ctx is still active is reported as an error.
+func (d *DockerService) Events(ctx context.Context, fn func(models.ContainerEvent) error) error {
+	endpoint := "/events?filters=" + url.QueryEscape(`{"type":["container"]}`)
+	resp, err := d.call(ctx, d.streamClient, http.MethodGet, endpoint, nil, http.StatusOK)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to follow events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event dockerEvent
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				return errors.New("event stream closed")
+			}
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		if event.Type != "" && event.Type != "container" {
+			continue
+		}
+		if err := fn(event.toModel()); err != nil {
+			return err
+		}
+	}
+}
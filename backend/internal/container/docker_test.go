@@ -0,0 +1,337 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// mockDocker starts a Docker Engine API mock serving the given handlers by path.
+func mockDocker(t *testing.T, handlers map[string]http.HandlerFunc) *DockerService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := handlers[r.Method+" "+r.URL.Path]; ok {
+			h(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return NewDockerService(server.URL)
+}
+
+func TestNewRuntime(t *testing.T) {
+	t.Run("should default to Podman", func(t *testing.T) {
+		runtime, err := NewRuntime("", "unix:///run/podman/podman.sock", ConnectOptions{})
+
+		require.NoError(t, err)
+		assert.IsType(t, &Service{}, runtime)
+	})
+
+	t.Run("should create a Docker runtime", func(t *testing.T) {
+		runtime, err := NewRuntime(RuntimeDocker, "unix:///var/run/docker.sock", ConnectOptions{})
+
+		require.NoError(t, err)
+		assert.IsType(t, &DockerService{}, runtime)
+	})
+
+	t.Run("should reject unknown runtimes", func(t *testing.T) {
+		_, err := NewRuntime("lxc", "", ConnectOptions{})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDockerService_List(t *testing.T) {
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"GET /v1.43/containers/json": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "true", r.URL.Query().Get("all"))
+			_, _ = w.Write([]byte(`[{"Id":"abc","Names":["/sabakan-mc"],"Image":"itzg/minecraft-server","State":"running","Status":"Up 5 minutes","Created":1700000000,
+				"Ports":[{"IP":"0.0.0.0","PrivatePort":25565,"PublicPort":25565,"Type":"tcp"},{"PrivatePort":8080,"Type":"tcp"}],"Labels":{"sabakan.slug":"mc"}}]`))
+		},
+	})
+
+	t.Run("should convert Docker containers", func(t *testing.T) {
+		containers, err := docker.List(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, containers, 1)
+		assert.Equal(t, "sabakan-mc", containers[0].Name)
+		assert.Equal(t, models.StateRunning, containers[0].State)
+		assert.Equal(t, "mc", containers[0].Labels["sabakan.slug"])
+		assert.Equal(t, []models.PortMapping{{HostIP: "0.0.0.0", HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}}, containers[0].Ports)
+	})
+}
+
+func TestDockerService_Create(t *testing.T) {
+	var spec map[string]any
+	var name string
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"POST /v1.43/containers/create": func(w http.ResponseWriter, r *http.Request) {
+			name = r.URL.Query().Get("name")
+			_ = json.NewDecoder(r.Body).Decode(&spec)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"abc","Warnings":[]}`))
+		},
+	})
+
+	id, err := docker.Create(context.Background(), CreateOptions{
+		Name:    "sabakan-mc",
+		Image:   "itzg/minecraft-server:latest",
+		Env:     map[string]string{"EULA": "TRUE", "MEMORY": "4G"},
+		Ports:   []models.PortMapping{{HostPort: 25565, ContainerPort: 25565}},
+		Volumes: []VolumeMount{{Name: "sabakan-mc-data", Dest: "/data"}},
+		Stdin:   true,
+
+		Resources:     models.ResourceLimits{MemoryMB: 4096, CPUs: 1.5, PidsLimit: 512},
+		RestartPolicy: models.RestartPolicyUnlessStopped,
+	})
+	require.NoError(t, err)
+
+	t.Run("should return the container ID", func(t *testing.T) {
+		assert.Equal(t, "abc", id)
+		assert.Equal(t, "sabakan-mc", name)
+	})
+
+	t.Run("should build a Docker container config", func(t *testing.T) {
+		assert.Equal(t, []any{"EULA=TRUE", "MEMORY=4G"}, spec["Env"])
+		assert.Equal(t, true, spec["OpenStdin"])
+		assert.Equal(t, map[string]any{"25565/tcp": map[string]any{}}, spec["ExposedPorts"])
+
+		hostConfig := spec["HostConfig"].(map[string]any)
+		assert.Equal(t, map[string]any{"25565/tcp": []any{map[string]any{"HostIp": "", "HostPort": "25565"}}}, hostConfig["PortBindings"])
+		assert.Equal(t, []any{map[string]any{"Type": "volume", "Source": "sabakan-mc-data", "Target": "/data"}}, hostConfig["Mounts"])
+	})
+
+	t.Run("should set resource limits and the restart policy", func(t *testing.T) {
+		hostConfig := spec["HostConfig"].(map[string]any)
+		assert.Equal(t, float64(4096<<20), hostConfig["Memory"])
+		assert.Equal(t, float64(1.5e9), hostConfig["NanoCpus"])
+		assert.Equal(t, float64(512), hostConfig["PidsLimit"])
+		assert.Equal(t, map[string]any{"Name": "unless-stopped"}, hostConfig["RestartPolicy"])
+	})
+}
+
+func TestDockerService_Update(t *testing.T) {
+	var body map[string]any
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"POST /v1.43/containers/abc/update": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_, _ = w.Write([]byte(`{"Warnings":[]}`))
+		},
+	})
+
+	t.Run("should send the new limits and restart policy", func(t *testing.T) {
+		err := docker.Update(context.Background(), "abc", UpdateOptions{
+			Resources:     models.ResourceLimits{MemoryMB: 2048, CPUShares: 512},
+			RestartPolicy: models.RestartPolicyOnFailure,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, float64(2048<<20), body["Memory"])
+		assert.Equal(t, float64(-1), body["MemorySwap"])
+		assert.Equal(t, float64(512), body["CpuShares"])
+		assert.Equal(t, float64(-1), body["PidsLimit"])
+		assert.Equal(t, map[string]any{"Name": "on-failure"}, body["RestartPolicy"])
+	})
+
+	t.Run("should fail for unknown containers", func(t *testing.T) {
+		err := docker.Update(context.Background(), "missing", UpdateOptions{})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDockerService_Stats(t *testing.T) {
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"GET /v1.43/containers/mc/stats": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "false", r.URL.Query().Get("stream"))
+			_, _ = w.Write([]byte(`{"id":"abc",
+				"cpu_stats":{"cpu_usage":{"total_usage":3000},"system_cpu_usage":20000,"online_cpus":4},
+				"precpu_stats":{"cpu_usage":{"total_usage":1000},"system_cpu_usage":10000},
+				"memory_stats":{"usage":600,"limit":1000,"stats":{"inactive_file":100}},
+				"networks":{"eth0":{"rx_bytes":10,"tx_bytes":20},"eth1":{"rx_bytes":1,"tx_bytes":2}},
+				"blkio_stats":{"io_service_bytes_recursive":[{"op":"read","value":5},{"op":"write","value":7}]},
+				"pids_stats":{"current":12}}`))
+		},
+	})
+
+	t.Run("should compute usage like docker stats", func(t *testing.T) {
+		stats, err := docker.Stats(context.Background(), "mc")
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", stats.ContainerID)
+		assert.InDelta(t, 80.0, stats.CPUPercent, 0.001)
+		assert.Equal(t, uint64(500), stats.MemoryUsage)
+		assert.InDelta(t, 50.0, stats.MemoryPercent, 0.001)
+		assert.Equal(t, uint64(11), stats.NetworkRx)
+		assert.Equal(t, uint64(22), stats.NetworkTx)
+		assert.Equal(t, uint64(5), stats.BlockRead)
+		assert.Equal(t, uint64(7), stats.BlockWrite)
+		assert.Equal(t, uint64(12), stats.PIDs)
+	})
+}
+
+func TestDockerEvent_ToModel(t *testing.T) {
+	t.Run("should read the exit code of died containers", func(t *testing.T) {
+		var event dockerEvent
+		require.NoError(t, json.Unmarshal([]byte(`{"Type":"container","Action":"die","Actor":{"ID":"abc","Attributes":{"name":"sabakan-mc","exitCode":"1"}},"timeNano":1700000000000000000}`), &event))
+
+		model := event.toModel()
+
+		assert.Equal(t, "die", model.Action)
+		assert.Equal(t, "sabakan-mc", model.Name)
+		assert.Equal(t, 1, model.ExitCode)
+	})
+
+	t.Run("should split health status actions", func(t *testing.T) {
+		event := dockerEvent{Action: "health_status: unhealthy"}
+
+		model := event.toModel()
+
+		assert.Equal(t, "health_status", model.Action)
+		assert.Equal(t, "unhealthy", model.HealthStatus)
+	})
+}
+
+func TestDockerService_Images(t *testing.T) {
+	var pulled string
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"GET /v1.43/images/json": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`[{"Id":"sha256:a","RepoTags":["itzg/minecraft-server:latest"],"RepoDigests":["itzg/minecraft-server@sha256:d1"],"Size":100,"Containers":-1},
+				{"Id":"sha256:b","RepoTags":["<none>:<none>"],"RepoDigests":[],"Size":50}]`))
+		},
+		"POST /v1.43/images/create": func(w http.ResponseWriter, r *http.Request) {
+			pulled = r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+			_, _ = w.Write([]byte(`{"status":"Pulling from itzg/minecraft-server","id":"latest"}
+{"status":"Download complete","id":"abc"}
+`))
+		},
+		"GET /v1.43/images/itzg/minecraft-server:latest/json": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"Id":"sha256:a","RepoTags":["itzg/minecraft-server:latest"],"RepoDigests":["itzg/minecraft-server@sha256:d1"]}`))
+		},
+		"DELETE /v1.43/images/busy": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		},
+	})
+
+	t.Run("should list images and detect dangling ones", func(t *testing.T) {
+		images, err := docker.ListImages(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, images, 2)
+		assert.Equal(t, "sha256:d1", images[0].Digest)
+		assert.Equal(t, 0, images[0].Containers)
+		assert.False(t, images[0].Dangling)
+		assert.True(t, images[1].Dangling)
+		assert.Empty(t, images[1].Tags)
+	})
+
+	t.Run("should pull a single tag and return the image ID", func(t *testing.T) {
+		var messages []string
+		id, err := docker.PullImage(context.Background(), "itzg/minecraft-server", func(p models.ImagePullProgress) error {
+			messages = append(messages, p.Message)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:a", id)
+		assert.Equal(t, "itzg/minecraft-server:latest", pulled)
+		assert.Equal(t, []string{"latest Pulling from itzg/minecraft-server", "abc Download complete"}, messages)
+	})
+
+	t.Run("should report images in use", func(t *testing.T) {
+		err := docker.RemoveImage(context.Background(), "busy", false)
+
+		assert.ErrorIs(t, err, ErrImageInUse)
+	})
+}
+
+func TestDockerService_ExportVolume(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, entry := range []struct{ name, body string }{{"data/", ""}, {"data/world/", ""}, {"data/world/level.dat", "level"}} {
+		hdr := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.body)), Typeflag: tar.TypeReg}
+		if entry.body == "" {
+			hdr.Typeflag = tar.TypeDir
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, _ = tw.Write([]byte(entry.body))
+	}
+	require.NoError(t, tw.Close())
+
+	var filters, path string
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"GET /v1.43/containers/json": func(w http.ResponseWriter, r *http.Request) {
+			filters = r.URL.Query().Get("filters")
+			if !strings.Contains(filters, "sabakan-mc-data") {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"Id":"mc","Mounts":[{"Type":"volume","Name":"sabakan-mc-data","Destination":"/data"}]}]`))
+		},
+		"GET /v1.43/containers/mc/archive": func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Query().Get("path")
+			_, _ = w.Write(archive.Bytes())
+		},
+	})
+
+	t.Run("should archive the volume through a container mounting it", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, docker.ExportVolume(context.Background(), "sabakan-mc-data", &out))
+
+		assert.JSONEq(t, `{"volume":["sabakan-mc-data"]}`, filters)
+		assert.Equal(t, "/data", path)
+
+		tr := tar.NewReader(&out)
+		var names []string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, hdr.Name)
+			if hdr.Name == "world/level.dat" {
+				body, _ := io.ReadAll(tr)
+				assert.Equal(t, "level", string(body))
+			}
+		}
+		assert.Equal(t, []string{"world/", "world/level.dat"}, names)
+	})
+
+	t.Run("should fail for volumes no container mounts", func(t *testing.T) {
+		err := docker.ExportVolume(context.Background(), "orphan", io.Discard)
+
+		assert.ErrorContains(t, err, "no container mounts it")
+	})
+}
+
+func TestDockerService_Rename(t *testing.T) {
+	var name string
+	docker := mockDocker(t, map[string]http.HandlerFunc{
+		"POST /v1.43/containers/abc/rename": func(w http.ResponseWriter, r *http.Request) {
+			name = r.URL.Query().Get("name")
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	t.Run("should send the new name", func(t *testing.T) {
+		require.NoError(t, docker.Rename(context.Background(), "abc", "sabakan-survival"))
+		assert.Equal(t, "sabakan-survival", name)
+	})
+
+	t.Run("should fail for unknown containers", func(t *testing.T) {
+		assert.Error(t, docker.Rename(context.Background(), "missing", "sabakan-survival"))
+	})
+}
@@ -0,0 +1,93 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// podmanEvent is a libpod event as returned by the events endpoint.
+type podmanEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	HealthStatus string `json:"HealthStatus"`
+	TimeNano     int64  `json:"timeNano"`
+}
+
+// toModel converts a libpod event to a models.ContainerEvent.
+func (e *podmanEvent) toModel() models.ContainerEvent {
+	event := models.ContainerEvent{
+		ContainerID:  e.Actor.ID,
+		Name:         e.Actor.Attributes["name"],
+		Action:       e.Action,
+		Attributes:   e.Actor.Attributes,
+		HealthStatus: e.HealthStatus,
+		Time:         time.Unix(0, e.TimeNano),
+	}
+	if code, err := strconv.Atoi(e.Actor.Attributes["containerExitCode"]); err == nil {
+		event.ExitCode = code
+	}
+	if event.HealthStatus == "" {
+		event.HealthStatus = e.Actor.Attributes["health_status"]
+	}
+	return event
+}
+
+// Events follows the container events of the API and calls fn for every
+// event until ctx is canceled, the stream ends or fn returns an error.
+// A stream that ends while ctx is still active is reported as an error.
+func (s *Service) Events(ctx context.Context, fn func(models.ContainerEvent) error) error {
+	filters := url.QueryEscape(`{"type":["container"]}`)
+	endpoint := "/v5.0.0/libpod/events?stream=true&filters=" + filters
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.streamClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to follow events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to follow events: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event podmanEvent
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				return errors.New("event stream closed")
+			}
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		if event.Type != "" && event.Type != "container" {
+			continue
+		}
+		if err := fn(event.toModel()); err != nil {
+			return err
+		}
+	}
+}
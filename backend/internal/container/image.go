@@ -0,0 +1,263 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+var (
+	// ErrImageNotFound is returned when an image does not exist in the local store.
+	ErrImageNotFound = errors.New("image not found")
+	// ErrImageInUse is returned when removing an image that is used by a container.
+	ErrImageInUse = errors.New("image is in use by a container")
+)
+
+// podmanImageSummary is a libpod image list entry.
+type podmanImageSummary struct {
+	ID          string   `json:"Id"`
+	Digest      string   `json:"Digest"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     int64    `json:"Created"`
+	Size        int64    `json:"Size"`
+	Dangling    bool     `json:"Dangling"`
+	Containers  int      `json:"Containers"`
+}
+
+func (i *podmanImageSummary) toModel() models.Image {
+	return models.Image{
+		ID:         i.ID,
+		Digest:     i.Digest,
+		Tags:       nonNil(i.RepoTags),
+		Digests:    nonNil(i.RepoDigests),
+		Size:       i.Size,
+		Created:    time.Unix(i.Created, 0),
+		Dangling:   i.Dangling,
+		Containers: i.Containers,
+	}
+}
+
+// podmanImageInspect is the subset of a libpod image inspect response we use.
+type podmanImageInspect struct {
+	ID          string   `json:"Id"`
+	Digest      string   `json:"Digest"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     string   `json:"Created"`
+	Size        int64    `json:"Size"`
+}
+
+func (i *podmanImageInspect) toModel() models.Image {
+	created, _ := time.Parse(time.RFC3339Nano, i.Created)
+	return models.Image{
+		ID:       i.ID,
+		Digest:   i.Digest,
+		Tags:     nonNil(i.RepoTags),
+		Digests:  nonNil(i.RepoDigests),
+		Size:     i.Size,
+		Created:  created,
+		Dangling: len(i.RepoTags) == 0,
+	}
+}
+
+// podmanPullReport is a line of the libpod image pull stream.
+type podmanPullReport struct {
+	Stream string   `json:"stream"`
+	Error  string   `json:"error"`
+	Images []string `json:"images"`
+	ID     string   `json:"id"`
+}
+
+// nonNil returns s, or an empty slice if s is nil.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// ListImages returns all images in the local store.
+func (s *Service) ListImages(ctx context.Context) ([]models.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL("/v5.0.0/libpod/images/json"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var summaries []podmanImageSummary
+	if err := json.NewDecoder(resp.Body).Decode(&summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := make([]models.Image, 0, len(summaries))
+	for _, i := range summaries {
+		result = append(result, i.toModel())
+	}
+	return result, nil
+}
+
+// GetImage returns an image by ID or reference.
+func (s *Service) GetImage(ctx context.Context, name string) (*models.Image, error) {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/images/%s/json", url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to inspect image %s: status %d: %s", name, resp.StatusCode, string(body))
+	}
+
+	var data podmanImageInspect
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	image := data.toModel()
+	return &image, nil
+}
+
+// PullImage pulls an image from its registry and calls fn with every progress
+// message. It returns the ID of the pulled image.
+func (s *Service) PullImage(ctx context.Context, reference string, fn func(models.ImagePullProgress) error) (string, error) {
+	endpoint := "/v5.0.0/libpod/images/pull?reference=" + url.QueryEscape(reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL(endpoint), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Large images take far longer than the default timeout
+	resp, err := s.streamClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull image %s: %w", reference, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to pull image %s: status %d: %s", reference, resp.StatusCode, string(body))
+	}
+
+	var imageID string
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var report podmanPullReport
+		if err := decoder.Decode(&report); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+
+		if report.Error != "" {
+			return "", fmt.Errorf("failed to pull image %s: %s", reference, report.Error)
+		}
+		if report.ID != "" {
+			imageID = report.ID
+		}
+		if msg := strings.TrimSpace(report.Stream); msg != "" && fn != nil {
+			if err := fn(models.ImagePullProgress{Message: msg}); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if imageID == "" {
+		return "", fmt.Errorf("failed to pull image %s: no image was pulled", reference)
+	}
+	return imageID, nil
+}
+
+// RemoveImage removes an image. Images used by containers are only removed when force is set.
+func (s *Service) RemoveImage(ctx context.Context, name string, force bool) error {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/images/%s?force=%t", url.PathEscape(name), force)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to remove image %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrImageNotFound, name)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrImageInUse, name)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to remove image %s: status %d: %s", name, resp.StatusCode, string(body))
+	}
+}
+
+// PruneImages removes dangling images.
+func (s *Service) PruneImages(ctx context.Context) (*models.ImagePruneReport, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL("/v5.0.0/libpod/images/prune"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune images: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to prune images: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var reports []struct {
+		ID   string  `json:"Id"`
+		Err  *string `json:"Err"`
+		Size uint64  `json:"Size"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := &models.ImagePruneReport{Deleted: []string{}}
+	for _, r := range reports {
+		if r.Err != nil && *r.Err != "" {
+			continue
+		}
+		result.Deleted = append(result.Deleted, r.ID)
+		result.ReclaimedSpace += r.Size
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestService_Images(t *testing.T) {
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v5.0.0/libpod/images/json":
+			_, _ = w.Write([]byte(`[
+				{"Id":"img1","RepoTags":["docker.io/itzg/minecraft-server:latest"],"RepoDigests":["docker.io/itzg/minecraft-server@sha256:abc"],"Created":1704067200,"Size":512,"Containers":1},
+				{"Id":"img2","RepoTags":null,"Created":1704067200,"Size":128,"Dangling":true}
+			]`))
+		case "/v5.0.0/libpod/images/itzg/minecraft-server:latest/json":
+			_, _ = w.Write([]byte(`{"Id":"img1","RepoTags":["docker.io/itzg/minecraft-server:latest"],"Created":"2024-01-01T00:00:00Z","Size":512}`))
+		case "/v5.0.0/libpod/images/pull":
+			switch r.URL.Query().Get("reference") {
+			case "itzg/minecraft-server:latest":
+				_, _ = w.Write([]byte(`{"stream":"Copying blob sha256:aaa\n"}` + "\n"))
+				_, _ = w.Write([]byte(`{"stream":"Writing manifest to image destination\n"}` + "\n"))
+				_, _ = w.Write([]byte(`{"images":["img1"],"id":"img1"}` + "\n"))
+			default:
+				_, _ = w.Write([]byte(`{"error":"manifest unknown"}` + "\n"))
+			}
+		case "/v5.0.0/libpod/images/unused":
+			assert.Equal(t, http.MethodDelete, r.Method)
+			_, _ = w.Write([]byte(`{"Deleted":["unused"],"ExitCode":0}`))
+		case "/v5.0.0/libpod/images/used":
+			if r.URL.Query().Get("force") == "true" {
+				_, _ = w.Write([]byte(`{"Deleted":["used"],"ExitCode":0}`))
+				return
+			}
+			w.WriteHeader(http.StatusConflict)
+		case "/v5.0.0/libpod/images/prune":
+			assert.Equal(t, http.MethodPost, r.Method)
+			_, _ = w.Write([]byte(`[{"Id":"img2","Err":null,"Size":128},{"Id":"img3","Err":null,"Size":64}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockPodman.Close()
+	svc := NewService(mockPodman.URL)
+	ctx := context.Background()
+
+	t.Run("should list images", func(t *testing.T) {
+		images, err := svc.ListImages(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, images, 2)
+		assert.Equal(t, []string{"docker.io/itzg/minecraft-server:latest"}, images[0].Tags)
+		assert.Equal(t, 1, images[0].Containers)
+		assert.Empty(t, images[1].Tags)
+		assert.True(t, images[1].Dangling)
+	})
+
+	t.Run("should inspect an image", func(t *testing.T) {
+		image, err := svc.GetImage(ctx, "itzg/minecraft-server:latest")
+
+		require.NoError(t, err)
+		assert.Equal(t, "img1", image.ID)
+	})
+
+	t.Run("should report missing images", func(t *testing.T) {
+		_, err := svc.GetImage(ctx, "missing")
+		assert.ErrorIs(t, err, ErrImageNotFound)
+	})
+
+	t.Run("should report pull progress", func(t *testing.T) {
+		var messages []string
+		id, err := svc.PullImage(ctx, "itzg/minecraft-server:latest", func(p models.ImagePullProgress) error {
+			messages = append(messages, p.Message)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "img1", id)
+		assert.Equal(t, []string{"Copying blob sha256:aaa", "Writing manifest to image destination"}, messages)
+	})
+
+	t.Run("should report pull failures", func(t *testing.T) {
+		_, err := svc.PullImage(ctx, "nope:latest", nil)
+		assert.ErrorContains(t, err, "manifest unknown")
+	})
+
+	t.Run("should remove images", func(t *testing.T) {
+		assert.NoError(t, svc.RemoveImage(ctx, "unused", false))
+	})
+
+	t.Run("should only remove images in use with force", func(t *testing.T) {
+		assert.ErrorIs(t, svc.RemoveImage(ctx, "used", false), ErrImageInUse)
+		assert.NoError(t, svc.RemoveImage(ctx, "used", true))
+	})
+
+	t.Run("should prune dangling images", func(t *testing.T) {
+		report, err := svc.PruneImages(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"img2", "img3"}, report.Deleted)
+		assert.Equal(t, uint64(192), report.ReclaimedSpace)
+	})
+}
@@ -0,0 +1,93 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// podmanInfo is the subset of the libpod info response we use.
+type podmanInfo struct {
+	Host struct {
+		Hostname string `json:"hostname"`
+		OS       string `json:"os"`
+		Arch     string `json:"arch"`
+		CPUs     int    `json:"cpus"`
+		MemTotal uint64 `json:"memTotal"`
+		MemFree  uint64 `json:"memFree"`
+	} `json:"host"`
+	Version struct {
+		Version string `json:"Version"`
+	} `json:"version"`
+}
+
+// Info describes the machine Podman runs on.
+func (s *Service) Info(ctx context.Context) (*models.HostInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL("/v5.0.0/libpod/info"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get host info: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var data podmanInfo
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &models.HostInfo{
+		Hostname:    data.Host.Hostname,
+		OS:          data.Host.OS,
+		Arch:        data.Host.Arch,
+		CPUs:        data.Host.CPUs,
+		MemoryTotal: data.Host.MemTotal,
+		MemoryFree:  data.Host.MemFree,
+		Version:     data.Version.Version,
+	}, nil
+}
+
+// dockerInfo is the subset of the Docker info response we use.
+type dockerInfo struct {
+	Name          string `json:"Name"`
+	OSType        string `json:"OSType"`
+	Architecture  string `json:"Architecture"`
+	NCPU          int    `json:"NCPU"`
+	MemTotal      uint64 `json:"MemTotal"`
+	ServerVersion string `json:"ServerVersion"`
+}
+
+// Info describes the machine Docker runs on.
+// Docker does not report free memory.
+func (d *DockerService) Info(ctx context.Context) (*models.HostInfo, error) {
+	resp, err := d.call(ctx, d.client, http.MethodGet, "/info", nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host info: %w", err)
+	}
+
+	var data dockerInfo
+	if err := decode(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return &models.HostInfo{
+		Hostname:    data.Name,
+		OS:          data.OSType,
+		Arch:        data.Architecture,
+		CPUs:        data.NCPU,
+		MemoryTotal: data.MemTotal,
+		Version:     data.ServerVersion,
+	}, nil
+}
@@ -0,0 +1,215 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// Stream type identifiers used in the multiplexed log format.
+const (
+	streamStdin  = 0
+	streamStdout = 1
+	streamStderr = 2
+	streamSystem = 3
+)
+
+const (
+	// logFrameHeaderSize is the size of the header preceding each multiplexed frame.
+	logFrameHeaderSize = 8
+	// logMaxFrameSize bounds the payload size of a multiplexed frame. Engines
+	// send frames of a few KiB, so larger sizes mean a corrupt stream.
+	logMaxFrameSize = 1 << 20
+	// logMaxLineSize bounds a line kept across frames; longer lines are split.
+	logMaxLineSize = 64 << 10
+)
+
+// Logs returns the last N lines of container logs.
+func (s *apiClient) Logs(ctx context.Context, id string, lines int) ([]models.ContainerLogEntry, error) {
+	endpoint := fmt.Sprintf("%s/containers/%s/logs?stdout=true&stderr=true&timestamps=true&tail=%d", s.prefix, url.PathEscape(id), lines)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs for container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	entries := []models.ContainerLogEntry{}
+	err = readLogStream(resp.Body, func(entry models.ContainerLogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	return entries, nil
+}
+
+// StreamLogs follows the logs of a container, starting with the last N lines,
+// and calls fn for every entry until ctx is canceled, the container stops,
+// or fn returns an error.
+func (s *apiClient) StreamLogs(ctx context.Context, id string, lines int, fn func(models.ContainerLogEntry) error) error {
+	endpoint := fmt.Sprintf("%s/containers/%s/logs?follow=true&stdout=true&stderr=true&timestamps=true&tail=%d", s.prefix, url.PathEscape(id), lines)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.streamClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to stream logs for container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	err = readLogStream(resp.Body, fn)
+	if err != nil && ctx.Err() != nil {
+		// The caller went away; this is the normal way a stream ends.
+		return nil
+	}
+	return err
+}
+
+// readLogStream reads container log output and calls fn for every line.
+// Output of containers without a TTY is multiplexed: each frame starts with
+// an 8-byte header holding the stream type and the payload size, and lines
+// may span several frames. Output of containers with a TTY is raw and
+// reported as stdout.
+func readLogStream(r io.Reader, fn func(models.ContainerLogEntry) error) error {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(logFrameHeaderSize)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// Short raw output
+			return readRawLog(br, fn)
+		}
+		return err
+	}
+	if !isFrameHeader(header) {
+		return readRawLog(br, fn)
+	}
+
+	// Engines may split a line over several frames, so the incomplete last
+	// line of each stream is kept until the rest of it arrives
+	pending := map[string][]byte{}
+	buf := make([]byte, logFrameHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			if errors.Is(err, io.EOF) {
+				for _, stream := range []string{"stdout", "stderr"} {
+					if err := emitLogLine(stream, pending[stream], fn); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			return err
+		}
+
+		size := binary.BigEndian.Uint32(buf[4:])
+		if size > logMaxFrameSize {
+			return fmt.Errorf("log frame of %d bytes exceeds the limit of %d bytes", size, logMaxFrameSize)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return err
+		}
+
+		stream := "stdout"
+		if buf[0] == streamStderr {
+			stream = "stderr"
+		}
+
+		data := append(pending[stream], payload...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if err := emitLogLine(stream, data[:i], fn); err != nil {
+				return err
+			}
+			data = data[i+1:]
+		}
+		if len(data) > logMaxLineSize {
+			if err := emitLogLine(stream, data, fn); err != nil {
+				return err
+			}
+			data = nil
+		}
+		pending[stream] = bytes.Clone(data)
+	}
+}
+
+// emitLogLine calls fn with the entry of a line unless the line is empty.
+func emitLogLine(stream string, line []byte, fn func(models.ContainerLogEntry) error) error {
+	if len(line) == 0 {
+		return nil
+	}
+	return fn(parseLogLine(stream, line))
+}
+
+// readRawLog reads non-multiplexed log output line by line.
+func readRawLog(r *bufio.Reader, fn func(models.ContainerLogEntry) error) error {
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			if fnErr := fn(parseLogLine("stdout", line)); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// isFrameHeader reports whether b looks like a multiplexed frame header.
+func isFrameHeader(b []byte) bool {
+	if b[0] > streamSystem {
+		return false
+	}
+	return b[1] == 0 && b[2] == 0 && b[3] == 0
+}
+
+// parseLogLine builds a log entry, splitting off the leading timestamp if present.
+func parseLogLine(stream string, line []byte) models.ContainerLogEntry {
+	entry := models.ContainerLogEntry{Stream: stream, Message: string(line)}
+
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+			entry.Timestamp = ts
+			entry.Message = string(line[i+1:])
+		}
+	}
+
+	return entry
+}
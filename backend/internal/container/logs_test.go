@@ -0,0 +1,107 @@
+package container
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// frame builds a multiplexed log frame for the given stream.
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, logFrameHeaderSize)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func collectLogs(t *testing.T, data []byte) []models.ContainerLogEntry {
+	t.Helper()
+	var entries []models.ContainerLogEntry
+	err := readLogStream(bytes.NewReader(data), func(entry models.ContainerLogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	require.NoError(t, err)
+	return entries
+}
+
+func TestReadLogStream(t *testing.T) {
+	t.Run("should demultiplex stdout and stderr frames", func(t *testing.T) {
+		var data []byte
+		data = append(data, frame(streamStdout, "2024-01-01T00:00:00.5Z Server started\n")...)
+		data = append(data, frame(streamStderr, "2024-01-01T00:00:01Z Something failed\n")...)
+
+		entries := collectLogs(t, data)
+
+		require.Len(t, entries, 2)
+		assert.Equal(t, "stdout", entries[0].Stream)
+		assert.Equal(t, "Server started", entries[0].Message)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 500000000, time.UTC), entries[0].Timestamp)
+		assert.Equal(t, "stderr", entries[1].Stream)
+		assert.Equal(t, "Something failed", entries[1].Message)
+	})
+
+	t.Run("should split frames containing several lines", func(t *testing.T) {
+		entries := collectLogs(t, frame(streamStdout, "first\nsecond\n"))
+
+		require.Len(t, entries, 2)
+		assert.Equal(t, "first", entries[0].Message)
+		assert.Equal(t, "second", entries[1].Message)
+		assert.True(t, entries[0].Timestamp.IsZero())
+	})
+
+	t.Run("should join lines split over several frames", func(t *testing.T) {
+		var data []byte
+		data = append(data, frame(streamStdout, "2024-01-01T00:00:00Z Server sta")...)
+		data = append(data, frame(streamStderr, "warning: low")...)
+		data = append(data, frame(streamStdout, "rted\nDone")...)
+		data = append(data, frame(streamStderr, " memory\n")...)
+
+		entries := collectLogs(t, data)
+
+		require.Len(t, entries, 3)
+		assert.Equal(t, models.ContainerLogEntry{Stream: "stdout", Message: "Server started", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, entries[0])
+		assert.Equal(t, "stderr", entries[1].Stream)
+		assert.Equal(t, "warning: low memory", entries[1].Message)
+		assert.Equal(t, "stdout", entries[2].Stream)
+		assert.Equal(t, "Done", entries[2].Message, "the last line is reported at the end of the stream")
+	})
+
+	t.Run("should split overlong lines", func(t *testing.T) {
+		long := strings.Repeat("a", logMaxLineSize+1)
+
+		entries := collectLogs(t, append(frame(streamStdout, long), frame(streamStdout, "b\n")...))
+
+		require.Len(t, entries, 2)
+		assert.Equal(t, long, entries[0].Message)
+		assert.Equal(t, "b", entries[1].Message)
+	})
+
+	t.Run("should reject oversized frames", func(t *testing.T) {
+		header := make([]byte, logFrameHeaderSize)
+		header[0] = streamStdout
+		binary.BigEndian.PutUint32(header[4:], 0xFFFFFFFF)
+
+		err := readLogStream(bytes.NewReader(header), func(models.ContainerLogEntry) error { return nil })
+		assert.ErrorContains(t, err, "exceeds the limit")
+	})
+
+	t.Run("should read raw output of TTY containers", func(t *testing.T) {
+		entries := collectLogs(t, []byte("Hello from a TTY\r\nsecond line"))
+
+		require.Len(t, entries, 2)
+		assert.Equal(t, "stdout", entries[0].Stream)
+		assert.Equal(t, "Hello from a TTY", entries[0].Message)
+		assert.Equal(t, "second line", entries[1].Message)
+	})
+
+	t.Run("should handle empty output", func(t *testing.T) {
+		assert.Empty(t, collectLogs(t, nil))
+	})
+}
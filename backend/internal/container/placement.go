@@ -0,0 +1,202 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrNoNodeAvailable is returned when no node can host a game server.
+var ErrNoNodeAvailable = errors.New("no node can host the game server")
+
+// PlacementRequest describes what a new game server needs from its node.
+type PlacementRequest struct {
+	// Node is the name of the node chosen by the user. The node is picked automatically when empty.
+	Node string
+	// Selector restricts automatic placement to nodes with all of these labels.
+	Selector map[string]string
+	// Ports are the host ports the server publishes.
+	Ports []models.GameServerPort
+	// MemoryMB is the memory limit of the server. Automatic placement skips nodes with less free memory.
+	MemoryMB int
+}
+
+// candidate is a node considered for placement.
+type candidate struct {
+	member     Member
+	node       models.Node
+	servers    int64
+	freeMemory uint64
+}
+
+// Place picks the node a new game server runs on and returns its ID, nil for the local node.
+// Nodes must have the requested host ports free and room for another server.
+// Among those, automatic placement prefers the node with the most free memory,
+// then the one with the fewest servers.
+// Without remote nodes there is no choice to make and the local node is returned as is.
+func (p *Pool) Place(ctx context.Context, db *gorm.DB, req PlacementRequest) (*uint, error) {
+	members := p.Members()
+	if len(members) == 1 && req.Node == "" && len(req.Selector) == 0 {
+		return nil, nil
+	}
+	ports := req.Ports
+	if req.Node != "" {
+		member, err := p.Lookup(req.Node)
+		if err != nil {
+			return nil, err
+		}
+		members = []Member{member}
+		// Port conflicts on a chosen node are reported by the PortAllocator
+		ports = nil
+	}
+
+	nodes := make(map[uint]models.Node)
+	var records []models.Node
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, n := range records {
+		nodes[n.ID] = n
+	}
+
+	var candidates []candidate
+	var reasons []error
+	for _, member := range members {
+		c := candidate{member: member, node: models.Node{Name: member.Name, Enabled: true}}
+		if member.ID != nil {
+			c.node = nodes[*member.ID]
+		}
+
+		if req.Node == "" && (!c.node.Enabled || !matchLabels(c.node.Labels, req.Selector)) {
+			continue
+		}
+		if err := p.evaluate(ctx, db, &c, ports); err != nil {
+			reasons = append(reasons, fmt.Errorf("%s: %w", member.Name, err))
+			continue
+		}
+		if need := uint64(req.MemoryMB) << 20; req.Node == "" && c.freeMemory < need {
+			reasons = append(reasons, fmt.Errorf("%s: not enough free memory (%d MB)", member.Name, c.freeMemory>>20))
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	if len(candidates) == 0 {
+		if len(reasons) > 0 {
+			return nil, fmt.Errorf("%w: %w", ErrNoNodeAvailable, errors.Join(reasons...))
+		}
+		return nil, ErrNoNodeAvailable
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].freeMemory != candidates[j].freeMemory {
+			return candidates[i].freeMemory > candidates[j].freeMemory
+		}
+		return candidates[i].servers < candidates[j].servers
+	})
+	return candidates[0].member.ID, nil
+}
+
+// evaluate checks that a node can host a server publishing ports and
+// records its server count and free memory.
+func (p *Pool) evaluate(ctx context.Context, db *gorm.DB, c *candidate, ports []models.GameServerPort) error {
+	servers := onNode(db.Model(&models.GameServer{}), c.member.ID)
+	if err := servers.Count(&c.servers).Error; err != nil {
+		return err
+	}
+	if c.node.MaxServers > 0 && c.servers >= int64(c.node.MaxServers) {
+		return fmt.Errorf("node is full (%d servers)", c.node.MaxServers)
+	}
+
+	var used []models.GameServerPort
+	err := db.Joins("JOIN game_servers ON game_servers.id = game_server_ports.game_server_id AND game_servers.deleted_at IS NULL").
+		Where(nodeCondition(c.member.ID, "game_servers.node_id")).
+		Find(&used).Error
+	if err != nil {
+		return err
+	}
+	for _, want := range ports {
+		for _, u := range used {
+			if u.HostPort == want.HostPort && u.Protocol == want.Protocol {
+				return fmt.Errorf("port %d/%s is already in use", want.HostPort, want.Protocol)
+			}
+		}
+	}
+
+	info, err := c.member.Runtime.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("node is unreachable: %w", err)
+	}
+	if c.node.MemoryMB == 0 && info.MemoryFree > 0 {
+		c.freeMemory = info.MemoryFree
+		return nil
+	}
+
+	capacity := info.MemoryTotal
+	if c.node.MemoryMB > 0 {
+		capacity = uint64(c.node.MemoryMB) << 20
+	}
+	usage, err := memoryUsage(db, c.member.ID)
+	if err != nil {
+		return err
+	}
+	if usage < capacity {
+		c.freeMemory = capacity - usage
+	}
+	return nil
+}
+
+// memoryUsage sums the latest sampled memory usage of the running servers on a node.
+func memoryUsage(db *gorm.DB, nodeID *uint) (uint64, error) {
+	var ids []uint
+	err := onNode(db.Model(&models.GameServer{}), nodeID).
+		Where("status IN ?", models.RunningStatuses).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	for _, id := range ids {
+		var samples []models.GameServerStat
+		err := db.Where("game_server_id = ? AND resolution = ?", id, models.StatResolutionMinute).
+			Order("timestamp DESC").Limit(1).Find(&samples).Error
+		if err != nil {
+			return 0, err
+		}
+		if len(samples) > 0 {
+			total += samples[0].MemoryUsage
+		}
+	}
+	return total, nil
+}
+
+// onNode restricts a game server query to the servers on a node.
+func onNode(query *gorm.DB, nodeID *uint) *gorm.DB {
+	if nodeID == nil {
+		return query.Where("node_id IS NULL")
+	}
+	return query.Where("node_id = ?", *nodeID)
+}
+
+// nodeCondition returns a condition matching column against a node.
+func nodeCondition(nodeID *uint, column string) string {
+	if nodeID == nil {
+		return column + " IS NULL"
+	}
+	return fmt.Sprintf("%s = %d", column, *nodeID)
+}
+
+// matchLabels reports whether labels contain every key and value of selector.
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
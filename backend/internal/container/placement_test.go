@@ -0,0 +1,159 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func setupPlacementTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.GameServer{}, &models.GameServerPort{}, &models.GameServerStat{}))
+	return db
+}
+
+// mockNode returns a Podman service reporting memFree bytes of free memory.
+func mockNode(t *testing.T, memFree uint64) *Service {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v5.0.0/libpod/info" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"host":{"hostname":"node","cpus":4,"memTotal":%d,"memFree":%d}}`, uint64(16<<30), memFree)
+	}))
+	t.Cleanup(server.Close)
+	return NewService(server.URL)
+}
+
+func TestPool_Place(t *testing.T) {
+	ctx := context.Background()
+	port := []models.GameServerPort{{HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}}
+
+	setup := func(t *testing.T) (*gorm.DB, *Pool, models.Node, models.Node) {
+		db := setupPlacementTestDB(t)
+		small := models.Node{Name: "small", Endpoint: "tcp://small:8080", Enabled: true, Labels: map[string]string{"region": "tokyo"}}
+		large := models.Node{Name: "large", Endpoint: "tcp://large:8080", Enabled: true, Labels: map[string]string{"region": "osaka"}}
+		require.NoError(t, db.Create(&small).Error)
+		require.NoError(t, db.Create(&large).Error)
+
+		pool := NewPool(mockNode(t, 1<<30))
+		pool.Set(small.ID, small.Name, mockNode(t, 2<<30))
+		pool.Set(large.ID, large.Name, mockNode(t, 8<<30))
+		return db, pool, small, large
+	}
+
+	t.Run("should use the local node when there are no other nodes", func(t *testing.T) {
+		db := setupPlacementTestDB(t)
+		nodeID, err := NewPool(NewService("http://localhost:0")).Place(ctx, db, PlacementRequest{Ports: port})
+		require.NoError(t, err)
+		assert.Nil(t, nodeID)
+	})
+
+	t.Run("should prefer the node with the most free memory", func(t *testing.T) {
+		db, pool, _, large := setup(t)
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{Ports: port})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, large.ID, *nodeID)
+	})
+
+	t.Run("should subtract server memory from the configured capacity", func(t *testing.T) {
+		db, pool, small, large := setup(t)
+		require.NoError(t, db.Model(&small).Update("memory_mb", 16384).Error)
+		require.NoError(t, db.Model(&large).Update("memory_mb", 16384).Error)
+		server := models.GameServer{Slug: "busy", Name: "busy", Image: "mc", Status: models.GameServerStatusRunning, NodeID: &large.ID}
+		require.NoError(t, db.Create(&server).Error)
+		require.NoError(t, db.Create(&models.GameServerStat{GameServerID: server.ID, Resolution: models.StatResolutionMinute, MemoryUsage: 4 << 30}).Error)
+
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, small.ID, *nodeID)
+	})
+
+	t.Run("should skip nodes whose host ports are taken", func(t *testing.T) {
+		db, pool, small, large := setup(t)
+		server := models.GameServer{Slug: "mc", Name: "mc", Image: "mc", NodeID: &large.ID}
+		require.NoError(t, db.Create(&server).Error)
+		require.NoError(t, db.Create(&models.GameServerPort{GameServerID: server.ID, HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}).Error)
+
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{Ports: port})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, small.ID, *nodeID)
+	})
+
+	t.Run("should skip nodes without enough free memory for the limit", func(t *testing.T) {
+		db, pool, _, large := setup(t)
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{MemoryMB: 4096})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, large.ID, *nodeID)
+
+		_, err = pool.Place(ctx, db, PlacementRequest{MemoryMB: 16384})
+		assert.ErrorIs(t, err, ErrNoNodeAvailable)
+	})
+
+	t.Run("should skip full and disabled nodes", func(t *testing.T) {
+		db, pool, small, large := setup(t)
+		require.NoError(t, db.Model(&large).Update("max_servers", 1).Error)
+		require.NoError(t, db.Create(&models.GameServer{Slug: "mc", Name: "mc", Image: "mc", NodeID: &large.ID}).Error)
+		require.NoError(t, db.Model(&small).Update("enabled", false).Error)
+
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{})
+		require.NoError(t, err)
+		assert.Nil(t, nodeID)
+	})
+
+	t.Run("should only consider nodes matching the selector", func(t *testing.T) {
+		db, pool, small, _ := setup(t)
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{Selector: map[string]string{"region": "tokyo"}})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, small.ID, *nodeID)
+
+		_, err = pool.Place(ctx, db, PlacementRequest{Selector: map[string]string{"region": "sapporo"}})
+		assert.ErrorIs(t, err, ErrNoNodeAvailable)
+	})
+
+	t.Run("should use the requested node", func(t *testing.T) {
+		db, pool, small, _ := setup(t)
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{Node: "small"})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, small.ID, *nodeID)
+
+		nodeID, err = pool.Place(ctx, db, PlacementRequest{Node: LocalNode})
+		require.NoError(t, err)
+		assert.Nil(t, nodeID)
+	})
+
+	t.Run("should not check the ports of the requested node", func(t *testing.T) {
+		db, pool, small, _ := setup(t)
+		server := models.GameServer{Slug: "mc", Name: "mc", Image: "mc", NodeID: &small.ID}
+		require.NoError(t, db.Create(&server).Error)
+		require.NoError(t, db.Create(&models.GameServerPort{GameServerID: server.ID, HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}).Error)
+
+		nodeID, err := pool.Place(ctx, db, PlacementRequest{Node: "small", Ports: port})
+		require.NoError(t, err)
+		require.NotNil(t, nodeID)
+		assert.Equal(t, small.ID, *nodeID)
+	})
+
+	t.Run("should return ErrNodeNotFound for unknown nodes", func(t *testing.T) {
+		db, pool, _, _ := setup(t)
+		_, err := pool.Place(ctx, db, PlacementRequest{Node: "missing"})
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+	})
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// LocalNode is the name of the node configured in config.toml.
+const LocalNode = "local"
+
+// ErrNodeNotFound is returned for nodes that are not in the pool.
+var ErrNodeNotFound = errors.New("node not found")
+
+// Member is a node of a Pool.
+type Member struct {
+	// ID is the node ID, nil for the local node.
+	ID *uint
+	// Name is the node name.
+	Name string
+	// Runtime is the container engine of the node.
+	Runtime Runtime
+}
+
+// Pool holds the runtime of every node game servers can run on.
+// Game servers without a node run on the local runtime.
+type Pool struct {
+	mu    sync.RWMutex
+	local Runtime
+	nodes map[uint]Member
+}
+
+// NewPool creates a pool with local as the runtime of the local node.
+func NewPool(local Runtime) *Pool {
+	return &Pool{
+		local: local,
+		nodes: make(map[uint]Member),
+	}
+}
+
+// Connect creates the runtime of a node and adds it to the pool,
+// replacing the previous runtime of the node.
+func (p *Pool) Connect(node models.Node) error {
+	runtime, err := NewRuntime(node.Runtime, node.Endpoint, ConnectOptions{
+		TLSCACert:     node.TLSCACert,
+		TLSCert:       node.TLSCert,
+		TLSKey:        node.TLSKey,
+		TLSInsecure:   node.TLSInsecure,
+		SSHKeyPath:    node.SSHKeyPath,
+		SSHKnownHosts: node.SSHKnownHosts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to node %s: %w", node.Name, err)
+	}
+	p.Set(node.ID, node.Name, runtime)
+	return nil
+}
+
+// Set adds the runtime of a node to the pool.
+func (p *Pool) Set(id uint, name string, runtime Runtime) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nodes[id] = Member{ID: &id, Name: name, Runtime: runtime}
+}
+
+// Remove removes a node from the pool.
+func (p *Pool) Remove(id uint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.nodes, id)
+}
+
+// Local returns the runtime of the local node.
+func (p *Pool) Local() Runtime {
+	return p.local
+}
+
+// Runtime returns the runtime of a node; nil selects the local node.
+func (p *Pool) Runtime(nodeID *uint) (Runtime, error) {
+	if nodeID == nil {
+		return p.local, nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	member, ok := p.nodes[*nodeID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrNodeNotFound, *nodeID)
+	}
+	return member.Runtime, nil
+}
+
+// Lookup returns the node with the given name. An empty name selects the local node.
+func (p *Pool) Lookup(name string) (Member, error) {
+	if name == "" || name == LocalNode {
+		return Member{Name: LocalNode, Runtime: p.local}, nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, member := range p.nodes {
+		if member.Name == name {
+			return member, nil
+		}
+	}
+	return Member{}, fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+}
+
+// Members returns all nodes, the local node first and the others by name.
+func (p *Pool) Members() []Member {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	members := make([]Member, 0, len(p.nodes)+1)
+	for _, member := range p.nodes {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return append([]Member{{Name: LocalNode, Runtime: p.local}}, members...)
+}
@@ -0,0 +1,170 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrPortConflict is returned when a requested host port is already in use.
+var ErrPortConflict = errors.New("host port is already in use")
+
+// ErrNoFreePort is returned when the port range has no free port left.
+var ErrNoFreePort = errors.New("no free host port in range")
+
+// PortRequest is a container port that needs a host port.
+type PortRequest struct {
+	// Name identifies the port in errors (e.g. "game" or "rcon").
+	Name string
+	// ContainerPort is the port inside the container.
+	ContainerPort int
+	// Protocol is tcp or udp.
+	Protocol string
+	// HostPort is the host port chosen by the user. A port is allocated when 0.
+	HostPort int
+	// Preferred is tried before the range when a port is allocated, usually the game's default port.
+	Preferred int
+}
+
+// portKey identifies a host port binding; tcp and udp ports are separate.
+type portKey struct {
+	port     int
+	protocol string
+}
+
+// PortAllocator assigns host ports to game servers. Ports stored for other
+// servers on the same node and ports bound by any container on the node are
+// considered taken.
+type PortAllocator struct {
+	mu    sync.Mutex
+	db    *gorm.DB
+	nodes *Pool
+	start int
+	end   int
+}
+
+// NewPortAllocator creates an allocator picking ports from start to end (inclusive).
+func NewPortAllocator(db *gorm.DB, nodes *Pool, start, end int) *PortAllocator {
+	return &PortAllocator{db: db, nodes: nodes, start: start, end: end}
+}
+
+// SetRange changes the range ports are allocated from.
+func (a *PortAllocator) SetRange(start, end int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.start = start
+	a.end = end
+}
+
+// Allocate assigns host ports on a node to reqs.
+// Manual ports that are taken fail with ErrPortConflict; the others get their
+// preferred port when it is free and the lowest free port of the range otherwise.
+// Allocations are serialized until release is called, so the caller must store
+// the ports before releasing.
+func (a *PortAllocator) Allocate(ctx context.Context, nodeID *uint, reqs []PortRequest) ([]models.GameServerPort, func(), error) {
+	a.mu.Lock()
+	ports, err := a.allocate(ctx, nodeID, reqs)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, nil, err
+	}
+	return ports, sync.OnceFunc(a.mu.Unlock), nil
+}
+
+// allocate assigns the ports. The caller must hold mu.
+func (a *PortAllocator) allocate(ctx context.Context, nodeID *uint, reqs []PortRequest) ([]models.GameServerPort, error) {
+	used, err := a.used(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Manual ports first, so that allocated ports cannot take them
+	ports := make([]models.GameServerPort, len(reqs))
+	for i, req := range reqs {
+		if req.HostPort == 0 {
+			continue
+		}
+		key := portKey{req.HostPort, req.Protocol}
+		if owner, ok := used[key]; ok {
+			return nil, fmt.Errorf("%w: %d/%s is used by %s", ErrPortConflict, req.HostPort, req.Protocol, owner)
+		}
+		used[key] = "this server"
+		ports[i] = models.GameServerPort{HostPort: req.HostPort, ContainerPort: req.ContainerPort, Protocol: req.Protocol}
+	}
+
+	for i, req := range reqs {
+		if req.HostPort != 0 {
+			continue
+		}
+		port, ok := a.free(used, req)
+		if !ok {
+			return nil, fmt.Errorf("%w %d-%d for port %s (%s)", ErrNoFreePort, a.start, a.end, req.Name, req.Protocol)
+		}
+		used[portKey{port, req.Protocol}] = "this server"
+		ports[i] = models.GameServerPort{HostPort: port, ContainerPort: req.ContainerPort, Protocol: req.Protocol}
+	}
+
+	return ports, nil
+}
+
+// free returns the preferred port of req if it is free, otherwise the lowest free port of the range.
+func (a *PortAllocator) free(used map[portKey]string, req PortRequest) (int, bool) {
+	if req.Preferred > 0 {
+		if _, ok := used[portKey{req.Preferred, req.Protocol}]; !ok {
+			return req.Preferred, true
+		}
+	}
+	for port := a.start; port <= a.end; port++ {
+		if _, ok := used[portKey{port, req.Protocol}]; !ok {
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+// used returns the host ports taken on a node, mapped to what uses them.
+// Container bindings are best effort: an unreachable node only reports stored ports.
+func (a *PortAllocator) used(ctx context.Context, nodeID *uint) (map[portKey]string, error) {
+	var rows []struct {
+		HostPort int
+		Protocol string
+		Slug     string
+	}
+	err := a.db.Model(&models.GameServerPort{}).
+		Select("game_server_ports.host_port, game_server_ports.protocol, game_servers.slug").
+		Joins("JOIN game_servers ON game_servers.id = game_server_ports.game_server_id AND game_servers.deleted_at IS NULL").
+		Where(nodeCondition(nodeID, "game_servers.node_id")).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load used ports: %w", err)
+	}
+
+	used := make(map[portKey]string, len(rows))
+	for _, row := range rows {
+		used[portKey{row.HostPort, row.Protocol}] = fmt.Sprintf("game server %q", row.Slug)
+	}
+
+	runtime, err := a.nodes.Runtime(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := runtime.List(ctx)
+	if err != nil {
+		slog.Warn("Failed to list containers for port allocation", "error", err)
+		return used, nil
+	}
+	for _, ctr := range containers {
+		for _, p := range ctr.Ports {
+			key := portKey{int(p.HostPort), p.Protocol}
+			if _, ok := used[key]; !ok && p.HostPort != 0 {
+				used[key] = fmt.Sprintf("container %q", ctr.Name)
+			}
+		}
+	}
+	return used, nil
+}
@@ -0,0 +1,123 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestPortAllocator_Allocate(t *testing.T) {
+	ctx := context.Background()
+
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v5.0.0/libpod/containers/json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"Id":"abc","Names":["proxy"],"State":"running","Ports":[{"host_port":8080,"container_port":80,"protocol":"tcp"}]}]`))
+	}))
+	defer mockPodman.Close()
+
+	setup := func(t *testing.T) (*gorm.DB, *PortAllocator) {
+		db := setupPlacementTestDB(t)
+		server := models.GameServer{Slug: "survival", Name: "survival", Image: "mc"}
+		require.NoError(t, db.Create(&server).Error)
+		require.NoError(t, db.Create(&models.GameServerPort{GameServerID: server.ID, HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}).Error)
+		return db, NewPortAllocator(db, NewPool(NewService(mockPodman.URL)), 30000, 30002)
+	}
+
+	allocate := func(a *PortAllocator, reqs ...PortRequest) ([]models.GameServerPort, error) {
+		ports, release, err := a.Allocate(ctx, nil, reqs)
+		if err == nil {
+			release()
+		}
+		return ports, err
+	}
+
+	t.Run("should use the preferred port when it is free", func(t *testing.T) {
+		_, a := setup(t)
+		ports, err := allocate(a, PortRequest{Name: "game", ContainerPort: 7777, Protocol: "udp", Preferred: 7777})
+		require.NoError(t, err)
+		assert.Equal(t, 7777, ports[0].HostPort)
+	})
+
+	t.Run("should allocate from the range when the preferred port is stored for another server", func(t *testing.T) {
+		_, a := setup(t)
+		ports, err := allocate(a,
+			PortRequest{Name: "game", ContainerPort: 25565, Protocol: "tcp", Preferred: 25565},
+			PortRequest{Name: "rcon", ContainerPort: 25575, Protocol: "tcp", Preferred: 25575},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 30000, ports[0].HostPort)
+		assert.Equal(t, 25565, ports[0].ContainerPort)
+		assert.Equal(t, 25575, ports[1].HostPort)
+	})
+
+	t.Run("should treat tcp and udp separately", func(t *testing.T) {
+		_, a := setup(t)
+		ports, err := allocate(a, PortRequest{Name: "game", ContainerPort: 25565, Protocol: "udp", HostPort: 25565})
+		require.NoError(t, err)
+		assert.Equal(t, 25565, ports[0].HostPort)
+	})
+
+	t.Run("should reject manual ports stored for another server", func(t *testing.T) {
+		_, a := setup(t)
+		_, err := allocate(a, PortRequest{Name: "game", ContainerPort: 25565, Protocol: "tcp", HostPort: 25565})
+		assert.ErrorIs(t, err, ErrPortConflict)
+		assert.Contains(t, err.Error(), `game server "survival"`)
+	})
+
+	t.Run("should reject manual ports bound by a container", func(t *testing.T) {
+		_, a := setup(t)
+		_, err := allocate(a, PortRequest{Name: "web", ContainerPort: 80, Protocol: "tcp", HostPort: 8080})
+		assert.ErrorIs(t, err, ErrPortConflict)
+		assert.Contains(t, err.Error(), `container "proxy"`)
+	})
+
+	t.Run("should reject the same port requested twice", func(t *testing.T) {
+		_, a := setup(t)
+		_, err := allocate(a,
+			PortRequest{Name: "a", ContainerPort: 1000, Protocol: "tcp", HostPort: 1000},
+			PortRequest{Name: "b", ContainerPort: 1001, Protocol: "tcp", HostPort: 1000},
+		)
+		assert.ErrorIs(t, err, ErrPortConflict)
+	})
+
+	t.Run("should not hand out manual ports to allocated ones", func(t *testing.T) {
+		_, a := setup(t)
+		ports, err := allocate(a,
+			PortRequest{Name: "a", ContainerPort: 1000, Protocol: "tcp"},
+			PortRequest{Name: "b", ContainerPort: 1001, Protocol: "tcp", HostPort: 30000},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 30001, ports[0].HostPort)
+		assert.Equal(t, 30000, ports[1].HostPort)
+	})
+
+	t.Run("should fail when the range is exhausted", func(t *testing.T) {
+		_, a := setup(t)
+		_, err := allocate(a,
+			PortRequest{Name: "a", ContainerPort: 1, Protocol: "tcp"},
+			PortRequest{Name: "b", ContainerPort: 2, Protocol: "tcp"},
+			PortRequest{Name: "c", ContainerPort: 3, Protocol: "tcp"},
+			PortRequest{Name: "d", ContainerPort: 4, Protocol: "tcp"},
+		)
+		assert.ErrorIs(t, err, ErrNoFreePort)
+	})
+
+	t.Run("should ignore ports of servers on other nodes", func(t *testing.T) {
+		db, a := setup(t)
+		nodeID := uint(3)
+		require.NoError(t, db.Model(&models.GameServer{}).Where("slug = ?", "survival").Update("node_id", nodeID).Error)
+
+		ports, err := allocate(a, PortRequest{Name: "game", ContainerPort: 25565, Protocol: "tcp", HostPort: 25565})
+		require.NoError(t, err)
+		assert.Equal(t, 25565, ports[0].HostPort)
+	})
+}
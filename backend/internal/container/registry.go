@@ -0,0 +1,221 @@
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// manifestMediaTypes are the manifest formats accepted from registries.
+// Manifest lists come first so multi-arch images resolve to their index digest,
+// which is what Podman records as the repository digest when pulling them.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// RegistryClient looks up image digests in container registries using the
+// registry HTTP API. Public images are supported through anonymous tokens.
+type RegistryClient struct {
+	client *http.Client
+	// baseURL returns the API base URL of a registry host.
+	baseURL func(host string) string
+}
+
+// NewRegistryClient creates a new registry client.
+func NewRegistryClient() *RegistryClient {
+	return &RegistryClient{
+		client: &http.Client{Timeout: 30 * time.Second},
+		baseURL: func(host string) string {
+			return "https://" + host
+		},
+	}
+}
+
+// imageReference is a parsed image reference.
+type imageReference struct {
+	host       string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseReference splits an image reference into registry host, repository, and tag or digest.
+// References without a registry resolve to Docker Hub like they do in Podman's default configuration.
+func parseReference(ref string) imageReference {
+	var r imageReference
+	if i := strings.Index(ref, "@"); i >= 0 {
+		r.digest = ref[i+1:]
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		r.tag = ref[i+1:]
+		ref = ref[:i]
+	}
+	if r.tag == "" && r.digest == "" {
+		r.tag = "latest"
+	}
+
+	first, rest, found := strings.Cut(ref, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		r.host = first
+		r.repository = rest
+	} else {
+		r.host = "docker.io"
+		r.repository = ref
+	}
+	if r.host == "docker.io" {
+		r.host = "registry-1.docker.io"
+		if !strings.Contains(r.repository, "/") {
+			r.repository = "library/" + r.repository
+		}
+	}
+	return r
+}
+
+// Digest returns the manifest digest a reference currently points to in its registry.
+// References that already contain a digest return it without contacting the registry.
+func (r *RegistryClient) Digest(ctx context.Context, reference string) (string, error) {
+	ref := parseReference(reference)
+	if ref.digest != "" {
+		return ref.digest, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/v2/%s/manifests/%s", r.baseURL(ref.host), ref.repository, url.PathEscape(ref.tag))
+	resp, err := r.manifest(ctx, http.MethodHead, endpoint, "")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	var token string
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err = r.token(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", fmt.Errorf("failed to authenticate to %s: %w", ref.host, err)
+		}
+		resp, err = r.manifest(ctx, http.MethodHead, endpoint, token)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); resp.StatusCode == http.StatusOK && digest != "" {
+		return digest, nil
+	}
+	return r.manifestDigest(ctx, endpoint, token, reference)
+}
+
+// manifestDigest downloads a manifest and computes its digest, for registries
+// that do not report it in the Docker-Content-Digest header.
+func (r *RegistryClient) manifestDigest(ctx context.Context, endpoint, token, reference string) (string, error) {
+	resp, err := r.manifest(ctx, http.MethodGet, endpoint, token)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("failed to get manifest of %s: status %d: %s", reference, resp.StatusCode, string(body))
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to read manifest of %s: %w", reference, err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// manifest requests a manifest endpoint.
+func (r *RegistryClient) manifest(ctx context.Context, method, endpoint, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry: %w", err)
+	}
+	return resp, nil
+}
+
+// token requests an anonymous bearer token for the challenge of a registry.
+func (r *RegistryClient) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+
+	values := parseChallenge(params)
+	realm := values["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("auth challenge has no realm: %q", challenge)
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if v := values[key]; v != "" {
+			query.Set(key, v)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned status %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if data.Token != "" {
+		return data.Token, nil
+	}
+	return data.AccessToken, nil
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of a WWW-Authenticate header.
+func parseChallenge(params string) map[string]string {
+	values := make(map[string]string)
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(strings.TrimLeft(params, " ,"), "=")
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return values
+}
@@ -0,0 +1,83 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want imageReference
+	}{
+		{"nginx", imageReference{host: "registry-1.docker.io", repository: "library/nginx", tag: "latest"}},
+		{"itzg/minecraft-server:java21", imageReference{host: "registry-1.docker.io", repository: "itzg/minecraft-server", tag: "java21"}},
+		{"docker.io/itzg/minecraft-server", imageReference{host: "registry-1.docker.io", repository: "itzg/minecraft-server", tag: "latest"}},
+		{"ghcr.io/owner/game:1.0", imageReference{host: "ghcr.io", repository: "owner/game", tag: "1.0"}},
+		{"localhost:5000/game", imageReference{host: "localhost:5000", repository: "game", tag: "latest"}},
+		{"ghcr.io/owner/game@sha256:abc", imageReference{host: "ghcr.io", repository: "owner/game", digest: "sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("should parse "+tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseReference(tt.ref))
+		})
+	}
+}
+
+func TestRegistryClient_Digest(t *testing.T) {
+	var registry *httptest.Server
+	registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.Equal(t, "repository:library/nginx:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+		case "/v2/library/nginx/manifests/latest":
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+registry.URL+`/token",service="registry.test",scope="repository:library/nginx:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:remote")
+		case "/v2/library/plain/manifests/latest":
+			// No digest header: the client hashes the manifest itself
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+
+	client := NewRegistryClient()
+	client.baseURL = func(string) string { return registry.URL }
+	ctx := context.Background()
+
+	t.Run("should get the digest with an anonymous token", func(t *testing.T) {
+		digest, err := client.Digest(ctx, "nginx")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:remote", digest)
+	})
+
+	t.Run("should hash the manifest when the registry sends no digest", func(t *testing.T) {
+		digest, err := client.Digest(ctx, "plain")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", digest)
+	})
+
+	t.Run("should return pinned digests without contacting the registry", func(t *testing.T) {
+		digest, err := client.Digest(ctx, "nginx@sha256:pinned")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:pinned", digest)
+	})
+
+	t.Run("should fail for unknown images", func(t *testing.T) {
+		_, err := client.Digest(ctx, "missing")
+		assert.Error(t, err)
+	})
+}
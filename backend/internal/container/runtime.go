@@ -0,0 +1,107 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// Runtime kinds selectable in the configuration.
+const (
+	// RuntimePodman selects the Podman libpod API.
+	RuntimePodman = "podman"
+	// RuntimeDocker selects the Docker Engine API.
+	RuntimeDocker = "docker"
+)
+
+// ErrNotSupported is returned for operations the runtime does not provide.
+var ErrNotSupported = errors.New("operation not supported by the container runtime")
+
+// Runtime is a container engine that game servers run on.
+type Runtime interface {
+	// Host returns the host name that published container ports are reachable on.
+	Host() string
+	// Info describes the machine the engine runs on.
+	Info(ctx context.Context) (*models.HostInfo, error)
+
+	// List returns all containers.
+	List(ctx context.Context) ([]models.Container, error)
+	// Get returns a specific container by ID or name.
+	Get(ctx context.Context, id string) (*models.Container, error)
+	// Create creates a new container and returns its ID.
+	Create(ctx context.Context, opts CreateOptions) (string, error)
+	// Update changes the resource limits and restart policy of a container.
+	Update(ctx context.Context, id string, opts UpdateOptions) error
+	// Start starts a container.
+	Start(ctx context.Context, id string) error
+	// Stop stops a container, waiting up to timeout seconds before killing it.
+	Stop(ctx context.Context, id string, timeout uint) error
+	// Wait blocks until a container has stopped and returns its exit code.
+	Wait(ctx context.Context, id string) (int, error)
+	// Remove removes a container.
+	Remove(ctx context.Context, id string, force bool) error
+	// Rename changes the name of a container.
+	Rename(ctx context.Context, id, name string) error
+
+	// Logs returns the last lines of container logs.
+	Logs(ctx context.Context, id string, lines int) ([]models.ContainerLogEntry, error)
+	// StreamLogs follows the logs of a container.
+	StreamLogs(ctx context.Context, id string, lines int, fn func(models.ContainerLogEntry) error) error
+	// Attach attaches to the main process of a running container.
+	Attach(ctx context.Context, id string) (*Session, error)
+	// ExecAttach starts a command in a container and returns an interactive session.
+	ExecAttach(ctx context.Context, id string, cmd []string) (*Session, error)
+	// Exec runs a command in a container and waits for it to finish.
+	Exec(ctx context.Context, id string, cmd []string) (*ExecResult, error)
+
+	// Stats returns a single resource usage sample of a running container.
+	Stats(ctx context.Context, id string) (*models.ContainerStats, error)
+	// StreamStats samples a running container every interval.
+	StreamStats(ctx context.Context, id string, interval time.Duration, fn func(models.ContainerStats) error) error
+	// Events follows container lifecycle events.
+	Events(ctx context.Context, fn func(models.ContainerEvent) error) error
+
+	// VolumeExists reports whether a named volume exists.
+	VolumeExists(ctx context.Context, name string) (bool, error)
+	// CreateVolume creates a named volume.
+	CreateVolume(ctx context.Context, name string, labels map[string]string) error
+	// EnsureVolume creates a named volume unless it already exists.
+	EnsureVolume(ctx context.Context, name string, labels map[string]string) error
+	// RemoveVolume removes a named volume.
+	RemoveVolume(ctx context.Context, name string, force bool) error
+	// ExportVolume writes the contents of a named volume to w as a tar archive.
+	ExportVolume(ctx context.Context, name string, w io.Writer) error
+
+	// ListImages returns all images in the local store.
+	ListImages(ctx context.Context) ([]models.Image, error)
+	// GetImage returns an image by ID or reference.
+	GetImage(ctx context.Context, name string) (*models.Image, error)
+	// PullImage pulls an image and returns its ID.
+	PullImage(ctx context.Context, reference string, fn func(models.ImagePullProgress) error) (string, error)
+	// RemoveImage removes an image.
+	RemoveImage(ctx context.Context, name string, force bool) error
+	// PruneImages removes dangling images.
+	PruneImages(ctx context.Context) (*models.ImagePruneReport, error)
+}
+
+var (
+	_ Runtime = (*Service)(nil)
+	_ Runtime = (*DockerService)(nil)
+)
+
+// NewRuntime creates the runtime of the given kind connected to socketPath.
+// An empty kind selects Podman.
+func NewRuntime(kind, socketPath string, opts ConnectOptions) (Runtime, error) {
+	switch kind {
+	case "", RuntimePodman:
+		return &Service{apiClient: newAPIClient(socketPath, "/v5.0.0/libpod", opts)}, nil
+	case RuntimeDocker:
+		return &DockerService{apiClient: newAPIClient(socketPath, dockerAPIPrefix, opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown container runtime %q", kind)
+	}
+}
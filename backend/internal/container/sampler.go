@@ -0,0 +1,165 @@
+package container
+
+import (
+	"context"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultSampleInterval is how often the sampler records stats.
+	DefaultSampleInterval = time.Minute
+	// minuteRetention is how long raw samples are kept.
+	minuteRetention = 24 * time.Hour
+	// hourRetention is how long hourly averages are kept.
+	hourRetention = 7 * 24 * time.Hour
+)
+
+// Sampler periodically records the resource usage of running game servers.
+// Raw samples are kept for a day; every hour they are averaged into an
+// hourly sample that is kept for a week.
+type Sampler struct {
+	db         *gorm.DB
+	nodes      *Pool
+	interval   time.Duration
+	lastRollup time.Time
+}
+
+// NewSampler creates a new stats sampler for the game servers on the nodes of a pool.
+func NewSampler(db *gorm.DB, nodes *Pool) *Sampler {
+	return &Sampler{
+		db:       db,
+		nodes:    nodes,
+		interval: DefaultSampleInterval,
+	}
+}
+
+// Run records samples every interval until ctx is canceled.
+func (s *Sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			_ = s.Sample(ctx, now)
+		}
+	}
+}
+
+// Sample records the current stats of every running game server, then rolls
+// up the hours completed since the last rollup and removes expired samples.
+// Servers whose stats cannot be read are skipped.
+func (s *Sampler) Sample(ctx context.Context, now time.Time) error {
+	now = now.UTC()
+
+	var servers []models.GameServer
+	err := s.db.Where("status IN ? AND container_id <> ''", models.RunningStatuses).Find(&servers).Error
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		runtime, err := s.nodes.Runtime(server.NodeID)
+		if err != nil {
+			continue
+		}
+		stats, err := runtime.Stats(ctx, server.ContainerID)
+		if err != nil {
+			continue
+		}
+		sample := statFromContainer(server.ID, stats, now)
+		if err := s.db.Create(&sample).Error; err != nil {
+			return err
+		}
+	}
+
+	// Roll up every hour completed since the last rollup. After a restart,
+	// every hour raw samples are still kept for is checked.
+	hour := now.Truncate(time.Hour)
+	from := s.lastRollup
+	if oldest := hour.Add(-minuteRetention); from.Before(oldest) {
+		from = oldest
+	}
+	for ; from.Before(hour); from = from.Add(time.Hour) {
+		if err := s.rollup(from, from.Add(time.Hour)); err != nil {
+			return err
+		}
+		s.lastRollup = from.Add(time.Hour)
+	}
+
+	return s.prune(now)
+}
+
+// rollup stores the average of the raw samples in [from, to) as hourly samples.
+func (s *Sampler) rollup(from, to time.Time) error {
+	var averages []models.GameServerStat
+	err := s.db.Model(&models.GameServerStat{}).
+		Select(`game_server_id,
+			AVG(cpu_percent) AS cpu_percent,
+			CAST(AVG(memory_usage) AS INTEGER) AS memory_usage,
+			MAX(memory_limit) AS memory_limit,
+			AVG(memory_percent) AS memory_percent,
+			MAX(network_rx) AS network_rx,
+			MAX(network_tx) AS network_tx,
+			MAX(block_read) AS block_read,
+			MAX(block_write) AS block_write`).
+		Where("resolution = ? AND timestamp >= ? AND timestamp < ?", models.StatResolutionMinute, from, to).
+		Group("game_server_id").
+		Scan(&averages).Error
+	if err != nil {
+		return err
+	}
+
+	for _, avg := range averages {
+		// Skip hours that were already rolled up before a restart
+		var count int64
+		s.db.Model(&models.GameServerStat{}).
+			Where("game_server_id = ? AND resolution = ? AND timestamp = ?", avg.GameServerID, models.StatResolutionHour, from).
+			Count(&count)
+		if count > 0 {
+			continue
+		}
+
+		avg.ID = 0
+		avg.Resolution = models.StatResolutionHour
+		avg.Timestamp = from
+		if err := s.db.Create(&avg).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prune removes samples that are older than their retention.
+func (s *Sampler) prune(now time.Time) error {
+	err := s.db.Where("resolution = ? AND timestamp < ?", models.StatResolutionMinute, now.Add(-minuteRetention)).
+		Delete(&models.GameServerStat{}).Error
+	if err != nil {
+		return err
+	}
+	return s.db.Where("resolution = ? AND timestamp < ?", models.StatResolutionHour, now.Add(-hourRetention)).
+		Delete(&models.GameServerStat{}).Error
+}
+
+// statFromContainer converts container stats to a raw sample of a game server.
+func statFromContainer(serverID uint, stats *models.ContainerStats, t time.Time) models.GameServerStat {
+	return models.GameServerStat{
+		GameServerID:  serverID,
+		Resolution:    models.StatResolutionMinute,
+		Timestamp:     t,
+		CPUPercent:    stats.CPUPercent,
+		MemoryUsage:   stats.MemoryUsage,
+		MemoryLimit:   stats.MemoryLimit,
+		MemoryPercent: stats.MemoryPercent,
+		NetworkRx:     stats.NetworkRx,
+		NetworkTx:     stats.NetworkTx,
+		BlockRead:     stats.BlockRead,
+		BlockWrite:    stats.BlockWrite,
+	}
+}
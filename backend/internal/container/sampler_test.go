@@ -0,0 +1,152 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestSampler(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.GameServer{}, &models.GameServerStat{}))
+
+	cpu := "20"
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v5.0.0/libpod/containers/stats", r.URL.Path)
+		assert.Equal(t, "mc", r.URL.Query().Get("containers"))
+		_, _ = w.Write([]byte(`{"Error":null,"Stats":[{"ContainerID":"mc","CPU":` + cpu + `,"MemUsage":1024,"MemLimit":4096,"MemPerc":25,"NetInput":100}]}`))
+	}))
+	defer mockPodman.Close()
+
+	running := models.GameServer{Slug: "mc", Name: "mc", Image: "mc", Status: models.GameServerStatusRunning, ContainerID: "mc"}
+	stopped := models.GameServer{Slug: "off", Name: "off", Image: "off", Status: models.GameServerStatusStopped, ContainerID: "off"}
+	db.Create(&running)
+	db.Create(&stopped)
+
+	sampler := NewSampler(db, NewPool(NewService(mockPodman.URL)))
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	countStats := func(resolution models.StatResolution) int64 {
+		var count int64
+		db.Model(&models.GameServerStat{}).Where("resolution = ?", resolution).Count(&count)
+		return count
+	}
+
+	t.Run("should record samples of running servers only", func(t *testing.T) {
+		require.NoError(t, sampler.Sample(ctx, start.Add(15*time.Minute)))
+		cpu = "40"
+		require.NoError(t, sampler.Sample(ctx, start.Add(45*time.Minute)))
+
+		var stats []models.GameServerStat
+		db.Find(&stats)
+		require.Len(t, stats, 2)
+		assert.Equal(t, running.ID, stats[0].GameServerID)
+		assert.Equal(t, uint64(1024), stats[0].MemoryUsage)
+		assert.Equal(t, uint64(100), stats[0].NetworkRx)
+	})
+
+	t.Run("should average a completed hour", func(t *testing.T) {
+		require.NoError(t, sampler.Sample(ctx, start.Add(61*time.Minute)))
+
+		var hourly models.GameServerStat
+		require.NoError(t, db.Where("resolution = ?", models.StatResolutionHour).First(&hourly).Error)
+		assert.Equal(t, running.ID, hourly.GameServerID)
+		assert.True(t, start.Equal(hourly.Timestamp))
+		assert.InDelta(t, 30.0, hourly.CPUPercent, 0.001)
+		assert.Equal(t, uint64(1024), hourly.MemoryUsage)
+	})
+
+	t.Run("should remove expired samples", func(t *testing.T) {
+		require.NoError(t, sampler.Sample(ctx, start.Add(25*time.Hour)))
+		assert.Equal(t, int64(2), countStats(models.StatResolutionMinute))
+
+		require.NoError(t, sampler.Sample(ctx, start.Add(8*24*time.Hour)))
+		assert.Equal(t, int64(1), countStats(models.StatResolutionMinute))
+		assert.Equal(t, int64(0), countStats(models.StatResolutionHour))
+	})
+}
+
+func TestSampler_Rollup(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.GameServer{}, &models.GameServerStat{}))
+
+	sampler := NewSampler(db, NewPool(NewService("http://localhost:0")))
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	raw := func(at time.Time, cpu float64) {
+		db.Create(&models.GameServerStat{GameServerID: 1, Resolution: models.StatResolutionMinute, Timestamp: at, CPUPercent: cpu})
+	}
+	hourly := func() []models.GameServerStat {
+		var stats []models.GameServerStat
+		db.Where("resolution = ?", models.StatResolutionHour).Order("timestamp").Find(&stats)
+		return stats
+	}
+
+	t.Run("should roll up every hour missed since the last rollup", func(t *testing.T) {
+		raw(start.Add(15*time.Minute), 10)
+		raw(start.Add(75*time.Minute), 20)
+		raw(start.Add(135*time.Minute), 30)
+		// The first hour was rolled up before a restart
+		db.Create(&models.GameServerStat{GameServerID: 1, Resolution: models.StatResolutionHour, Timestamp: start, CPUPercent: 10})
+
+		require.NoError(t, sampler.Sample(ctx, start.Add(3*time.Hour+time.Minute)))
+
+		stats := hourly()
+		require.Len(t, stats, 3)
+		for i, cpu := range []float64{10, 20, 30} {
+			assert.True(t, start.Add(time.Duration(i)*time.Hour).Equal(stats[i].Timestamp))
+			assert.InDelta(t, cpu, stats[i].CPUPercent, 0.001)
+		}
+	})
+
+	t.Run("should roll up hours completed while no sample was taken", func(t *testing.T) {
+		raw(start.Add(3*time.Hour+30*time.Minute), 40)
+		raw(start.Add(4*time.Hour+30*time.Minute), 50)
+
+		require.NoError(t, sampler.Sample(ctx, start.Add(6*time.Hour)))
+
+		stats := hourly()
+		require.Len(t, stats, 5)
+		assert.InDelta(t, 40.0, stats[3].CPUPercent, 0.001)
+		assert.InDelta(t, 50.0, stats[4].CPUPercent, 0.001)
+	})
+}
+
+func TestService_Stats(t *testing.T) {
+	t.Run("should return a stats snapshot", func(t *testing.T) {
+		mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "mc", r.URL.Query().Get("containers"))
+			_, _ = w.Write([]byte(`{"Error":null,"Stats":[{"ContainerID":"abc123","CPU":12.5,"MemUsage":1024,"MemLimit":4096,"MemPerc":25,"PIDs":7}]}`))
+		}))
+		defer mockPodman.Close()
+
+		stats, err := NewService(mockPodman.URL).Stats(context.Background(), "mc")
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", stats.ContainerID)
+		assert.Equal(t, 12.5, stats.CPUPercent)
+		assert.Equal(t, uint64(1024), stats.MemoryUsage)
+		assert.Equal(t, uint64(7), stats.PIDs)
+	})
+
+	t.Run("should fail for stopped containers", func(t *testing.T) {
+		mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"Error":{"message":"container is not running"},"Stats":null}`))
+		}))
+		defer mockPodman.Close()
+
+		_, err := NewService(mockPodman.URL).Stats(context.Background(), "mc")
+		assert.ErrorContains(t, err, "container is not running")
+	})
+}
@@ -1,81 +1,31 @@
-// Package container provides container management services using Podman REST API.
+// Package container provides container management services using the Podman or Docker Engine REST API.
 package container
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sweetfish329/sabakan/backend/internal/models"
 )
 
-// Service provides container management operations using Podman REST API.
+// Service is the Podman implementation of Runtime, using the libpod REST API.
 type Service struct {
-	socketPath string
-	baseURL    string
-	client     *http.Client
+	apiClient
 }
 
-// NewService creates a new container service with the specified socket path.
+// NewService creates a new Podman container service with the specified socket path.
 // socketPath should be in the format "unix:///path/to/socket", "tcp://host:port", or "http://host:port".
 func NewService(socketPath string) *Service {
-	// Parse the socket path to determine the transport type
-	u, err := url.Parse(socketPath)
-	if err != nil {
-		// Default to unix socket
-		u = &url.URL{Scheme: "unix", Path: socketPath}
-	}
-
-	var transport *http.Transport
-	var baseURL string
-
-	switch u.Scheme {
-	case "unix":
-		// Unix socket transport - use dummy host for URL
-		path := u.Path
-		if path == "" {
-			// Handle "unix:///path" format
-			path = u.Host + u.Path
-		}
-		transport = &http.Transport{
-			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-				return net.Dial("unix", path)
-			},
-		}
-		baseURL = "http://d" // Dummy host for unix socket
-	case "http", "https", "tcp":
-		// TCP/HTTP transport - use actual URL
-		transport = &http.Transport{}
-		if u.Scheme == "tcp" {
-			baseURL = "http://" + u.Host
-		} else {
-			baseURL = strings.TrimSuffix(socketPath, "/")
-		}
-	default:
-		// Assume http URL
-		transport = &http.Transport{}
-		baseURL = strings.TrimSuffix(socketPath, "/")
-	}
-
-	return &Service{
-		socketPath: socketPath,
-		baseURL:    baseURL,
-		client: &http.Client{
-			Transport: transport,
-			Timeout:   30 * time.Second,
-		},
-	}
-}
-
-// apiURL constructs the full URL for an API endpoint.
-func (s *Service) apiURL(path string) string {
-	return s.baseURL + path
+	return &Service{apiClient: newAPIClient(socketPath, "/v5.0.0/libpod", ConnectOptions{})}
 }
 
 // List returns all containers.
@@ -140,6 +90,133 @@ func (s *Service) Get(ctx context.Context, id string) (*models.Container, error)
 	return &container, nil
 }
 
+// CreateOptions describes a container to be created.
+type CreateOptions struct {
+	// Name is the container name.
+	Name string
+	// Image is the image reference to create the container from.
+	Image string
+	// Env holds the environment variables passed to the container.
+	Env map[string]string
+	// Ports is a list of port mappings to publish.
+	Ports []models.PortMapping
+	// Labels are attached to the container.
+	Labels map[string]string
+	// Volumes is a list of named volumes to mount.
+	Volumes []VolumeMount
+	// Stdin keeps the container's standard input open so it can be attached to.
+	Stdin bool
+	// Resources limits what the container may use.
+	Resources models.ResourceLimits
+	// RestartPolicy tells the runtime when to restart the container. Empty means "no".
+	RestartPolicy string
+}
+
+// UpdateOptions holds the settings of a container that can change while it exists.
+type UpdateOptions struct {
+	// Resources replaces the resource limits. Zero values remove a limit.
+	Resources models.ResourceLimits
+	// RestartPolicy replaces the restart policy. Empty means "no".
+	RestartPolicy string
+}
+
+// cpuPeriod is the CFS period CPU quotas are expressed in, in microseconds.
+const cpuPeriod = 100000
+
+// Create creates a new container and returns its ID.
+// The container is created but not started.
+func (s *Service) Create(ctx context.Context, opts CreateOptions) (string, error) {
+	spec := podmanCreateSpec{
+		Name:   opts.Name,
+		Image:  opts.Image,
+		Env:    opts.Env,
+		Labels: opts.Labels,
+		Stdin:  opts.Stdin,
+
+		ResourceLimits: podmanResources(opts.Resources, false),
+		RestartPolicy:  opts.RestartPolicy,
+	}
+	for _, p := range opts.Ports {
+		spec.PortMappings = append(spec.PortMappings, podmanPort{
+			HostIP:        p.HostIP,
+			HostPort:      p.HostPort,
+			ContainerPort: p.ContainerPort,
+			Protocol:      p.Protocol,
+		})
+	}
+	for _, v := range opts.Volumes {
+		spec.Volumes = append(spec.Volumes, podmanNamedVolume{
+			Name: v.Name,
+			Dest: v.Dest,
+		})
+	}
+
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode create spec: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL("/v5.0.0/libpod/containers/create"), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container %s: %w", opts.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to create container %s: status %d: %s", opts.Name, resp.StatusCode, string(respBody))
+	}
+
+	var created struct {
+		ID       string   `json:"Id"`
+		Warnings []string `json:"Warnings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// Update changes the resource limits and restart policy of a container,
+// applying them immediately if it is running.
+func (s *Service) Update(ctx context.Context, id string, opts UpdateOptions) error {
+	body, err := json.Marshal(podmanResources(opts.Resources, true))
+	if err != nil {
+		return fmt.Errorf("failed to encode resources: %w", err)
+	}
+
+	policy := opts.RestartPolicy
+	if policy == "" {
+		policy = models.RestartPolicyNo
+	}
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/%s/update?restartPolicy=%s", url.PathEscape(id), url.QueryEscape(policy))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL(endpoint), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update container %s: status %d: %s", id, resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
 // Start starts a container by ID or name.
 func (s *Service) Start(ctx context.Context, id string) error {
 	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/%s/start", url.PathEscape(id))
@@ -189,33 +266,77 @@ func (s *Service) Stop(ctx context.Context, id string, timeout uint) error {
 	return nil
 }
 
-// Logs returns the last N lines of container logs.
-func (s *Service) Logs(ctx context.Context, id string, lines int) ([]models.ContainerLogEntry, error) {
-	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/%s/logs?stdout=true&stderr=true&tail=%d", url.PathEscape(id), lines)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+// Wait blocks until a container has stopped and returns its exit code.
+func (s *Service) Wait(ctx context.Context, id string) (int, error) {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/%s/wait?condition=stopped&condition=exited", url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL(endpoint), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.streamClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get logs for container %s: %w", id, err)
+		return 0, fmt.Errorf("failed to wait for container %s: %w", id, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return 0, fmt.Errorf("failed to wait for container %s: status %d: %s", id, resp.StatusCode, string(body))
+	}
+
+	var exitCode int
+	if err := json.NewDecoder(resp.Body).Decode(&exitCode); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return exitCode, nil
+}
+
+// Remove removes a container by ID or name.
+// When force is true, a running container is killed before removal.
+func (s *Service) Remove(ctx context.Context, id string, force bool) error {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/%s?force=%t", url.PathEscape(id), force)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Read log output - each line is prefixed with stream type
-	body, err := io.ReadAll(resp.Body)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read logs: %w", err)
+		return fmt.Errorf("failed to remove container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	// 404 means already removed, which is acceptable
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to remove container %s: status %d: %s", id, resp.StatusCode, string(body))
 	}
 
-	entries := parseLogOutput(body)
-	return entries, nil
+	return nil
+}
+
+// Rename changes the name of a container.
+func (s *Service) Rename(ctx context.Context, id, name string) error {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/%s/rename?name=%s", url.PathEscape(id), url.QueryEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to rename container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to rename container %s: status %d: %s", id, resp.StatusCode, string(body))
+	}
+
+	return nil
 }
 
 // podmanListContainer represents a container in Podman list response.
@@ -237,6 +358,65 @@ type podmanPort struct {
 	Protocol      string `json:"protocol"`
 }
 
+// podmanCreateSpec is the subset of the libpod SpecGenerator used to create containers.
+type podmanCreateSpec struct {
+	Name         string              `json:"name,omitempty"`
+	Image        string              `json:"image"`
+	Env          map[string]string   `json:"env,omitempty"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	PortMappings []podmanPort        `json:"portmappings,omitempty"`
+	Volumes      []podmanNamedVolume `json:"volumes,omitempty"`
+	Stdin        bool                `json:"stdin,omitempty"`
+
+	ResourceLimits *podmanLinuxResources `json:"resource_limits,omitempty"`
+	RestartPolicy  string                `json:"restart_policy,omitempty"`
+}
+
+// podmanLinuxResources is the subset of the OCI LinuxResources used for limits.
+type podmanLinuxResources struct {
+	Memory *podmanLimit `json:"memory,omitempty"`
+	CPU    *podmanCPU   `json:"cpu,omitempty"`
+	Pids   *podmanLimit `json:"pids,omitempty"`
+}
+
+type podmanLimit struct {
+	Limit int64 `json:"limit"`
+}
+
+type podmanCPU struct {
+	Shares uint64 `json:"shares,omitempty"`
+	Quota  int64  `json:"quota"`
+	Period uint64 `json:"period,omitempty"`
+}
+
+// podmanResources converts limits to OCI resources. Unset limits are left out,
+// or set to -1 (unlimited) when clear is true so that an update removes them.
+func podmanResources(limits models.ResourceLimits, clear bool) *podmanLinuxResources {
+	var r podmanLinuxResources
+	if limits.MemoryMB > 0 {
+		r.Memory = &podmanLimit{Limit: int64(limits.MemoryMB) << 20}
+	} else if clear {
+		r.Memory = &podmanLimit{Limit: -1}
+	}
+	if limits.CPUs > 0 {
+		r.CPU = &podmanCPU{Quota: int64(limits.CPUs * cpuPeriod), Period: cpuPeriod}
+	} else if clear || limits.CPUShares > 0 {
+		r.CPU = &podmanCPU{Quota: -1}
+	}
+	if r.CPU != nil {
+		r.CPU.Shares = uint64(limits.CPUShares)
+	}
+	if limits.PidsLimit > 0 {
+		r.Pids = &podmanLimit{Limit: limits.PidsLimit}
+	} else if clear {
+		r.Pids = &podmanLimit{Limit: -1}
+	}
+	if r.Memory == nil && r.CPU == nil && r.Pids == nil {
+		return nil
+	}
+	return &r
+}
+
 func (c *podmanListContainer) toModel() models.Container {
 	name := ""
 	if len(c.Names) > 0 {
@@ -267,31 +447,136 @@ func (c *podmanListContainer) toModel() models.Container {
 
 // podmanInspectData represents container inspect response.
 type podmanInspectData struct {
-	ID      string `json:"Id"`
-	Name    string `json:"Name"`
-	Created string `json:"Created"`
-	State   struct {
-		Status string `json:"Status"`
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Created      string `json:"Created"`
+	Image        string `json:"Image"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status     string `json:"Status"`
+		ExitCode   int    `json:"ExitCode"`
+		OOMKilled  bool   `json:"OOMKilled"`
+		Error      string `json:"Error"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
 	} `json:"State"`
 	Config struct {
 		Image  string            `json:"Image"`
 		Labels map[string]string `json:"Labels"`
+		Env    []string          `json:"Env"`
 	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Name        string `json:"Name"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		// Ports maps "port/protocol" to the host bindings of the port.
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
 }
 
 func (d *podmanInspectData) toModel() models.Container {
 	created, _ := time.Parse(time.RFC3339Nano, d.Created)
 
-	return models.Container{
-		ID:      d.ID,
-		Name:    d.Name,
-		Image:   d.Config.Image,
-		State:   mapState(d.State.Status),
-		Status:  d.State.Status,
-		Created: created,
-		Ports:   []models.PortMapping{},
-		Labels:  d.Config.Labels,
+	c := models.Container{
+		ID:            d.ID,
+		Name:          d.Name,
+		Image:         d.Config.Image,
+		ImageID:       d.Image,
+		State:         mapState(d.State.Status),
+		Status:        d.State.Status,
+		Created:       created,
+		Ports:         d.ports(),
+		Labels:        d.Config.Labels,
+		Mounts:        make([]models.Mount, 0, len(d.Mounts)),
+		Env:           make(map[string]string, len(d.Config.Env)),
+		RestartPolicy: d.HostConfig.RestartPolicy.Name,
+		ExitCode:      d.State.ExitCode,
+		OOMKilled:     d.State.OOMKilled,
+		Error:         d.State.Error,
+		RestartCount:  d.RestartCount,
+		StartedAt:     parseInspectTime(d.State.StartedAt),
+		FinishedAt:    parseInspectTime(d.State.FinishedAt),
+	}
+	if d.State.Health != nil {
+		c.Health = d.State.Health.Status
+	}
+	for _, m := range d.Mounts {
+		c.Mounts = append(c.Mounts, models.Mount{
+			Type:        m.Type,
+			Name:        m.Name,
+			Source:      m.Source,
+			Destination: m.Destination,
+			ReadWrite:   m.RW,
+		})
+	}
+	for _, kv := range d.Config.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		c.Env[key] = value
+	}
+
+	return c
+}
+
+// ports returns the published ports of the container sorted by container port.
+func (d *podmanInspectData) ports() []models.PortMapping {
+	ports := []models.PortMapping{}
+	for spec, bindings := range d.NetworkSettings.Ports {
+		portStr, protocol, _ := strings.Cut(spec, "/")
+		containerPort, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			continue
+		}
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		for _, b := range bindings {
+			hostPort, err := strconv.ParseUint(b.HostPort, 10, 16)
+			if err != nil {
+				continue
+			}
+			ports = append(ports, models.PortMapping{
+				HostIP:        b.HostIP,
+				HostPort:      uint16(hostPort),
+				ContainerPort: uint16(containerPort),
+				Protocol:      protocol,
+			})
+		}
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].ContainerPort != ports[j].ContainerPort {
+			return ports[i].ContainerPort < ports[j].ContainerPort
+		}
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		return ports[i].HostPort < ports[j].HostPort
+	})
+	return ports
+}
+
+// parseInspectTime parses an inspect timestamp, returning nil for the zero time Podman reports for unset values.
+func parseInspectTime(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.IsZero() || t.Year() <= 1 {
+		return nil
 	}
+	return &t
 }
 
 // mapState converts a Podman state string to our ContainerState.
@@ -313,38 +598,3 @@ func mapState(state string) models.ContainerState {
 		return models.StateUnknown
 	}
 }
-
-// parseLogOutput parses the Docker/Podman log output format.
-func parseLogOutput(data []byte) []models.ContainerLogEntry {
-	var entries []models.ContainerLogEntry
-
-	// Simple line-by-line parsing
-	lines := splitLines(data)
-	for _, line := range lines {
-		if len(line) == 0 {
-			continue
-		}
-		entries = append(entries, models.ContainerLogEntry{
-			Stream:  "stdout",
-			Message: string(line),
-		})
-	}
-
-	return entries
-}
-
-// splitLines splits byte data into lines.
-func splitLines(data []byte) [][]byte {
-	var lines [][]byte
-	start := 0
-	for i := 0; i < len(data); i++ {
-		if data[i] == '\n' {
-			lines = append(lines, data[start:i])
-			start = i + 1
-		}
-	}
-	if start < len(data) {
-		lines = append(lines, data[start:])
-	}
-	return lines
-}
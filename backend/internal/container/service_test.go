@@ -0,0 +1,275 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestService_Create_Resources(t *testing.T) {
+	var spec map[string]any
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spec = nil
+		_ = json.NewDecoder(r.Body).Decode(&spec)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":"abc","Warnings":[]}`))
+	}))
+	defer mockPodman.Close()
+	svc := NewService(mockPodman.URL)
+
+	t.Run("should pass resource limits and the restart policy", func(t *testing.T) {
+		_, err := svc.Create(context.Background(), CreateOptions{
+			Name:          "sabakan-ark",
+			Image:         "ark",
+			Resources:     models.ResourceLimits{MemoryMB: 8192, CPUs: 2, CPUShares: 2048, PidsLimit: 1024},
+			RestartPolicy: models.RestartPolicyOnFailure,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "on-failure", spec["restart_policy"])
+		assert.Equal(t, map[string]any{
+			"memory": map[string]any{"limit": float64(8192 << 20)},
+			"cpu":    map[string]any{"shares": float64(2048), "quota": float64(200000), "period": float64(100000)},
+			"pids":   map[string]any{"limit": float64(1024)},
+		}, spec["resource_limits"])
+	})
+
+	t.Run("should leave out unset limits", func(t *testing.T) {
+		_, err := svc.Create(context.Background(), CreateOptions{Name: "sabakan-mc", Image: "mc"})
+
+		require.NoError(t, err)
+		assert.NotContains(t, spec, "resource_limits")
+		assert.NotContains(t, spec, "restart_policy")
+	})
+}
+
+func TestService_Update(t *testing.T) {
+	var body map[string]any
+	var query map[string][]string
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v5.0.0/libpod/containers/abc/update" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer mockPodman.Close()
+	svc := NewService(mockPodman.URL)
+
+	t.Run("should remove limits that are no longer set", func(t *testing.T) {
+		err := svc.Update(context.Background(), "abc", UpdateOptions{
+			Resources:     models.ResourceLimits{MemoryMB: 4096},
+			RestartPolicy: models.RestartPolicyAlways,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"always"}, query["restartPolicy"])
+		assert.Equal(t, map[string]any{
+			"memory": map[string]any{"limit": float64(4096 << 20)},
+			"cpu":    map[string]any{"quota": float64(-1)},
+			"pids":   map[string]any{"limit": float64(-1)},
+		}, body)
+	})
+
+	t.Run("should default to no restart policy", func(t *testing.T) {
+		require.NoError(t, svc.Update(context.Background(), "abc", UpdateOptions{}))
+		assert.Equal(t, []string{"no"}, query["restartPolicy"])
+	})
+
+	t.Run("should fail for unknown containers", func(t *testing.T) {
+		assert.Error(t, svc.Update(context.Background(), "missing", UpdateOptions{}))
+	})
+}
+
+func TestService_Rename(t *testing.T) {
+	var name string
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v5.0.0/libpod/containers/abc/rename" {
+			http.NotFound(w, r)
+			return
+		}
+		name = r.URL.Query().Get("name")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockPodman.Close()
+	svc := NewService(mockPodman.URL)
+
+	t.Run("should send the new name", func(t *testing.T) {
+		require.NoError(t, svc.Rename(context.Background(), "abc", "sabakan-survival"))
+		assert.Equal(t, "sabakan-survival", name)
+	})
+
+	t.Run("should fail for unknown containers", func(t *testing.T) {
+		assert.Error(t, svc.Rename(context.Background(), "missing", "sabakan-survival"))
+	})
+}
+
+func TestService_Create_Spec(t *testing.T) {
+	var spec map[string]any
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&spec)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":"abc","Warnings":[]}`))
+	}))
+	defer mockPodman.Close()
+
+	id, err := NewService(mockPodman.URL).Create(context.Background(), CreateOptions{
+		Name:   "sabakan-survival",
+		Image:  "itzg/minecraft-server:latest",
+		Env:    map[string]string{"EULA": "TRUE"},
+		Labels: map[string]string{"sabakan.slug": "survival"},
+		Ports: []models.PortMapping{
+			{HostPort: 30000, ContainerPort: 25565, Protocol: "tcp"},
+			{HostIP: "127.0.0.1", HostPort: 30001, ContainerPort: 25575, Protocol: "tcp"},
+		},
+		Volumes: []VolumeMount{{Name: "sabakan-survival-data", Dest: "/data"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	assert.Equal(t, "sabakan-survival", spec["name"])
+	assert.Equal(t, "itzg/minecraft-server:latest", spec["image"])
+	assert.Equal(t, map[string]any{"EULA": "TRUE"}, spec["env"])
+	assert.Equal(t, map[string]any{"sabakan.slug": "survival"}, spec["labels"])
+	assert.Equal(t, []any{map[string]any{"Name": "sabakan-survival-data", "Dest": "/data"}}, spec["volumes"])
+
+	portMappings, ok := spec["portmappings"].([]any)
+	require.True(t, ok)
+	hostIPs := make(map[float64]any)
+	for _, p := range portMappings {
+		mapping := p.(map[string]any)
+		hostIPs[mapping["container_port"].(float64)] = mapping["host_ip"]
+	}
+	assert.Equal(t, "127.0.0.1", hostIPs[25575])
+	assert.Empty(t, hostIPs[25565])
+}
+
+func TestService_List(t *testing.T) {
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id":"abc123","Names":["test-container"],"Image":"nginx:latest","State":"running","Status":"Up 10 minutes","Created":1704067200}]`))
+	}))
+	defer mockPodman.Close()
+
+	containers, err := NewService(mockPodman.URL).List(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "abc123", containers[0].ID)
+	assert.Equal(t, "test-container", containers[0].Name)
+	assert.Equal(t, models.StateRunning, containers[0].State)
+}
+
+func TestService_Get(t *testing.T) {
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v5.0.0/libpod/containers/mc/json" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"cause":"no such container","message":"no such container","response":404}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"Id": "abc123",
+			"Name": "mc",
+			"Created": "2024-01-01T00:00:00Z",
+			"RestartCount": 2,
+			"State": {
+				"Status": "exited",
+				"ExitCode": 137,
+				"OOMKilled": true,
+				"StartedAt": "2024-01-01T01:00:00Z",
+				"FinishedAt": "0001-01-01T00:00:00Z",
+				"Health": {"Status": "unhealthy"}
+			},
+			"Config": {
+				"Image": "itzg/minecraft-server",
+				"Labels": {"app": "test"},
+				"Env": ["EULA=TRUE", "RCON_PASSWORD=hunter2"]
+			},
+			"HostConfig": {"RestartPolicy": {"Name": "on-failure"}},
+			"Mounts": [{"Type": "volume", "Name": "sabakan-mc-data", "Source": "/var/lib/containers/storage/volumes/sabakan-mc-data/_data", "Destination": "/data", "RW": true}],
+			"NetworkSettings": {"Ports": {
+				"25575/tcp": null,
+				"25565/tcp": [{"HostIp": "", "HostPort": "25565"}],
+				"19132/udp": [{"HostIp": "0.0.0.0", "HostPort": "19132"}]
+			}}
+		}`))
+	}))
+	defer mockPodman.Close()
+	svc := NewService(mockPodman.URL)
+
+	t.Run("should return ports, mounts and exit details", func(t *testing.T) {
+		got, err := svc.Get(context.Background(), "mc")
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", got.ID)
+		assert.Equal(t, "mc", got.Name)
+		assert.Equal(t, models.StateExited, got.State)
+		assert.Equal(t, "test", got.Labels["app"])
+		assert.Equal(t, []models.PortMapping{
+			{HostIP: "0.0.0.0", HostPort: 19132, ContainerPort: 19132, Protocol: "udp"},
+			{HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"},
+		}, got.Ports)
+		assert.Equal(t, 137, got.ExitCode)
+		assert.True(t, got.OOMKilled)
+		assert.Equal(t, 2, got.RestartCount)
+		assert.Equal(t, "unhealthy", got.Health)
+		assert.Equal(t, "on-failure", got.RestartPolicy)
+		assert.NotNil(t, got.StartedAt)
+		assert.Nil(t, got.FinishedAt)
+		if assert.Len(t, got.Mounts, 1) {
+			assert.Equal(t, "sabakan-mc-data", got.Mounts[0].Name)
+			assert.Equal(t, "/data", got.Mounts[0].Destination)
+			assert.True(t, got.Mounts[0].ReadWrite)
+		}
+		assert.Equal(t, map[string]string{"EULA": "TRUE", "RCON_PASSWORD": "hunter2"}, got.Env)
+	})
+
+	t.Run("should fail for unknown containers", func(t *testing.T) {
+		_, err := svc.Get(context.Background(), "nonexistent")
+		assert.Error(t, err)
+	})
+}
+
+func TestService_StreamLogs(t *testing.T) {
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v5.0.0/libpod/containers/mc/logs", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("follow"))
+		_, _ = w.Write(frame(streamStderr, "disk full!\n"))
+	}))
+	defer mockPodman.Close()
+
+	var entries []models.ContainerLogEntry
+	err := NewService(mockPodman.URL).StreamLogs(context.Background(), "mc", 10, func(entry models.ContainerLogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "stderr", entries[0].Stream)
+	assert.Equal(t, "disk full!", entries[0].Message)
+}
+
+func TestService_Info(t *testing.T) {
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"host":{"hostname":"worker","cpus":8,"memTotal":17179869184,"memFree":8589934592},"version":{"Version":"5.0.0"}}`))
+	}))
+	defer mockPodman.Close()
+
+	info, err := NewService(mockPodman.URL).Info(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "worker", info.Hostname)
+	assert.Equal(t, 8, info.CPUs)
+	assert.Equal(t, uint64(16<<30), info.MemoryTotal)
+	assert.Equal(t, uint64(8<<30), info.MemoryFree)
+	assert.Equal(t, "5.0.0", info.Version)
+}
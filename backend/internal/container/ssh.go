@@ -0,0 +1,154 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshTunnel connects to an API socket on a remote host through SSH.
+// The SSH connection is opened on first use and reopened when it breaks.
+type sshTunnel struct {
+	addr   string
+	socket string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHTunnel creates a tunnel for an ssh://user@host[:port]/path/to/socket endpoint.
+func newSSHTunnel(u *url.URL, opts ConnectOptions) (*sshTunnel, error) {
+	if u.Path == "" {
+		return nil, fmt.Errorf("ssh endpoint %s has no socket path", u.Redacted())
+	}
+
+	knownHostsPath := opts.SSHKnownHosts
+	if knownHostsPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate known_hosts: %w", err)
+		}
+		knownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_hosts: %w", err)
+	}
+
+	auth, err := sshAuth(opts.SSHKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	user := u.User.Username()
+	if user == "" {
+		user = "root"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+
+	return &sshTunnel{
+		addr:   net.JoinHostPort(u.Hostname(), port),
+		socket: u.Path,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{auth},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         10 * time.Second,
+		},
+	}, nil
+}
+
+// sshAuth returns the public key authentication using the key file at
+// keyPath, or the SSH agent when keyPath is empty.
+func sshAuth(keyPath string) (ssh.AuthMethod, error) {
+	if keyPath == "" {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, fmt.Errorf("no SSH key configured and SSH_AUTH_SOCK is not set")
+		}
+		return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			conn, err := net.Dial("unix", sock)
+			if err != nil {
+				return nil, err
+			}
+			defer conn.Close()
+			return agent.NewClient(conn).Signers()
+		}), nil
+	}
+
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+	}
+	return ssh.PublicKeys(signer), nil
+}
+
+// dial opens a connection to the remote API socket.
+func (t *sshTunnel) dial(ctx context.Context) (net.Conn, error) {
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, "unix", t.socket)
+	if err == nil {
+		return conn, nil
+	}
+
+	// The SSH connection may have dropped; reconnect once
+	t.reset(client)
+	client, err = t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.DialContext(ctx, "unix", t.socket)
+}
+
+// connect returns the open SSH connection, connecting if necessary.
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", t.addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open SSH connection to %s: %w", t.addr, err)
+	}
+	t.client = ssh.NewClient(sshConn, chans, reqs)
+	return t.client, nil
+}
+
+// reset closes client if it is still the current connection.
+func (t *sshTunnel) reset(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == client {
+		_ = t.client.Close()
+		t.client = nil
+	}
+}
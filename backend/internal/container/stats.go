@@ -0,0 +1,144 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// podmanStatsReport is a libpod stats response.
+type podmanStatsReport struct {
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"Error"`
+	Stats []podmanStats `json:"Stats"`
+}
+
+// podmanStats is the libpod representation of container stats.
+type podmanStats struct {
+	ContainerID string  `json:"ContainerID"`
+	CPU         float64 `json:"CPU"`
+	MemUsage    uint64  `json:"MemUsage"`
+	MemLimit    uint64  `json:"MemLimit"`
+	MemPerc     float64 `json:"MemPerc"`
+	NetInput    uint64  `json:"NetInput"`
+	NetOutput   uint64  `json:"NetOutput"`
+	BlockInput  uint64  `json:"BlockInput"`
+	BlockOutput uint64  `json:"BlockOutput"`
+	PIDs        uint64  `json:"PIDs"`
+}
+
+// toModel converts libpod stats to a models.ContainerStats taken at t.
+func (p *podmanStats) toModel(t time.Time) models.ContainerStats {
+	return models.ContainerStats{
+		ContainerID:   p.ContainerID,
+		Timestamp:     t,
+		CPUPercent:    p.CPU,
+		MemoryUsage:   p.MemUsage,
+		MemoryLimit:   p.MemLimit,
+		MemoryPercent: p.MemPerc,
+		NetworkRx:     p.NetInput,
+		NetworkTx:     p.NetOutput,
+		BlockRead:     p.BlockInput,
+		BlockWrite:    p.BlockOutput,
+		PIDs:          p.PIDs,
+	}
+}
+
+// Stats returns a single resource usage sample of a running container.
+func (s *Service) Stats(ctx context.Context, id string) (*models.ContainerStats, error) {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/stats?stream=false&containers=%s", url.QueryEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats of container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get stats of container %s: status %d: %s", id, resp.StatusCode, string(body))
+	}
+
+	var report podmanStatsReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	stats, err := report.first(id)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// StreamStats samples a running container every interval and calls fn with
+// each sample until ctx is canceled, the container stops or fn returns an error.
+func (s *Service) StreamStats(ctx context.Context, id string, interval time.Duration, fn func(models.ContainerStats) error) error {
+	seconds := int(interval / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/containers/stats?stream=true&interval=%d&containers=%s", seconds, url.QueryEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.streamClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to stream stats of container %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to stream stats of container %s: status %d: %s", id, resp.StatusCode, string(body))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var report podmanStatsReport
+		if err := decoder.Decode(&report); err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode stats: %w", err)
+		}
+
+		stats, err := report.first(id)
+		if err != nil {
+			return err
+		}
+		if err := fn(*stats); err != nil {
+			return err
+		}
+	}
+}
+
+// first returns the first sample of a report.
+func (r *podmanStatsReport) first(id string) (*models.ContainerStats, error) {
+	if r.Error != nil {
+		return nil, fmt.Errorf("failed to get stats of container %s: %s", id, r.Error.Message)
+	}
+	if len(r.Stats) == 0 {
+		return nil, fmt.Errorf("no stats for container %s", id)
+	}
+	stats := r.Stats[0].toModel(time.Now())
+	return &stats, nil
+}
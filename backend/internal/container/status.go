@@ -0,0 +1,70 @@
+package container
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// statusBuffer is how many events a subscriber may fall behind before it misses events.
+const statusBuffer = 64
+
+// StatusEvent is a change of the status of a game server.
+type StatusEvent struct {
+	// ID is the ID of the game server.
+	ID uint `json:"id"`
+	// Slug is the slug of the game server.
+	Slug string `json:"slug"`
+	// Status is the new status.
+	Status models.GameServerStatus `json:"status"`
+	// Time is when the status changed.
+	Time time.Time `json:"time"`
+}
+
+// StatusBroker passes game server status changes on to subscribers such as
+// the event stream of the API. Subscribers that fall behind miss events
+// rather than block the publisher.
+type StatusBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan StatusEvent]struct{}
+}
+
+// NewStatusBroker creates a broker without subscribers.
+func NewStatusBroker() *StatusBroker {
+	return &StatusBroker{subscribers: make(map[chan StatusEvent]struct{})}
+}
+
+// Publish sends the status of a server to all subscribers.
+// Publishing to a nil broker does nothing.
+func (b *StatusBroker) Publish(server *models.GameServer) {
+	if b == nil {
+		return
+	}
+	event := StatusEvent{ID: server.ID, Slug: server.Slug, Status: server.Status, Time: time.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving status changes and a function that
+// ends the subscription and closes the channel.
+func (b *StatusBroker) Subscribe() (<-chan StatusEvent, func()) {
+	ch := make(chan StatusEvent, statusBuffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, sync.OnceFunc(func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+		close(ch)
+	})
+}
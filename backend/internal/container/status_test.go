@@ -0,0 +1,51 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestStatusBroker(t *testing.T) {
+	server := &models.GameServer{Slug: "mc", Status: models.GameServerStatusReady}
+
+	t.Run("should send status changes to every subscriber", func(t *testing.T) {
+		broker := NewStatusBroker()
+		first, unsubscribeFirst := broker.Subscribe()
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := broker.Subscribe()
+		defer unsubscribeSecond()
+
+		broker.Publish(server)
+
+		assert.Equal(t, models.GameServerStatusReady, (<-first).Status)
+		assert.Equal(t, "mc", (<-second).Slug)
+	})
+
+	t.Run("should drop events for subscribers that fall behind", func(t *testing.T) {
+		broker := NewStatusBroker()
+		events, unsubscribe := broker.Subscribe()
+		defer unsubscribe()
+
+		for range statusBuffer + 1 {
+			broker.Publish(server)
+		}
+
+		assert.Len(t, events, statusBuffer)
+	})
+
+	t.Run("should close the channel on unsubscribe", func(t *testing.T) {
+		broker := NewStatusBroker()
+		events, unsubscribe := broker.Subscribe()
+		unsubscribe()
+
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+
+	t.Run("should ignore publishing to a nil broker", func(t *testing.T) {
+		var broker *StatusBroker
+		assert.NotPanics(t, func() { broker.Publish(server) })
+	})
+}
@@ -0,0 +1,130 @@
+package container
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// UpdateChecker periodically compares the image each game server container
+// runs with the digest its tag points to in the registry and flags servers
+// with an available update. Servers pinned to a digest are never flagged.
+type UpdateChecker struct {
+	db       *gorm.DB
+	nodes    *Pool
+	registry *RegistryClient
+	interval time.Duration
+}
+
+// NewUpdateChecker creates a new update checker for the game servers on the
+// nodes of a pool that runs every interval.
+func NewUpdateChecker(db *gorm.DB, nodes *Pool, interval time.Duration) *UpdateChecker {
+	return &UpdateChecker{
+		db:       db,
+		nodes:    nodes,
+		registry: NewRegistryClient(),
+		interval: interval,
+	}
+}
+
+// Run checks for updates immediately and then every interval until ctx is canceled.
+func (u *UpdateChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := u.Check(ctx); err != nil {
+			slog.Warn("Failed to check for image updates", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check updates the update status of every game server with a container.
+// Servers whose image or registry cannot be inspected are skipped.
+func (u *UpdateChecker) Check(ctx context.Context) error {
+	var servers []models.GameServer
+	if err := u.db.Where("container_id <> ''").Find(&servers).Error; err != nil {
+		return err
+	}
+
+	// Servers often share an image, so look every image and tag up only once.
+	// Images are keyed by runtime as each node has its own image store.
+	remoteDigests := make(map[string]string)
+	images := make(map[Runtime]map[string]*models.Image)
+
+	for _, server := range servers {
+		now := time.Now().UTC()
+		if server.PinnedDigest != "" {
+			if err := u.db.Model(&server).Updates(map[string]any{
+				"update_available":  false,
+				"update_checked_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		runtime, err := u.nodes.Runtime(server.NodeID)
+		if err != nil {
+			continue
+		}
+		c, err := runtime.Get(ctx, server.ContainerID)
+		if err != nil {
+			continue
+		}
+		if images[runtime] == nil {
+			images[runtime] = make(map[string]*models.Image)
+		}
+		image, ok := images[runtime][c.ImageID]
+		if !ok {
+			image, err = runtime.GetImage(ctx, c.ImageID)
+			if err != nil {
+				continue
+			}
+			images[runtime][c.ImageID] = image
+		}
+
+		remote, ok := remoteDigests[server.Image]
+		if !ok {
+			remote, err = u.registry.Digest(ctx, server.Image)
+			if err != nil {
+				slog.Warn("Failed to get registry digest", "image", server.Image, "error", err)
+				continue
+			}
+			remoteDigests[server.Image] = remote
+		}
+
+		if err := u.db.Model(&server).Updates(map[string]any{
+			"image_digest":      image.Digest,
+			"latest_digest":     remote,
+			"update_available":  !hasDigest(image, remote),
+			"update_checked_at": now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasDigest reports whether an image was pulled with the given manifest digest.
+func hasDigest(image *models.Image, digest string) bool {
+	if image.Digest == digest {
+		return true
+	}
+	for _, repoDigest := range image.Digests {
+		if strings.HasSuffix(repoDigest, "@"+digest) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestUpdateChecker_Check(t *testing.T) {
+	db := setupWatcherTestDB(t)
+
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v5.0.0/libpod/containers/old/json":
+			_, _ = w.Write([]byte(`{"Id":"old","Image":"img-old","State":{"Status":"running"}}`))
+		case "/v5.0.0/libpod/containers/new/json":
+			_, _ = w.Write([]byte(`{"Id":"new","Image":"img-new","State":{"Status":"running"}}`))
+		case "/v5.0.0/libpod/images/img-old/json":
+			_, _ = w.Write([]byte(`{"Id":"img-old","Digest":"sha256:old","RepoDigests":["docker.io/itzg/minecraft-server@sha256:old"]}`))
+		case "/v5.0.0/libpod/images/img-new/json":
+			_, _ = w.Write([]byte(`{"Id":"img-new","Digest":"sha256:platform","RepoDigests":["docker.io/itzg/minecraft-server@sha256:latest"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockPodman.Close()
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/itzg/minecraft-server/manifests/latest", r.URL.Path)
+		w.Header().Set("Docker-Content-Digest", "sha256:latest")
+	}))
+	defer registry.Close()
+
+	outdated := models.GameServer{Slug: "outdated", Name: "outdated", Image: "itzg/minecraft-server:latest", ContainerID: "old"}
+	current := models.GameServer{Slug: "current", Name: "current", Image: "itzg/minecraft-server:latest", ContainerID: "new"}
+	pinned := models.GameServer{Slug: "pinned", Name: "pinned", Image: "itzg/minecraft-server:latest", ContainerID: "old", PinnedDigest: "sha256:old"}
+	db.Create(&outdated)
+	db.Create(&current)
+	db.Create(&pinned)
+
+	checker := NewUpdateChecker(db, NewPool(NewService(mockPodman.URL)), 0)
+	checker.registry.baseURL = func(string) string { return registry.URL }
+
+	require.NoError(t, checker.Check(context.Background()))
+
+	load := func(id uint) models.GameServer {
+		var s models.GameServer
+		require.NoError(t, db.First(&s, id).Error)
+		return s
+	}
+
+	t.Run("should flag servers running an outdated image", func(t *testing.T) {
+		s := load(outdated.ID)
+		assert.True(t, s.UpdateAvailable)
+		assert.Equal(t, "sha256:old", s.ImageDigest)
+		assert.Equal(t, "sha256:latest", s.LatestDigest)
+		assert.NotNil(t, s.UpdateCheckedAt)
+	})
+
+	t.Run("should match repository digests of multi-arch images", func(t *testing.T) {
+		assert.False(t, load(current.ID).UpdateAvailable)
+	})
+
+	t.Run("should never flag pinned servers", func(t *testing.T) {
+		s := load(pinned.ID)
+		assert.False(t, s.UpdateAvailable)
+		assert.NotNil(t, s.UpdateCheckedAt)
+	})
+}
@@ -0,0 +1,143 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// VolumeMount describes a named volume mounted into a container.
+type VolumeMount struct {
+	// Name is the name of the volume.
+	Name string
+	// Dest is the mount path inside the container.
+	Dest string
+}
+
+// podmanNamedVolume is a named volume in the libpod SpecGenerator.
+type podmanNamedVolume struct {
+	Name    string   `json:"Name"`
+	Dest    string   `json:"Dest"`
+	Options []string `json:"Options,omitempty"`
+}
+
+// VolumeExists reports whether a named volume exists.
+func (s *Service) VolumeExists(ctx context.Context, name string) (bool, error) {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/volumes/%s/exists", url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check volume %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// CreateVolume creates a named volume with the given labels.
+func (s *Service) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	body, err := json.Marshal(map[string]any{
+		"Name":  name,
+		"Label": labels,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode volume spec: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL("/v5.0.0/libpod/volumes/create"), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create volume %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create volume %s: status %d: %s", name, resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// EnsureVolume creates a named volume unless it already exists.
+func (s *Service) EnsureVolume(ctx context.Context, name string, labels map[string]string) error {
+	exists, err := s.VolumeExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return s.CreateVolume(ctx, name, labels)
+}
+
+// RemoveVolume removes a named volume.
+// When force is true, containers using the volume are removed as well.
+func (s *Service) RemoveVolume(ctx context.Context, name string, force bool) error {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/volumes/%s?force=%t", url.PathEscape(name), force)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	// 404 means already removed, which is acceptable
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to remove volume %s: status %d: %s", name, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// ExportVolume writes the contents of a named volume to w as a tar archive.
+func (s *Service) ExportVolume(ctx context.Context, name string, w io.Writer) error {
+	endpoint := fmt.Sprintf("/v5.0.0/libpod/volumes/%s/export", url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL(endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Volumes can be large, so do not apply the default timeout
+	resp, err := s.streamClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export volume %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to export volume %s: status %d: %s", name, resp.StatusCode, string(body))
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to export volume %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// watcherMinBackoff is the delay before the first reconnection attempt.
+	watcherMinBackoff = time.Second
+	// watcherMaxBackoff caps the delay between reconnection attempts.
+	watcherMaxBackoff = time.Minute
+	// watcherSyncInterval is how often the watcher picks up added or removed nodes.
+	watcherSyncInterval = 30 * time.Second
+)
+
+// cleanExitCodes are exit codes treated as a regular stop rather than a crash:
+// a normal exit and termination by SIGINT, SIGKILL or SIGTERM, which is how
+// `podman stop` ends a container.
+var cleanExitCodes = map[int]bool{0: true, 130: true, 137: true, 143: true}
+
+// ReadinessProber follows game servers whose container has started until their
+// game accepts players.
+type ReadinessProber interface {
+	// Started sets the status of a server whose container runs and, if its game
+	// has a readiness probe, starts probing it. Servers already being probed
+	// are left alone.
+	Started(server models.GameServer)
+}
+
+// Watcher keeps the status of game servers in sync with their containers by
+// following the event stream of every node. It reconnects with exponential
+// backoff when a stream breaks, and reconciles the servers of the node after
+// every (re)connect so that events missed while disconnected are not lost.
+type Watcher struct {
+	db           *gorm.DB
+	nodes        *Pool
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	syncInterval time.Duration
+	broker       *StatusBroker
+	prober       ReadinessProber
+
+	mu sync.Mutex
+	// oomKilled holds containers that received an oom event and have not died yet.
+	oomKilled map[string]bool
+}
+
+// NewWatcher creates a new event watcher for the nodes of a pool.
+func NewWatcher(db *gorm.DB, nodes *Pool) *Watcher {
+	return &Watcher{
+		db:           db,
+		nodes:        nodes,
+		minBackoff:   watcherMinBackoff,
+		maxBackoff:   watcherMaxBackoff,
+		syncInterval: watcherSyncInterval,
+		oomKilled:    make(map[string]bool),
+	}
+}
+
+// SetStatusBroker sets the broker status changes are published to.
+func (w *Watcher) SetStatusBroker(broker *StatusBroker) {
+	w.broker = broker
+}
+
+// SetReadinessProber sets the prober of servers whose container starts.
+// Without a prober, such servers are simply marked as running.
+func (w *Watcher) SetReadinessProber(prober ReadinessProber) {
+	w.prober = prober
+}
+
+// Run follows the event streams of all nodes until ctx is canceled.
+// Nodes added to or removed from the pool are picked up every sync interval.
+func (w *Watcher) Run(ctx context.Context) {
+	watching := make(map[Runtime]context.CancelFunc)
+	ticker := time.NewTicker(w.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		current := make(map[Runtime]bool)
+		for _, member := range w.nodes.Members() {
+			current[member.Runtime] = true
+			if _, ok := watching[member.Runtime]; !ok {
+				nodeCtx, cancel := context.WithCancel(ctx)
+				watching[member.Runtime] = cancel
+				go w.watchNode(nodeCtx, member)
+			}
+		}
+		for runtime, cancel := range watching {
+			if !current[runtime] {
+				cancel()
+				delete(watching, runtime)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchNode follows the event stream of a node until ctx is canceled.
+func (w *Watcher) watchNode(ctx context.Context, member Member) {
+	backoff := w.minBackoff
+	for {
+		connected := false
+		err := member.Runtime.Events(ctx, func(event models.ContainerEvent) error {
+			connected = true
+			return w.HandleEvent(member.ID, event)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = w.minBackoff
+		}
+
+		slog.Warn("Container event stream disconnected", "node", member.Name, "error", err, "retryIn", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, w.maxBackoff)
+
+		if err := w.reconcileNode(ctx, member); err != nil {
+			slog.Warn("Failed to reconcile game server status", "node", member.Name, "error", err)
+		}
+	}
+}
+
+// Reconcile sets the status of every game server from the current state of its container.
+// Servers that are being created are left alone. Unreachable nodes are skipped
+// and reported in the returned error.
+func (w *Watcher) Reconcile(ctx context.Context) error {
+	var errs []error
+	for _, member := range w.nodes.Members() {
+		if err := w.reconcileNode(ctx, member); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", member.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reconcileNode reconciles the game servers placed on a node.
+func (w *Watcher) reconcileNode(ctx context.Context, member Member) error {
+	containers, err := member.Runtime.List(ctx)
+	if err != nil {
+		return err
+	}
+	running := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		running[c.ID] = c.State == models.StateRunning
+	}
+
+	var servers []models.GameServer
+	err = onNode(w.db, member.ID).Where("container_id <> '' AND status <> ?", models.GameServerStatusCreating).Find(&servers).Error
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		switch {
+		case running[server.ContainerID]:
+			err = w.started(&server)
+		case server.Status.IsRunning():
+			err = w.setStatus(&server, models.GameServerStatusStopped)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleEvent updates the game server that owns the container of an event on a node.
+// Events of containers that do not belong to a game server are ignored.
+func (w *Watcher) HandleEvent(nodeID *uint, event models.ContainerEvent) error {
+	var status models.GameServerStatus
+	switch event.Action {
+	case "start":
+		status = models.GameServerStatusRunning
+	case "oom":
+		w.mu.Lock()
+		w.oomKilled[event.ContainerID] = true
+		w.mu.Unlock()
+		status = models.GameServerStatusError
+	case "died", "die":
+		w.mu.Lock()
+		oom := w.oomKilled[event.ContainerID]
+		delete(w.oomKilled, event.ContainerID)
+		w.mu.Unlock()
+
+		status = models.GameServerStatusStopped
+		if oom || !cleanExitCodes[event.ExitCode] {
+			status = models.GameServerStatusError
+		}
+	case "health_status":
+		switch event.HealthStatus {
+		case "healthy":
+			status = models.GameServerStatusRunning
+		case "unhealthy":
+			status = models.GameServerStatusError
+		default:
+			return nil
+		}
+	case "remove":
+		status = models.GameServerStatusStopped
+	default:
+		return nil
+	}
+
+	server, err := w.findServer(nodeID, event)
+	if err != nil || server == nil {
+		return err
+	}
+
+	if event.Action == "remove" {
+		if err := w.db.Model(server).Update("container_id", "").Error; err != nil {
+			return err
+		}
+	}
+	if status == models.GameServerStatusRunning {
+		return w.started(server)
+	}
+	return w.setStatus(server, status)
+}
+
+// started updates a server whose container runs. Ready servers keep their
+// status; the others are handed to the readiness prober.
+func (w *Watcher) started(server *models.GameServer) error {
+	if w.prober == nil {
+		return w.setStatus(server, models.GameServerStatusRunning)
+	}
+	if server.Status != models.GameServerStatusReady {
+		w.prober.Started(*server)
+	}
+	return nil
+}
+
+// findServer returns the game server on a node owning the container of an event, or nil.
+// Servers are matched by container ID first. The sabakan.slug label is used for
+// servers whose container ID is not recorded yet, so stale events of a replaced
+// container never affect its successor.
+func (w *Watcher) findServer(nodeID *uint, event models.ContainerEvent) (*models.GameServer, error) {
+	var servers []models.GameServer
+	if err := onNode(w.db, nodeID).Where("container_id = ?", event.ContainerID).Limit(1).Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	if len(servers) > 0 {
+		return &servers[0], nil
+	}
+
+	slug := event.Attributes["sabakan.slug"]
+	if slug == "" || event.Action == "remove" {
+		return nil, nil
+	}
+	if err := onNode(w.db, nodeID).Where("slug = ? AND container_id = ''", slug).Limit(1).Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, nil
+	}
+
+	server := &servers[0]
+	if err := w.db.Model(server).Update("container_id", event.ContainerID).Error; err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// setStatus updates and publishes the status of a server if it changed.
+func (w *Watcher) setStatus(server *models.GameServer, status models.GameServerStatus) error {
+	if server.Status == status {
+		return nil
+	}
+	if err := w.db.Model(server).Update("status", status).Error; err != nil {
+		return err
+	}
+	w.broker.Publish(server)
+	return nil
+}
@@ -0,0 +1,212 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func setupWatcherTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// Every connection to :memory: opens a separate database
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.GameServer{}))
+	return db
+}
+
+func TestWatcher_HandleEvent(t *testing.T) {
+	db := setupWatcherTestDB(t)
+	watcher := NewWatcher(db, NewPool(NewService("http://localhost:0")))
+
+	server := models.GameServer{Slug: "mc", Name: "mc", Image: "mc", Status: models.GameServerStatusRunning, ContainerID: "abc"}
+	db.Create(&server)
+
+	status := func() models.GameServerStatus {
+		var s models.GameServer
+		require.NoError(t, db.First(&s, server.ID).Error)
+		return s.Status
+	}
+
+	t.Run("should mark a clean exit as stopped", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "died", ExitCode: 143}))
+		assert.Equal(t, models.GameServerStatusStopped, status())
+	})
+
+	t.Run("should mark a start as running", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "start"}))
+		assert.Equal(t, models.GameServerStatusRunning, status())
+	})
+
+	t.Run("should mark a non-zero exit as error", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "died", ExitCode: 1}))
+		assert.Equal(t, models.GameServerStatusError, status())
+	})
+
+	t.Run("should keep the error status when an OOM killed container dies", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "start"}))
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "oom"}))
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "died", ExitCode: 137}))
+		assert.Equal(t, models.GameServerStatusError, status())
+	})
+
+	t.Run("should follow health checks", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "health_status", HealthStatus: "healthy"}))
+		assert.Equal(t, models.GameServerStatusRunning, status())
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "health_status", HealthStatus: "unhealthy"}))
+		assert.Equal(t, models.GameServerStatusError, status())
+	})
+
+	t.Run("should ignore containers of other servers", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{
+			ContainerID: "other",
+			Action:      "start",
+			Attributes:  map[string]string{"sabakan.slug": "mc"},
+		}))
+		assert.Equal(t, models.GameServerStatusError, status())
+	})
+
+	t.Run("should match servers without a container ID by slug label", func(t *testing.T) {
+		pending := models.GameServer{Slug: "pending", Name: "pending", Image: "mc", Status: models.GameServerStatusStopped}
+		db.Create(&pending)
+
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{
+			ContainerID: "def",
+			Action:      "start",
+			Attributes:  map[string]string{"sabakan.slug": "pending"},
+		}))
+
+		var s models.GameServer
+		require.NoError(t, db.First(&s, pending.ID).Error)
+		assert.Equal(t, models.GameServerStatusRunning, s.Status)
+		assert.Equal(t, "def", s.ContainerID)
+	})
+
+	t.Run("should ignore events from other nodes", func(t *testing.T) {
+		nodeID := uint(7)
+		remote := models.GameServer{Slug: "remote", Name: "remote", Image: "mc", Status: models.GameServerStatusStopped, ContainerID: "abc", NodeID: &nodeID}
+		db.Create(&remote)
+
+		require.NoError(t, watcher.HandleEvent(&nodeID, models.ContainerEvent{ContainerID: "abc", Action: "start"}))
+
+		var s models.GameServer
+		require.NoError(t, db.First(&s, remote.ID).Error)
+		assert.Equal(t, models.GameServerStatusRunning, s.Status)
+		assert.Equal(t, models.GameServerStatusError, status())
+	})
+
+	t.Run("should forget removed containers", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "remove"}))
+
+		var s models.GameServer
+		require.NoError(t, db.First(&s, server.ID).Error)
+		assert.Equal(t, models.GameServerStatusStopped, s.Status)
+		assert.Empty(t, s.ContainerID)
+	})
+}
+
+func TestWatcher_Run(t *testing.T) {
+	db := setupWatcherTestDB(t)
+	server := models.GameServer{Slug: "mc", Name: "mc", Image: "mc", Status: models.GameServerStatusStopped, ContainerID: "abc"}
+	db.Create(&server)
+
+	var connections atomic.Int32
+	mockPodman := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v5.0.0/libpod/containers/json":
+			_, _ = w.Write([]byte(`[{"Id":"abc","Names":["mc"],"State":"exited"}]`))
+		case "/v5.0.0/libpod/events":
+			assert.Equal(t, "true", r.URL.Query().Get("stream"))
+			if connections.Add(1) == 1 {
+				// Simulate a Podman restart by closing the first stream right away
+				return
+			}
+			_, _ = w.Write([]byte(`{"Type":"container","Action":"died","Actor":{"ID":"abc","Attributes":{"containerExitCode":"2","sabakan.slug":"mc"}},"timeNano":1700000000000000000}` + "\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockPodman.Close()
+
+	watcher := NewWatcher(db, NewPool(NewService(mockPodman.URL)))
+	watcher.minBackoff = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	t.Run("should reconnect and apply events", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			var s models.GameServer
+			db.First(&s, server.ID)
+			return s.Status == models.GameServerStatusError
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.GreaterOrEqual(t, connections.Load(), int32(2))
+	})
+
+	cancel()
+	<-done
+}
+
+// recordingProber records the servers handed to it.
+type recordingProber struct {
+	started []string
+}
+
+func (p *recordingProber) Started(server models.GameServer) {
+	p.started = append(p.started, server.Slug)
+}
+
+func TestWatcher_Readiness(t *testing.T) {
+	db := setupWatcherTestDB(t)
+	watcher := NewWatcher(db, NewPool(NewService("http://localhost:0")))
+	prober := &recordingProber{}
+	watcher.SetReadinessProber(prober)
+	broker := NewStatusBroker()
+	watcher.SetStatusBroker(broker)
+
+	stopped := models.GameServer{Slug: "stopped", Name: "stopped", Image: "mc", Status: models.GameServerStatusStopped, ContainerID: "abc"}
+	ready := models.GameServer{Slug: "ready", Name: "ready", Image: "mc", Status: models.GameServerStatusReady, ContainerID: "def"}
+	db.Create(&stopped)
+	db.Create(&ready)
+
+	t.Run("should hand started servers to the prober", func(t *testing.T) {
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "abc", Action: "start"}))
+		assert.Equal(t, []string{"stopped"}, prober.started)
+	})
+
+	t.Run("should keep ready servers ready", func(t *testing.T) {
+		prober.started = nil
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "def", Action: "health_status", HealthStatus: "healthy"}))
+		assert.Empty(t, prober.started)
+	})
+
+	t.Run("should publish status changes", func(t *testing.T) {
+		events, unsubscribe := broker.Subscribe()
+		defer unsubscribe()
+
+		require.NoError(t, watcher.HandleEvent(nil, models.ContainerEvent{ContainerID: "def", Action: "died", ExitCode: 1}))
+
+		event := <-events
+		assert.Equal(t, ready.ID, event.ID)
+		assert.Equal(t, "ready", event.Slug)
+		assert.Equal(t, models.GameServerStatusError, event.Status)
+	})
+}
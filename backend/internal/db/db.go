@@ -33,9 +33,13 @@ func Migrate() error {
 		&models.OAuthAccount{},
 		&models.APIToken{},
 		&models.RefreshToken{},
+		&models.RecoveryCode{},
+		&models.Node{},
 		&models.GameServer{},
 		&models.GameServerPort{},
 		&models.GameServerEnv{},
+		&models.GameServerVolume{},
+		&models.GameServerStat{},
 		&models.Mod{},
 		&models.GameServerMod{},
 		&models.AuditLog{},
@@ -2,17 +2,40 @@ package games
 
 import (
 	"context"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // SevenDaysToDieHandler handles 7 Days to Die-specific operations.
 // Uses vinanrra/7dtd-server image.
 type SevenDaysToDieHandler struct{}
 
+// GetMetadata returns the 7 Days to Die display information.
+func (h *SevenDaysToDieHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "7 Days to Die",
+		Icon:        "pest_control",
+		DocsURL:     "https://github.com/vinanrra/Docker-7dtd",
+	}
+}
+
 // GetDefaultImage returns the default 7 Days to Die server image.
 func (h *SevenDaysToDieHandler) GetDefaultImage() string {
 	return "vinanrra/7dtd-server:latest"
 }
 
+// GetRequirements returns the minimum resources of a 7 Days to Die server.
+func (h *SevenDaysToDieHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 4096}
+}
+
+// GetReadinessProbe returns the readiness probe of a 7 Days to Die server.
+// The server opens its telnet console once the world is generated.
+func (h *SevenDaysToDieHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{Type: ProbeRCON, Timeout: 20 * time.Minute}
+}
+
 // GetDefaultPorts returns the default 7 Days to Die port mappings.
 func (h *SevenDaysToDieHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -22,39 +45,68 @@ func (h *SevenDaysToDieHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the 7 Days to Die ports.
+func (h *SevenDaysToDieHandler) GetPortProtocols() map[string]string {
+	return map[string]string{
+		"game": "udp",
+	}
+}
+
 // GetDefaultEnv returns the default 7 Days to Die environment variables.
 func (h *SevenDaysToDieHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the 7 Days to Die configuration schema.
+func (h *SevenDaysToDieHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "START_MODE", Type: FieldTypeEnum, Default: "1", Allowed: []string{"0", "1", "2", "3", "4"}, Description: "Start mode of the image: 0 installs only, 1 starts, 2 to 4 update before starting"},
+		{Name: "VERSION", Type: FieldTypeString, Default: "stable", Description: "Game branch such as stable or latest_experimental"},
+		{Name: "SERVER_NAME", Type: FieldTypeString, Default: "Sabakan 7DTD Server", Description: "Server name"},
+		{Name: "SERVER_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Description: "Password required to join"},
+		{Name: "SERVER_PORT", Type: FieldTypeInt, Default: "26900", Description: "Game port"},
+		{Name: "TELNET_PORT", Type: FieldTypeInt, Default: "8081", Description: "Telnet console port"},
+		{Name: "WEB_PORT", Type: FieldTypeInt, Default: "8082", Description: "Web dashboard port"},
+		{Name: "MAX_PLAYERS", Type: FieldTypeInt, Default: "8", Description: "Maximum number of players"},
+		{Name: "TELNET_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Generated: true, Description: "Telnet console password"},
+		{Name: "UPDATE_ON_START", Type: FieldTypeEnum, Default: "NO", Allowed: []string{"YES", "NO"}, Description: "Update the server on start"},
+		{Name: "BACKUP_ON_SHUTDOWN", Type: FieldTypeEnum, Default: "YES", Allowed: []string{"YES", "NO"}, Description: "Back up the world on shutdown"},
+	}
+}
+
+// GetDefaultVolumes returns the default 7 Days to Die data volumes.
+func (h *SevenDaysToDieHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"START_MODE":         "1",
-		"VERSION":            "stable",
-		"SERVER_NAME":        "Sabakan 7DTD Server",
-		"SERVER_PASSWORD":    "",
-		"SERVER_PORT":        "26900",
-		"TELNET_PORT":        "8081",
-		"WEB_PORT":           "8082",
-		"MAX_PLAYERS":        "8",
-		"TELNET_PASSWORD":    "changeme",
-		"UPDATE_ON_START":    "NO",
-		"BACKUP_ON_SHUTDOWN": "YES",
+		"saves":  "/home/sdtdserver/.local/share/7DaysToDie",
+		"server": "/home/sdtdserver/serverfiles",
+	}
+}
+
+// GetRCONConfig returns the 7 Days to Die telnet console configuration.
+func (h *SevenDaysToDieHandler) GetRCONConfig() RCONConfig {
+	return RCONConfig{
+		Protocol:    rcon.ProtocolTelnet,
+		Port:        "telnet",
+		PasswordEnv: "TELNET_PASSWORD",
 	}
 }
 
 // ValidateConfig validates 7 Days to Die-specific configuration.
 func (h *SevenDaysToDieHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement 7DTD-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when a 7 Days to Die server is started.
-func (h *SevenDaysToDieHandler) OnStart(_ context.Context, _ string) error {
+func (h *SevenDaysToDieHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement 7DTD-specific startup logic
 	return nil
 }
 
-// OnStop is called when a 7 Days to Die server is stopped.
-func (h *SevenDaysToDieHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement 7DTD-specific shutdown logic (backup world)
-	return nil
+// OnStop shuts a 7 Days to Die server down gracefully.
+// Saves the world over telnet, then shuts down.
+// The image backs up the world on shutdown when BACKUP_ON_SHUTDOWN is set.
+func (h *SevenDaysToDieHandler) OnStop(ctx context.Context, server Server) error {
+	return saveAndExit(ctx, server, []string{"saveworld"}, "shutdown")
 }
 
 func init() {
@@ -2,17 +2,40 @@ package games
 
 import (
 	"context"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // ArkHandler handles ARK: Survival Evolved-specific operations.
 // Uses hermsi/ark-server image.
 type ArkHandler struct{}
 
+// GetMetadata returns the ARK display information.
+func (h *ArkHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "ARK: Survival Evolved",
+		Icon:        "nature",
+		DocsURL:     "https://github.com/Hermsi1337/ark-server-tools-docker",
+	}
+}
+
 // GetDefaultImage returns the default ARK server image.
 func (h *ArkHandler) GetDefaultImage() string {
 	return "hermsi/ark-server:latest"
 }
 
+// GetRequirements returns the minimum resources of an ARK server.
+func (h *ArkHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 6144}
+}
+
+// GetReadinessProbe returns the readiness probe of an ARK server.
+// The server opens RCON once the map is loaded, which can take many minutes.
+func (h *ArkHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{Type: ProbeRCON, Timeout: 30 * time.Minute}
+}
+
 // GetDefaultPorts returns the default ARK port mappings.
 func (h *ArkHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -22,43 +45,71 @@ func (h *ArkHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the ARK ports.
+func (h *ArkHandler) GetPortProtocols() map[string]string {
+	return map[string]string{
+		"game":  "udp",
+		"query": "udp",
+	}
+}
+
 // GetDefaultEnv returns the default ARK environment variables.
 func (h *ArkHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the ARK configuration schema.
+func (h *ArkHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "SESSION_NAME", Type: FieldTypeString, Default: "Sabakan ARK Server", Description: "Server name"},
+		{Name: "SERVER_MAP", Type: FieldTypeString, Default: "TheIsland", Description: "Map to load"},
+		{Name: "SERVER_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Description: "Password required to join"},
+		{Name: "ADMIN_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Generated: true, Description: "Admin and RCON password"},
+		{Name: "MAX_PLAYERS", Type: FieldTypeInt, Default: "70", Description: "Maximum number of players"},
+		{Name: "UPDATE_ON_START", Type: FieldTypeBool, Default: "true", Description: "Update the server on start"},
+		{Name: "BACKUP_ON_STOP", Type: FieldTypeBool, Default: "true", Description: "Back up the world on stop"},
+		{Name: "WARN_ON_STOP", Type: FieldTypeBool, Default: "true", Description: "Warn players before stopping"},
+		{Name: "ENABLE_CROSSPLAY", Type: FieldTypeBool, Default: "false", Description: "Allow Epic Games players to join"},
+		{Name: "DISABLE_BATTLEYE", Type: FieldTypeBool, Default: "false", Description: "Disable BattlEye anti-cheat"},
+		{Name: "ARK_MODS", Type: FieldTypeString, Default: "", Description: "Comma-separated list of mod IDs"},
+		{Name: "GAME_CLIENT_PORT", Type: FieldTypeInt, Default: "7777", Description: "Game port"},
+		{Name: "UDP_SOCKET", Type: FieldTypeInt, Default: "7778", Description: "Raw UDP socket port"},
+		{Name: "RCON_PORT", Type: FieldTypeInt, Default: "32330", Description: "RCON port"},
+		{Name: "SERVER_QUERY_PORT", Type: FieldTypeInt, Default: "27015", Description: "Steam query port"},
+	}
+}
+
+// GetDefaultVolumes returns the default ARK data volumes.
+func (h *ArkHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"SESSION_NAME":      "Sabakan ARK Server",
-		"SERVER_MAP":        "TheIsland",
-		"SERVER_PASSWORD":   "",
-		"ADMIN_PASSWORD":    "changeme",
-		"MAX_PLAYERS":       "70",
-		"UPDATE_ON_START":   "true",
-		"BACKUP_ON_STOP":    "true",
-		"WARN_ON_STOP":      "true",
-		"ENABLE_CROSSPLAY":  "false",
-		"DISABLE_BATTLEYE":  "false",
-		"ARK_MODS":          "",
-		"GAME_CLIENT_PORT":  "7777",
-		"UDP_SOCKET":        "7778",
-		"RCON_PORT":         "32330",
-		"SERVER_QUERY_PORT": "27015",
+		"data": "/app",
+	}
+}
+
+// GetRCONConfig returns the ARK RCON configuration.
+func (h *ArkHandler) GetRCONConfig() RCONConfig {
+	return RCONConfig{
+		Protocol:    rcon.ProtocolSource,
+		Port:        "rcon",
+		PasswordEnv: "ADMIN_PASSWORD",
 	}
 }
 
 // ValidateConfig validates ARK-specific configuration.
 func (h *ArkHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement ARK-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when an ARK server is started.
-func (h *ArkHandler) OnStart(_ context.Context, _ string) error {
+func (h *ArkHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement ARK-specific startup logic
 	return nil
 }
 
-// OnStop is called when an ARK server is stopped.
-func (h *ArkHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement ARK-specific shutdown logic (save world, backup)
-	return nil
+// OnStop shuts an ARK server down gracefully.
+// Saves the world over RCON, then exits.
+func (h *ArkHandler) OnStop(ctx context.Context, server Server) error {
+	return saveAndExit(ctx, server, []string{"SaveWorld"}, "DoExit")
 }
 
 func init() {
@@ -0,0 +1,304 @@
+package games
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/config"
+)
+
+// exampleSuffix marks game definition files that are not loaded.
+const exampleSuffix = ".example.toml"
+
+// CustomHandler is a generic GameHandler defined by a TOML game config file.
+type CustomHandler struct {
+	metadata  Metadata
+	image     string
+	ports     map[string]int
+	protocols map[string]string
+	volumes   map[string]string
+	schema    ConfigSchema
+	minimum   Requirements
+	readiness ReadinessProbe
+}
+
+// NewCustomHandler creates a handler from a game config.
+//
+// Ports are written as "[name=]container[/protocol]". For compatibility with
+// "host:container" entries, only the container port is used; host ports are
+// assigned when a server is created. Unnamed ports are named after the
+// container part, e.g. "2456/udp".
+//
+// Volumes are written as "name:/path". If the source is a host path such as
+// "./data", its base name is used as the volume name.
+func NewCustomHandler(cfg *config.GameConfig) (*CustomHandler, error) {
+	if cfg.Container.Image == "" {
+		return nil, errors.New("container image is required")
+	}
+
+	h := &CustomHandler{
+		metadata: Metadata{
+			DisplayName: cfg.Game.Name,
+			Icon:        cfg.Game.Icon,
+			DocsURL:     cfg.Game.DocsURL,
+		},
+		image:     cfg.Container.Image,
+		minimum:   Requirements{MemoryMB: cfg.Container.MinMemoryMB, CPUs: cfg.Container.MinCPUs},
+		ports:     make(map[string]int),
+		protocols: make(map[string]string),
+		volumes:   make(map[string]string),
+	}
+	if h.metadata.DisplayName == "" {
+		h.metadata.DisplayName = cfg.Game.ID
+	}
+	if h.metadata.Icon == "" {
+		h.metadata.Icon = "sports_esports"
+	}
+
+	for _, spec := range cfg.Container.Ports {
+		name, port, protocol, err := parsePortSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := h.ports[name]; exists {
+			return nil, fmt.Errorf("duplicate port name %q", name)
+		}
+		h.ports[name] = port
+		if protocol != "tcp" {
+			h.protocols[name] = protocol
+		}
+	}
+
+	for _, spec := range cfg.Container.Volumes {
+		name, path, err := parseVolumeSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		h.volumes[name] = path
+	}
+
+	readiness, err := parseReadiness(cfg.Readiness, h.ports, h.protocols)
+	if err != nil {
+		return nil, err
+	}
+	h.readiness = readiness
+
+	keys := make([]string, 0, len(cfg.Container.Env))
+	for key := range cfg.Container.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h.schema = append(h.schema, ConfigField{
+			Name:    key,
+			Type:    FieldTypeString,
+			Default: cfg.Container.Env[key],
+		})
+	}
+
+	return h, nil
+}
+
+// parsePortSpec parses a "[name=][host:]container[/protocol]" port entry.
+func parsePortSpec(spec string) (name string, port int, protocol string, err error) {
+	rest := spec
+	if i := strings.Index(rest, "="); i >= 0 {
+		name, rest = rest[:i], rest[i+1:]
+	}
+
+	protocol = "tcp"
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest, protocol = rest[:i], rest[i+1:]
+	}
+	if protocol != "tcp" && protocol != "udp" {
+		return "", 0, "", fmt.Errorf("invalid port %q: protocol must be tcp or udp", spec)
+	}
+
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		rest = rest[i+1:]
+	}
+	port, err = strconv.Atoi(rest)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, "", fmt.Errorf("invalid port %q", spec)
+	}
+
+	if name == "" {
+		name = strconv.Itoa(port)
+		if protocol != "tcp" {
+			name += "/" + protocol
+		}
+	}
+	return name, port, protocol, nil
+}
+
+// parseReadiness builds the readiness probe of a game config.
+// The probe is empty when neither a log pattern nor a port is set. Only tcp
+// ports can be probed, since an unanswered UDP datagram proves nothing.
+func parseReadiness(info config.ReadinessInfo, ports map[string]int, protocols map[string]string) (ReadinessProbe, error) {
+	probe := ReadinessProbe{Timeout: time.Duration(info.Timeout) * time.Second}
+	switch {
+	case info.Log != "" && info.Port != "":
+		return ReadinessProbe{}, errors.New("readiness: set either log or port")
+	case info.Log != "":
+		pattern, err := regexp.Compile(info.Log)
+		if err != nil {
+			return ReadinessProbe{}, fmt.Errorf("readiness: invalid log pattern: %w", err)
+		}
+		probe.Type = ProbeLog
+		probe.Pattern = pattern
+	case info.Port != "":
+		if _, ok := ports[info.Port]; !ok {
+			return ReadinessProbe{}, fmt.Errorf("readiness: unknown port %q", info.Port)
+		}
+		if protocol, ok := protocols[info.Port]; ok && protocol != "tcp" {
+			return ReadinessProbe{}, fmt.Errorf("readiness: port %q is %s, only tcp ports can be probed", info.Port, protocol)
+		}
+		probe.Type = ProbePort
+		probe.Port = info.Port
+	}
+	return probe, nil
+}
+
+// parseVolumeSpec parses a "name:/path" volume entry. Only named volumes are
+// supported, so host paths are rejected rather than mounted as a volume.
+func parseVolumeSpec(spec string) (name, path string, err error) {
+	source, path, ok := strings.Cut(spec, ":")
+	if !ok || source == "" || !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("invalid volume %q: expected name:/path", spec)
+	}
+	if strings.ContainsAny(source, `/\`) || strings.HasPrefix(source, ".") {
+		return "", "", fmt.Errorf("invalid volume %q: %q is a host path, expected a volume name", spec, source)
+	}
+	return source, path, nil
+}
+
+// GetMetadata returns the display information of the game.
+func (h *CustomHandler) GetMetadata() Metadata {
+	return h.metadata
+}
+
+// GetDefaultImage returns the container image of the game.
+func (h *CustomHandler) GetDefaultImage() string {
+	return h.image
+}
+
+// GetRequirements returns the minimum resources set in the game config.
+func (h *CustomHandler) GetRequirements() Requirements {
+	return h.minimum
+}
+
+// GetReadinessProbe returns the readiness probe set in the game config.
+func (h *CustomHandler) GetReadinessProbe() ReadinessProbe {
+	return h.readiness
+}
+
+// GetDefaultPorts returns the ports of the game.
+func (h *CustomHandler) GetDefaultPorts() map[string]int {
+	return h.ports
+}
+
+// GetPortProtocols returns the protocols of the ports that do not use tcp.
+func (h *CustomHandler) GetPortProtocols() map[string]string {
+	return h.protocols
+}
+
+// GetDefaultEnv returns the environment variables of the game.
+func (h *CustomHandler) GetDefaultEnv() map[string]string {
+	return h.schema.Defaults()
+}
+
+// GetDefaultVolumes returns the volumes of the game.
+func (h *CustomHandler) GetDefaultVolumes() map[string]string {
+	return h.volumes
+}
+
+// GetConfigSchema returns a schema with a string field for every environment variable.
+func (h *CustomHandler) GetConfigSchema() ConfigSchema {
+	return h.schema
+}
+
+// ValidateConfig validates the configuration against the schema.
+func (h *CustomHandler) ValidateConfig(config map[string]any) error {
+	return h.schema.Validate(config)
+}
+
+// OnStart is called when the server is started.
+func (h *CustomHandler) OnStart(_ context.Context, _ Server) error {
+	return nil
+}
+
+// OnStop is called when the server is stopped.
+// Custom games have no console, so the container stop signal shuts them down.
+func (h *CustomHandler) OnStop(_ context.Context, _ Server) error {
+	return nil
+}
+
+// LoadDir registers a CustomHandler for every *.toml file in dir. Files ending
+// in .example.toml are skipped. A missing directory is not an error. Invalid
+// files and files whose ID is taken by a built-in game or an earlier file are
+// skipped and reported in the returned error; the IDs of the loaded games are
+// returned.
+func LoadDir(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var loaded []string
+	var errs []error
+	sources := make(map[string]string)
+	for _, path := range paths {
+		if strings.HasSuffix(path, exampleSuffix) {
+			continue
+		}
+
+		id, handler, err := loadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if source, ok := sources[id]; ok {
+			errs = append(errs, fmt.Errorf("%s: game %q is already defined in %s", path, id, source))
+			continue
+		}
+		if _, ok := Get(id); ok {
+			errs = append(errs, fmt.Errorf("%s: game %q is already defined by a built-in game", path, id))
+			continue
+		}
+		Register(id, handler)
+		sources[id] = path
+		loaded = append(loaded, id)
+	}
+
+	return loaded, errors.Join(errs...)
+}
+
+// loadFile loads a single game definition. The ID defaults to the file name.
+func loadFile(path string) (string, *CustomHandler, error) {
+	cfg, err := config.LoadGameConfig(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id := cfg.Game.ID
+	if id == "" {
+		id = strings.TrimSuffix(filepath.Base(path), ".toml")
+	}
+
+	handler, err := NewCustomHandler(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, handler, nil
+}
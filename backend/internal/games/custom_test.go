@@ -0,0 +1,229 @@
+package games
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/config"
+)
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	write("valheim.toml", `
+[game]
+id = "valheim"
+name = "Valheim"
+docs_url = "https://github.com/lloesche/valheim-server-docker"
+
+[container]
+image = "lloesche/valheim-server:latest"
+ports = ["game=2456/udp", "2457:2457/udp", "status=9001"]
+volumes = ["config:/config", "data:/opt/valheim"]
+min_memory_mb = 2048
+
+[readiness]
+port = "status"
+
+[container.env]
+SERVER_NAME = "Sabakan Valheim"
+WORLD_NAME = "Dedicated"
+`)
+	write("minecraft.toml", `
+[game]
+id = "minecraft"
+name = "Minecraft (custom)"
+
+[container]
+image = "example/minecraft:latest"
+ports = ["minecraft=25565"]
+`)
+	write("valheim2.toml", `
+[game]
+id = "valheim"
+name = "Valheim (copy)"
+
+[container]
+image = "example/valheim:latest"
+`)
+	write("broken.toml", `
+[game]
+id = "broken"
+
+[container]
+ports = ["25565"]
+`)
+	write("hostpath.toml", `
+[game]
+id = "hostpath"
+
+[container]
+image = "example:latest"
+volumes = ["./data:/data"]
+`)
+	write("badprobe.toml", `
+[game]
+id = "badprobe"
+
+[container]
+image = "example:latest"
+
+[readiness]
+port = "missing"
+`)
+	write("game.example.toml", `
+[game]
+id = "example"
+
+[container]
+image = "example:latest"
+`)
+
+	t.Cleanup(func() {
+		delete(Registry, "valheim")
+	})
+
+	loaded, err := LoadDir(dir)
+
+	t.Run("should report invalid files", func(t *testing.T) {
+		assert.ErrorContains(t, err, "broken.toml")
+		assert.ErrorContains(t, err, `unknown port "missing"`)
+		assert.ErrorContains(t, err, `"./data" is a host path`)
+		assert.Equal(t, []string{"valheim"}, loaded)
+	})
+
+	t.Run("should register the game", func(t *testing.T) {
+		handler, ok := Get("valheim")
+		require.True(t, ok)
+
+		assert.Equal(t, "Valheim", handler.GetMetadata().DisplayName)
+		assert.Equal(t, "lloesche/valheim-server:latest", handler.GetDefaultImage())
+		assert.Equal(t, map[string]int{"game": 2456, "2457/udp": 2457, "status": 9001}, handler.GetDefaultPorts())
+		assert.Equal(t, "udp", PortProtocol(handler, "game"))
+		assert.Equal(t, "tcp", PortProtocol(handler, "status"))
+		assert.Equal(t, map[string]string{"config": "/config", "data": "/opt/valheim"}, handler.GetDefaultVolumes())
+		assert.Equal(t, "Dedicated", handler.GetDefaultEnv()["WORLD_NAME"])
+		assert.Equal(t, Requirements{MemoryMB: 2048}, MinimumRequirements(handler))
+		assert.Equal(t, &ReadinessProbe{Type: ProbePort, Port: "status"}, Readiness(handler))
+	})
+
+	t.Run("should not replace built-in games with the same ID", func(t *testing.T) {
+		assert.ErrorContains(t, err, `minecraft.toml: game "minecraft" is already defined by a built-in game`)
+
+		handler, ok := Get("minecraft")
+		require.True(t, ok)
+		assert.Equal(t, "itzg/minecraft-server:latest", handler.GetDefaultImage())
+	})
+
+	t.Run("should keep the first of several files with the same ID", func(t *testing.T) {
+		assert.ErrorContains(t, err, `valheim2.toml: game "valheim" is already defined in `+filepath.Join(dir, "valheim.toml"))
+
+		handler, ok := Get("valheim")
+		require.True(t, ok)
+		assert.Equal(t, "lloesche/valheim-server:latest", handler.GetDefaultImage())
+	})
+
+	t.Run("should skip example files", func(t *testing.T) {
+		_, ok := Get("example")
+		assert.False(t, ok)
+	})
+}
+
+func TestLoadDir_Missing(t *testing.T) {
+	loaded, err := LoadDir(filepath.Join(t.TempDir(), "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, loaded)
+}
+
+func TestParseReadiness(t *testing.T) {
+	ports := map[string]int{"game": 2456, "status": 9001}
+	protocols := map[string]string{"game": "udp"}
+
+	t.Run("should probe tcp ports", func(t *testing.T) {
+		probe, err := parseReadiness(config.ReadinessInfo{Port: "status"}, ports, protocols)
+		require.NoError(t, err)
+		assert.Equal(t, ReadinessProbe{Type: ProbePort, Port: "status"}, probe)
+	})
+
+	t.Run("should reject udp ports", func(t *testing.T) {
+		_, err := parseReadiness(config.ReadinessInfo{Port: "game"}, ports, protocols)
+		assert.ErrorContains(t, err, `port "game" is udp`)
+	})
+
+	t.Run("should only probe tcp ports of built-in games", func(t *testing.T) {
+		for name, handler := range Registry {
+			if probe := Readiness(handler); probe != nil && probe.Type == ProbePort {
+				assert.Equal(t, "tcp", PortProtocol(handler, probe.Port), name)
+			}
+		}
+	})
+}
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		name     string
+		port     int
+		protocol string
+		wantErr  bool
+	}{
+		{spec: "25565", name: "25565", port: 25565, protocol: "tcp"},
+		{spec: "25566:25565", name: "25565", port: 25565, protocol: "tcp"},
+		{spec: "game=2456/udp", name: "game", port: 2456, protocol: "udp"},
+		{spec: "game=2456/sctp", wantErr: true},
+		{spec: "lots", wantErr: true},
+		{spec: "70000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			name, port, protocol, err := parsePortSpec(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.port, port)
+			assert.Equal(t, tt.protocol, protocol)
+		})
+	}
+}
+
+func TestParseVolumeSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{spec: "data:/data", name: "data", path: "/data"},
+		{spec: "world-1:/opt/world", name: "world-1", path: "/opt/world"},
+		{spec: "./data:/data", wantErr: true},
+		{spec: "../data:/data", wantErr: true},
+		{spec: ".data:/data", wantErr: true},
+		{spec: "/srv/data:/data", wantErr: true},
+		{spec: "game/data:/data", wantErr: true},
+		{spec: "data:data", wantErr: true},
+		{spec: "data", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			name, path, err := parseVolumeSpec(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
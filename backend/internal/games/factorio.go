@@ -2,17 +2,43 @@ package games
 
 import (
 	"context"
+	"regexp"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // FactorioHandler handles Factorio-specific operations.
 // Uses factoriotools/factorio image.
 type FactorioHandler struct{}
 
+// GetMetadata returns the Factorio display information.
+func (h *FactorioHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "Factorio",
+		Icon:        "precision_manufacturing",
+		DocsURL:     "https://github.com/factoriotools/factorio-docker",
+	}
+}
+
 // GetDefaultImage returns the default Factorio server image.
 func (h *FactorioHandler) GetDefaultImage() string {
 	return "factoriotools/factorio:stable"
 }
 
+// GetRequirements returns the minimum resources of a Factorio server.
+func (h *FactorioHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 1024}
+}
+
+// GetReadinessProbe returns the readiness probe of a Factorio server.
+// The server is ready once the game state changes to InGame.
+func (h *FactorioHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{
+		Type:    ProbeLog,
+		Pattern: regexp.MustCompile(`changing state from\(CreatingGame\) to\(InGame\)`),
+	}
+}
+
 // GetDefaultPorts returns the default Factorio port mappings.
 func (h *FactorioHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -21,37 +47,64 @@ func (h *FactorioHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the Factorio ports.
+func (h *FactorioHandler) GetPortProtocols() map[string]string {
+	return map[string]string{
+		"game": "udp",
+	}
+}
+
 // GetDefaultEnv returns the default Factorio environment variables.
 func (h *FactorioHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the Factorio configuration schema.
+func (h *FactorioHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "PORT", Type: FieldTypeInt, Default: "34197", Description: "Game port"},
+		{Name: "RCON_PORT", Type: FieldTypeInt, Default: "27015", Description: "RCON port"},
+		{Name: "UPDATE_MODS_ON_START", Type: FieldTypeBool, Default: "false", Description: "Update mods on start"},
+		{Name: "DLC_SPACE_AGE", Type: FieldTypeBool, Default: "false", Description: "Enable the Space Age expansion"},
+		{Name: "SAVE_NAME", Type: FieldTypeString, Default: "world", Description: "Name of the save to load"},
+		{Name: "TOKEN", Type: FieldTypeString, Default: "", Secret: true, Description: "factorio.com token, used to download mods"},
+		{Name: "USERNAME", Type: FieldTypeString, Default: "", Description: "factorio.com username, used to download mods"},
+		{Name: "GENERATE_NEW_SAVE", Type: FieldTypeBool, Default: "true", Description: "Create the save if it does not exist"},
+		{Name: "LOAD_LATEST_SAVE", Type: FieldTypeBool, Default: "true", Description: "Load the most recent save"},
+	}
+}
+
+// GetDefaultVolumes returns the default Factorio data volumes.
+func (h *FactorioHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"PORT":                 "34197",
-		"RCON_PORT":            "27015",
-		"UPDATE_MODS_ON_START": "false",
-		"DLC_SPACE_AGE":        "false",
-		"SAVE_NAME":            "world",
-		"TOKEN":                "",
-		"USERNAME":             "",
-		"GENERATE_NEW_SAVE":    "true",
-		"LOAD_LATEST_SAVE":     "true",
+		"data": "/factorio",
+	}
+}
+
+// GetRCONConfig returns the Factorio RCON configuration.
+func (h *FactorioHandler) GetRCONConfig() RCONConfig {
+	return RCONConfig{
+		Protocol:     rcon.ProtocolSource,
+		Port:         "rcon",
+		PasswordFile: "/factorio/config/rconpw",
 	}
 }
 
 // ValidateConfig validates Factorio-specific configuration.
 func (h *FactorioHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement Factorio-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when a Factorio server is started.
-func (h *FactorioHandler) OnStart(_ context.Context, _ string) error {
+func (h *FactorioHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement Factorio-specific startup logic
 	return nil
 }
 
-// OnStop is called when a Factorio server is stopped.
-func (h *FactorioHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement Factorio-specific shutdown logic (autosave)
-	return nil
+// OnStop shuts a Factorio server down gracefully.
+// Saves the map over RCON, then quits.
+func (h *FactorioHandler) OnStop(ctx context.Context, server Server) error {
+	return saveAndExit(ctx, server, []string{"/server-save"}, "/quit")
 }
 
 func init() {
@@ -3,27 +3,158 @@ package games
 
 import (
 	"context"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // GameHandler defines the interface for game-specific operations.
 type GameHandler interface {
+	// GetMetadata returns display information about the game.
+	GetMetadata() Metadata
+
 	// GetDefaultImage returns the default Docker image for this game.
 	GetDefaultImage() string
 
 	// GetDefaultPorts returns the default port mappings for this game.
 	GetDefaultPorts() map[string]int
 
+	// GetPortProtocols returns the protocol of each named default port.
+	// Ports that are not listed use tcp.
+	GetPortProtocols() map[string]string
+
 	// GetDefaultEnv returns the default environment variables for this game.
 	GetDefaultEnv() map[string]string
 
+	// GetDefaultVolumes returns the named volumes for this game,
+	// keyed by volume name with the mount path inside the container as value.
+	GetDefaultVolumes() map[string]string
+
+	// GetConfigSchema returns the schema of the game's environment variables.
+	GetConfigSchema() ConfigSchema
+
 	// ValidateConfig validates the game-specific configuration.
+	// Invalid fields are reported as ValidationErrors.
 	ValidateConfig(config map[string]any) error
 
-	// OnStart is called when a game server is started.
-	OnStart(ctx context.Context, containerID string) error
+	// OnStart is called after a game server has been started.
+	OnStart(ctx context.Context, server Server) error
+
+	// OnStop is called before a game server's container is stopped and
+	// should save the world and shut the server down gracefully.
+	// The container is stopped afterwards even if OnStop fails.
+	OnStop(ctx context.Context, server Server) error
+}
+
+// Metadata holds display information about a game.
+type Metadata struct {
+	// DisplayName is the human-readable name of the game.
+	DisplayName string `json:"displayName"`
+	// Icon is the name of the Material icon shown for the game.
+	Icon string `json:"icon"`
+	// DocsURL links to the documentation of the server image.
+	DocsURL string `json:"docsUrl"`
+}
+
+// Server gives lifecycle hooks access to a game server.
+type Server interface {
+	// ContainerID returns the ID of the server's container.
+	ContainerID() string
+
+	// RCON connects to the server's remote console.
+	RCON(ctx context.Context) (rcon.Client, error)
+
+	// Wait blocks until the server's container has exited.
+	Wait(ctx context.Context) error
+}
+
+// RCONConfig describes how to reach the remote console of a game server.
+type RCONConfig struct {
+	// Protocol is the remote console protocol spoken by the server.
+	Protocol rcon.Protocol
+	// Port is the name of the default port the console listens on.
+	Port string
+	// PasswordEnv is the environment variable holding the password.
+	PasswordEnv string
+	// PasswordFile is a file inside the container holding the password.
+	// It is used when PasswordEnv is empty.
+	PasswordFile string
+}
+
+// RCONHandler is implemented by game handlers whose servers provide RCON.
+type RCONHandler interface {
+	// GetRCONConfig returns the remote console configuration.
+	GetRCONConfig() RCONConfig
+}
+
+// Requirements are the minimum resources a game server needs to run.
+type Requirements struct {
+	// MemoryMB is the smallest memory limit in MiB the server works with.
+	MemoryMB int `json:"memoryMb"`
+	// CPUs is the smallest CPU limit the server works with.
+	CPUs float64 `json:"cpus,omitempty"`
+}
+
+// RequirementsHandler is implemented by game handlers whose servers need a
+// minimum of resources.
+type RequirementsHandler interface {
+	// GetRequirements returns the minimum resources of a server.
+	GetRequirements() Requirements
+}
+
+// MinimumRequirements returns the requirements of a handler, zero when it has none.
+func MinimumRequirements(handler GameHandler) Requirements {
+	if h, ok := handler.(RequirementsHandler); ok {
+		return h.GetRequirements()
+	}
+	return Requirements{}
+}
+
+// ProbeType is how a readiness probe checks a game server.
+type ProbeType string
+
+const (
+	// ProbeLog waits for a log line matching the probe's pattern.
+	ProbeLog ProbeType = "log"
+	// ProbePort connects to the host port of the probe's port.
+	ProbePort ProbeType = "port"
+	// ProbeRCON connects to the server's remote console.
+	ProbeRCON ProbeType = "rcon"
+)
 
-	// OnStop is called when a game server is stopped.
-	OnStop(ctx context.Context, containerID string) error
+// ReadinessProbe describes how to tell that a started game server accepts players.
+type ReadinessProbe struct {
+	// Type is how the server is checked.
+	Type ProbeType
+	// Pattern matches the log line printed once the game is ready (ProbeLog).
+	Pattern *regexp.Regexp
+	// Port is the name of the default port to connect to (ProbePort).
+	Port string
+	// Timeout is how long the game may take to become ready.
+	// The server is marked as failed afterwards. A default is used when 0.
+	Timeout time.Duration
+}
+
+// ReadinessHandler is implemented by game handlers whose servers take a while
+// to accept players after their container has started.
+type ReadinessHandler interface {
+	// GetReadinessProbe returns the readiness probe of a server.
+	GetReadinessProbe() ReadinessProbe
+}
+
+// Readiness returns the readiness probe of a handler, nil when it has none.
+func Readiness(handler GameHandler) *ReadinessProbe {
+	h, ok := handler.(ReadinessHandler)
+	if !ok {
+		return nil
+	}
+	probe := h.GetReadinessProbe()
+	if probe.Type == "" {
+		return nil
+	}
+	return &probe
 }
 
 // Registry holds all registered game handlers.
@@ -40,11 +171,20 @@ func Get(name string) (GameHandler, bool) {
 	return handler, ok
 }
 
-// List returns all registered game names.
+// PortProtocol returns the protocol of the named default port of a handler.
+func PortProtocol(handler GameHandler, name string) string {
+	if protocol, ok := handler.GetPortProtocols()[name]; ok {
+		return protocol
+	}
+	return "tcp"
+}
+
+// List returns all registered game names in alphabetical order.
 func List() []string {
 	names := make([]string, 0, len(Registry))
 	for name := range Registry {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
@@ -2,17 +2,44 @@ package games
 
 import (
 	"context"
+	"regexp"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // MinecraftHandler handles Minecraft-specific operations.
 // Uses itzg/docker-minecraft-server image.
 type MinecraftHandler struct{}
 
+// GetMetadata returns the Minecraft display information.
+func (h *MinecraftHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "Minecraft",
+		Icon:        "sports_esports",
+		DocsURL:     "https://docker-minecraft-server.readthedocs.io/",
+	}
+}
+
 // GetDefaultImage returns the default Minecraft server image.
 func (h *MinecraftHandler) GetDefaultImage() string {
 	return "itzg/minecraft-server:latest"
 }
 
+// GetRequirements returns the minimum resources of a Minecraft server.
+// The JVM heap (MEMORY, 1G by default) must fit in the limit.
+func (h *MinecraftHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 1024}
+}
+
+// GetReadinessProbe returns the readiness probe of a Minecraft server.
+// The server is ready once it logs "Done (...)! For help".
+func (h *MinecraftHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{
+		Type:    ProbeLog,
+		Pattern: regexp.MustCompile(`Done \([0-9.]+s\)! For help`),
+	}
+}
+
 // GetDefaultPorts returns the default Minecraft port mappings.
 func (h *MinecraftHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -21,35 +48,61 @@ func (h *MinecraftHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the Minecraft ports.
+// All Minecraft ports use tcp.
+func (h *MinecraftHandler) GetPortProtocols() map[string]string {
+	return map[string]string{}
+}
+
 // GetDefaultEnv returns the default Minecraft environment variables.
 func (h *MinecraftHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the Minecraft configuration schema.
+func (h *MinecraftHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "EULA", Type: FieldTypeBool, Default: "TRUE", Description: "Accept the Minecraft EULA"},
+		{Name: "TYPE", Type: FieldTypeEnum, Default: "VANILLA", Allowed: []string{"VANILLA", "PAPER", "SPIGOT", "BUKKIT", "PURPUR", "FABRIC", "QUILT", "FORGE", "NEOFORGE"}, Description: "Server type"},
+		{Name: "VERSION", Type: FieldTypeString, Default: "LATEST", Description: "Minecraft version such as 1.21.4, or LATEST"},
+		{Name: "MEMORY", Type: FieldTypeMemory, Default: "2G", Description: "Java heap size"},
+		{Name: "OPS", Type: FieldTypeString, Default: "", Description: "Comma-separated list of operator usernames"},
+		{Name: "ENABLE_RCON", Type: FieldTypeBool, Default: "true", Description: "Enable RCON, used for console commands and graceful stop"},
+		{Name: "RCON_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Generated: true, Description: "RCON password"},
+	}
+}
+
+// GetDefaultVolumes returns the default Minecraft data volumes.
+func (h *MinecraftHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"EULA":          "TRUE",
-		"TYPE":          "VANILLA",
-		"VERSION":       "LATEST",
-		"MEMORY":        "2G",
-		"OPS":           "",
-		"ENABLE_RCON":   "true",
-		"RCON_PASSWORD": "changeme",
+		"data": "/data",
+	}
+}
+
+// GetRCONConfig returns the Minecraft RCON configuration.
+func (h *MinecraftHandler) GetRCONConfig() RCONConfig {
+	return RCONConfig{
+		Protocol:    rcon.ProtocolSource,
+		Port:        "rcon",
+		PasswordEnv: "RCON_PASSWORD",
 	}
 }
 
 // ValidateConfig validates Minecraft-specific configuration.
 func (h *MinecraftHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement Minecraft-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when a Minecraft server is started.
-func (h *MinecraftHandler) OnStart(_ context.Context, _ string) error {
+func (h *MinecraftHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement Minecraft-specific startup logic
 	return nil
 }
 
-// OnStop is called when a Minecraft server is stopped.
-func (h *MinecraftHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement Minecraft-specific shutdown logic (e.g., graceful save)
-	return nil
+// OnStop shuts a Minecraft server down gracefully.
+// Flushes all chunks to disk over RCON, then stops.
+func (h *MinecraftHandler) OnStop(ctx context.Context, server Server) error {
+	return saveAndExit(ctx, server, []string{"save-all flush"}, "stop")
 }
 
 func init() {
@@ -2,17 +2,39 @@ package games
 
 import (
 	"context"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // PalworldHandler handles Palworld-specific operations.
 // Uses thijsvanloef/palworld-server-docker image.
 type PalworldHandler struct{}
 
+// GetMetadata returns the Palworld display information.
+func (h *PalworldHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "Palworld",
+		Icon:        "pets",
+		DocsURL:     "https://github.com/thijsvanloef/palworld-server-docker",
+	}
+}
+
 // GetDefaultImage returns the default Palworld server image.
 func (h *PalworldHandler) GetDefaultImage() string {
 	return "thijsvanloef/palworld-server-docker:latest"
 }
 
+// GetRequirements returns the minimum resources of a Palworld server.
+func (h *PalworldHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 8192}
+}
+
+// GetReadinessProbe returns the readiness probe of a Palworld server.
+// The server is ready once its RCON accepts connections.
+func (h *PalworldHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{Type: ProbeRCON}
+}
+
 // GetDefaultPorts returns the default Palworld port mappings.
 func (h *PalworldHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -22,39 +44,67 @@ func (h *PalworldHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the Palworld ports.
+func (h *PalworldHandler) GetPortProtocols() map[string]string {
+	return map[string]string{
+		"game":  "udp",
+		"query": "udp",
+	}
+}
+
 // GetDefaultEnv returns the default Palworld environment variables.
 func (h *PalworldHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the Palworld configuration schema.
+func (h *PalworldHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "PUID", Type: FieldTypeInt, Default: "1000", Description: "User ID the server runs as"},
+		{Name: "PGID", Type: FieldTypeInt, Default: "1000", Description: "Group ID the server runs as"},
+		{Name: "PORT", Type: FieldTypeInt, Default: "8211", Description: "Game port"},
+		{Name: "PLAYERS", Type: FieldTypeInt, Default: "16", Description: "Maximum number of players"},
+		{Name: "MULTITHREADING", Type: FieldTypeBool, Default: "true", Description: "Use multiple CPU threads"},
+		{Name: "RCON_ENABLED", Type: FieldTypeBool, Default: "true", Description: "Enable RCON, used for console commands and graceful stop"},
+		{Name: "RCON_PORT", Type: FieldTypeInt, Default: "25575", Description: "RCON port"},
+		{Name: "ADMIN_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Generated: true, Description: "Admin and RCON password"},
+		{Name: "COMMUNITY", Type: FieldTypeBool, Default: "false", Description: "List the server in the community server browser"},
+		{Name: "SERVER_NAME", Type: FieldTypeString, Default: "Sabakan Palworld Server", Description: "Server name"},
+		{Name: "SERVER_DESCRIPTION", Type: FieldTypeString, Default: "A Palworld server managed by Sabakan", Description: "Server description"},
+	}
+}
+
+// GetDefaultVolumes returns the default Palworld data volumes.
+func (h *PalworldHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"PUID":               "1000",
-		"PGID":               "1000",
-		"PORT":               "8211",
-		"PLAYERS":            "16",
-		"MULTITHREADING":     "true",
-		"RCON_ENABLED":       "true",
-		"RCON_PORT":          "25575",
-		"ADMIN_PASSWORD":     "changeme",
-		"COMMUNITY":          "false",
-		"SERVER_NAME":        "Sabakan Palworld Server",
-		"SERVER_DESCRIPTION": "A Palworld server managed by Sabakan",
+		"data": "/palworld",
+	}
+}
+
+// GetRCONConfig returns the Palworld RCON configuration.
+func (h *PalworldHandler) GetRCONConfig() RCONConfig {
+	return RCONConfig{
+		Protocol:    rcon.ProtocolSource,
+		Port:        "rcon",
+		PasswordEnv: "ADMIN_PASSWORD",
 	}
 }
 
 // ValidateConfig validates Palworld-specific configuration.
 func (h *PalworldHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement Palworld-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when a Palworld server is started.
-func (h *PalworldHandler) OnStart(_ context.Context, _ string) error {
+func (h *PalworldHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement Palworld-specific startup logic
 	return nil
 }
 
-// OnStop is called when a Palworld server is stopped.
-func (h *PalworldHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement Palworld-specific shutdown logic
-	return nil
+// OnStop shuts a Palworld server down gracefully.
+// Saves the world over RCON, then shuts down.
+func (h *PalworldHandler) OnStop(ctx context.Context, server Server) error {
+	return saveAndExit(ctx, server, []string{"Save"}, "Shutdown 1 Server is shutting down")
 }
 
 func init() {
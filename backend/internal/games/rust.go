@@ -2,17 +2,45 @@ package games
 
 import (
 	"context"
+	"regexp"
+	"time"
+
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
 )
 
 // RustHandler handles Rust game server-specific operations.
 // Uses max-pfeiffer/rust-game-server image.
 type RustHandler struct{}
 
+// GetMetadata returns the Rust display information.
+func (h *RustHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "Rust",
+		Icon:        "construction",
+		DocsURL:     "https://github.com/max-pfeiffer/rust-game-server-docker",
+	}
+}
+
 // GetDefaultImage returns the default Rust game server image.
 func (h *RustHandler) GetDefaultImage() string {
 	return "maxpfeiffer/rust-game-server:latest"
 }
 
+// GetRequirements returns the minimum resources of a Rust server.
+func (h *RustHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 8192}
+}
+
+// GetReadinessProbe returns the readiness probe of a Rust server.
+// The server is ready once it logs "Server startup complete".
+func (h *RustHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{
+		Type:    ProbeLog,
+		Pattern: regexp.MustCompile(`Server startup complete`),
+		Timeout: 20 * time.Minute,
+	}
+}
+
 // GetDefaultPorts returns the default Rust port mappings.
 func (h *RustHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -22,38 +50,65 @@ func (h *RustHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the Rust ports.
+func (h *RustHandler) GetPortProtocols() map[string]string {
+	return map[string]string{
+		"game": "udp",
+	}
+}
+
 // GetDefaultEnv returns the default Rust environment variables.
 func (h *RustHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the Rust configuration schema.
+func (h *RustHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "SERVER_NAME", Type: FieldTypeString, Default: "Sabakan Rust Server", Description: "Server name"},
+		{Name: "SERVER_DESCRIPTION", Type: FieldTypeString, Default: "A Rust server managed by Sabakan", Description: "Server description"},
+		{Name: "SERVER_HOSTNAME", Type: FieldTypeString, Default: "0.0.0.0", Description: "Address the server listens on"},
+		{Name: "SERVER_SEED", Type: FieldTypeString, Default: "", Description: "Map seed; random when empty"},
+		{Name: "SERVER_WORLDSIZE", Type: FieldTypeInt, Default: "3000", Description: "Map size"},
+		{Name: "SERVER_MAXPLAYERS", Type: FieldTypeInt, Default: "50", Description: "Maximum number of players"},
+		{Name: "SERVER_IDENTITY", Type: FieldTypeString, Default: "default", Description: "Name of the save directory"},
+		{Name: "RCON_PASSWORD", Type: FieldTypeString, Default: "", Secret: true, Generated: true, Description: "WebRCON password"},
+		{Name: "RCON_WEB", Type: FieldTypeBool, Default: "1", Description: "Use WebRCON, required for console commands and graceful stop"},
+		{Name: "APP_PORT", Type: FieldTypeInt, Default: "28082", Description: "Rust+ companion app port"},
+	}
+}
+
+// GetDefaultVolumes returns the default Rust data volumes.
+func (h *RustHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"SERVER_NAME":        "Sabakan Rust Server",
-		"SERVER_DESCRIPTION": "A Rust server managed by Sabakan",
-		"SERVER_HOSTNAME":    "0.0.0.0",
-		"SERVER_SEED":        "",
-		"SERVER_WORLDSIZE":   "3000",
-		"SERVER_MAXPLAYERS":  "50",
-		"SERVER_IDENTITY":    "default",
-		"RCON_PASSWORD":      "changeme",
-		"RCON_WEB":           "1",
-		"APP_PORT":           "28082",
+		"server": "/srv/rust/server",
+	}
+}
+
+// GetRCONConfig returns the Rust RCON configuration.
+func (h *RustHandler) GetRCONConfig() RCONConfig {
+	return RCONConfig{
+		Protocol:    rcon.ProtocolWebRCON,
+		Port:        "rcon",
+		PasswordEnv: "RCON_PASSWORD",
 	}
 }
 
 // ValidateConfig validates Rust-specific configuration.
 func (h *RustHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement Rust-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when a Rust server is started.
-func (h *RustHandler) OnStart(_ context.Context, _ string) error {
+func (h *RustHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement Rust-specific startup logic
 	return nil
 }
 
-// OnStop is called when a Rust server is stopped.
-func (h *RustHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement Rust-specific shutdown logic (save world)
-	return nil
+// OnStop shuts a Rust server down gracefully.
+// Saves the world over WebRCON, then quits.
+func (h *RustHandler) OnStop(ctx context.Context, server Server) error {
+	return saveAndExit(ctx, server, []string{"server.save"}, "quit")
 }
 
 func init() {
@@ -2,17 +2,44 @@ package games
 
 import (
 	"context"
+	"regexp"
+	"time"
 )
 
 // SatisfactoryHandler handles Satisfactory-specific operations.
 // Uses wolveix/satisfactory-server image.
 type SatisfactoryHandler struct{}
 
+// GetMetadata returns the Satisfactory display information.
+func (h *SatisfactoryHandler) GetMetadata() Metadata {
+	return Metadata{
+		DisplayName: "Satisfactory",
+		Icon:        "factory",
+		DocsURL:     "https://github.com/wolveix/satisfactory-server",
+	}
+}
+
 // GetDefaultImage returns the default Satisfactory server image.
 func (h *SatisfactoryHandler) GetDefaultImage() string {
 	return "wolveix/satisfactory-server:latest"
 }
 
+// GetRequirements returns the minimum resources of a Satisfactory server.
+func (h *SatisfactoryHandler) GetRequirements() Requirements {
+	return Requirements{MemoryMB: 8192}
+}
+
+// GetReadinessProbe returns the readiness probe of a Satisfactory server.
+// The server is ready once its network driver logs that it listens on the
+// game port. The port itself is udp and cannot be probed.
+func (h *SatisfactoryHandler) GetReadinessProbe() ReadinessProbe {
+	return ReadinessProbe{
+		Type:    ProbeLog,
+		Pattern: regexp.MustCompile(`IpNetDriver listening on port [0-9]+`),
+		Timeout: 20 * time.Minute,
+	}
+}
+
 // GetDefaultPorts returns the default Satisfactory port mappings.
 func (h *SatisfactoryHandler) GetDefaultPorts() map[string]int {
 	return map[string]int{
@@ -22,40 +49,61 @@ func (h *SatisfactoryHandler) GetDefaultPorts() map[string]int {
 	}
 }
 
+// GetPortProtocols returns the protocols of the Satisfactory ports.
+func (h *SatisfactoryHandler) GetPortProtocols() map[string]string {
+	return map[string]string{
+		"game":   "udp",
+		"query":  "udp",
+		"beacon": "udp",
+	}
+}
+
 // GetDefaultEnv returns the default Satisfactory environment variables.
 func (h *SatisfactoryHandler) GetDefaultEnv() map[string]string {
+	return h.GetConfigSchema().Defaults()
+}
+
+// GetConfigSchema returns the Satisfactory configuration schema.
+func (h *SatisfactoryHandler) GetConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "MAXPLAYERS", Type: FieldTypeInt, Default: "4", Description: "Maximum number of players"},
+		{Name: "PGID", Type: FieldTypeInt, Default: "1000", Description: "Group ID the server runs as"},
+		{Name: "PUID", Type: FieldTypeInt, Default: "1000", Description: "User ID the server runs as"},
+		{Name: "ROOTLESS", Type: FieldTypeBool, Default: "false", Description: "Run the container as a non-root user"},
+		{Name: "STEAMBETA", Type: FieldTypeBool, Default: "false", Description: "Use the experimental branch"},
+		{Name: "AUTOPAUSE", Type: FieldTypeBool, Default: "true", Description: "Pause the game when no players are connected"},
+		{Name: "AUTOSAVEINTERVAL", Type: FieldTypeInt, Default: "300", Description: "Autosave interval in seconds"},
+		{Name: "AUTOSAVENUM", Type: FieldTypeInt, Default: "3", Description: "Number of autosaves to keep"},
+		{Name: "AUTOSAVEONDISCONNECT", Type: FieldTypeBool, Default: "true", Description: "Save when a player disconnects"},
+		{Name: "CRASHREPORT", Type: FieldTypeBool, Default: "true", Description: "Send crash reports"},
+		{Name: "DEBUG", Type: FieldTypeBool, Default: "false", Description: "Enable debug logging"},
+		{Name: "DISABLESEASONALEVENTS", Type: FieldTypeBool, Default: "false", Description: "Disable seasonal events"},
+		{Name: "NETWORKQUALITY", Type: FieldTypeEnum, Default: "3", Allowed: []string{"0", "1", "2", "3"}, Description: "Network quality from 0 (low) to 3 (ultra)"},
+	}
+}
+
+// GetDefaultVolumes returns the default Satisfactory data volumes.
+func (h *SatisfactoryHandler) GetDefaultVolumes() map[string]string {
 	return map[string]string{
-		"MAXPLAYERS":            "4",
-		"PGID":                  "1000",
-		"PUID":                  "1000",
-		"ROOTLESS":              "false",
-		"STEAMBETA":             "false",
-		"AUTOPAUSE":             "true",
-		"AUTOSAVEINTERVAL":      "300",
-		"AUTOSAVENUM":           "3",
-		"AUTOSAVEONDISCONNECT":  "true",
-		"CRASHREPORT":           "true",
-		"DEBUG":                 "false",
-		"DISABLESEASONALEVENTS": "false",
-		"NETWORKQUALITY":        "3",
+		"config": "/config",
 	}
 }
 
 // ValidateConfig validates Satisfactory-specific configuration.
 func (h *SatisfactoryHandler) ValidateConfig(config map[string]any) error {
-	// TODO: Implement Satisfactory-specific validation
-	return nil
+	return h.GetConfigSchema().Validate(config)
 }
 
 // OnStart is called when a Satisfactory server is started.
-func (h *SatisfactoryHandler) OnStart(_ context.Context, _ string) error {
+func (h *SatisfactoryHandler) OnStart(_ context.Context, _ Server) error {
 	// TODO: Implement Satisfactory-specific startup logic
 	return nil
 }
 
 // OnStop is called when a Satisfactory server is stopped.
-func (h *SatisfactoryHandler) OnStop(_ context.Context, _ string) error {
-	// TODO: Implement Satisfactory-specific shutdown logic (autosave)
+// Satisfactory has no remote console; the server shuts down cleanly on the
+// stop signal sent when its container is stopped.
+func (h *SatisfactoryHandler) OnStop(_ context.Context, _ Server) error {
 	return nil
 }
 
@@ -0,0 +1,149 @@
+package games
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldType is the type of a game config field.
+type FieldType string
+
+const (
+	// FieldTypeString accepts any value.
+	FieldTypeString FieldType = "string"
+	// FieldTypeInt accepts whole numbers.
+	FieldTypeInt FieldType = "int"
+	// FieldTypeBool accepts true or false.
+	FieldTypeBool FieldType = "bool"
+	// FieldTypeEnum accepts one of the field's allowed values.
+	FieldTypeEnum FieldType = "enum"
+	// FieldTypeMemory accepts a memory size such as 512M or 4G.
+	FieldTypeMemory FieldType = "memory"
+)
+
+// memoryPattern matches memory sizes with an optional unit suffix.
+var memoryPattern = regexp.MustCompile(`^[0-9]+[KkMmGg]?$`)
+
+// ConfigField describes a single environment variable a game understands.
+// Generated fields get a random value when a server is created without one.
+type ConfigField struct {
+	Name        string    `json:"name"`
+	Type        FieldType `json:"type"`
+	Allowed     []string  `json:"allowed,omitempty"`
+	Default     string    `json:"default"`
+	Secret      bool      `json:"secret,omitempty"`
+	Generated   bool      `json:"generated,omitempty"`
+	Description string    `json:"description"`
+}
+
+// ConfigSchema describes the configuration of a game.
+type ConfigSchema []ConfigField
+
+// Field returns the field with the given name.
+func (s ConfigSchema) Field(name string) (ConfigField, bool) {
+	for _, field := range s {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return ConfigField{}, false
+}
+
+// Defaults returns the default value of every field.
+func (s ConfigSchema) Defaults() map[string]string {
+	defaults := make(map[string]string, len(s))
+	for _, field := range s {
+		defaults[field.Name] = field.Default
+	}
+	return defaults
+}
+
+// Generate returns random values for the generated fields that config leaves
+// empty, so that no server runs with a well-known password.
+func (s ConfigSchema) Generate(config map[string]any) (map[string]string, error) {
+	generated := make(map[string]string)
+	for _, field := range s {
+		if !field.Generated {
+			continue
+		}
+		if value, ok := config[field.Name]; ok && fmt.Sprint(value) != "" {
+			continue
+		}
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", field.Name, err)
+		}
+		generated[field.Name] = hex.EncodeToString(b)
+	}
+	return generated, nil
+}
+
+// Validate checks config against the schema. Keys that are not part of the
+// schema are passed through to the container unchecked.
+func (s ConfigSchema) Validate(config map[string]any) error {
+	var errs ValidationErrors
+	for key, value := range config {
+		field, ok := s.Field(key)
+		if !ok {
+			continue
+		}
+		if err := field.validate(fmt.Sprint(value)); err != nil {
+			errs = append(errs, FieldError{Field: key, Message: err.Error()})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// validate checks a single value against the field.
+func (f ConfigField) validate(value string) error {
+	switch f.Type {
+	case FieldTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+	case FieldTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+	case FieldTypeEnum:
+		for _, allowed := range f.Allowed {
+			if strings.EqualFold(value, allowed) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(f.Allowed, ", "))
+	case FieldTypeMemory:
+		if !memoryPattern.MatchString(value) {
+			return fmt.Errorf("must be a memory size such as 512M or 4G")
+		}
+	}
+	return nil
+}
+
+// FieldError is a validation error of a single config field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by ValidateConfig when fields are invalid.
+type ValidationErrors []FieldError
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
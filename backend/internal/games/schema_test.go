@@ -0,0 +1,103 @@
+package games
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema_Validate(t *testing.T) {
+	schema := ConfigSchema{
+		{Name: "MEMORY", Type: FieldTypeMemory, Default: "2G"},
+		{Name: "MAX_PLAYERS", Type: FieldTypeInt, Default: "8"},
+		{Name: "PVP", Type: FieldTypeBool, Default: "true"},
+		{Name: "MODE", Type: FieldTypeEnum, Default: "YES", Allowed: []string{"YES", "NO"}},
+		{Name: "NAME", Type: FieldTypeString, Default: ""},
+	}
+
+	t.Run("should accept valid values", func(t *testing.T) {
+		err := schema.Validate(map[string]any{
+			"MEMORY":      "512M",
+			"MAX_PLAYERS": 16,
+			"PVP":         "false",
+			"MODE":        "no",
+			"NAME":        "anything goes",
+			"UNKNOWN":     "passed through",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should report every invalid field", func(t *testing.T) {
+		err := schema.Validate(map[string]any{
+			"MEMORY":      "lots",
+			"MAX_PLAYERS": "eight",
+			"PVP":         "maybe",
+			"MODE":        "SOMETIMES",
+		})
+
+		var fieldErrs ValidationErrors
+		require.ErrorAs(t, err, &fieldErrs)
+		require.Len(t, fieldErrs, 4)
+		assert.Equal(t, "MAX_PLAYERS", fieldErrs[0].Field)
+		assert.Equal(t, "MEMORY", fieldErrs[1].Field)
+		assert.Equal(t, "MODE", fieldErrs[2].Field)
+		assert.Equal(t, "must be one of YES, NO", fieldErrs[2].Message)
+		assert.Equal(t, "PVP", fieldErrs[3].Field)
+	})
+}
+
+func TestConfigSchema_Defaults(t *testing.T) {
+	t.Run("should match each game's default env", func(t *testing.T) {
+		for name, handler := range Registry {
+			schema := handler.GetConfigSchema()
+			assert.Equal(t, schema.Defaults(), handler.GetDefaultEnv(), name)
+			assert.NoError(t, handler.ValidateConfig(toConfig(handler.GetDefaultEnv())), name)
+		}
+	})
+}
+
+func TestConfigSchema_Generate(t *testing.T) {
+	schema := ConfigSchema{
+		{Name: "SERVER_PASSWORD", Type: FieldTypeString, Secret: true},
+		{Name: "ADMIN_PASSWORD", Type: FieldTypeString, Secret: true, Generated: true},
+	}
+
+	t.Run("should generate a random value for missing or empty fields", func(t *testing.T) {
+		first, err := schema.Generate(map[string]any{})
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+		assert.Len(t, first["ADMIN_PASSWORD"], 32)
+
+		second, err := schema.Generate(map[string]any{"ADMIN_PASSWORD": ""})
+		require.NoError(t, err)
+		assert.NotEqual(t, first["ADMIN_PASSWORD"], second["ADMIN_PASSWORD"])
+	})
+
+	t.Run("should keep given values", func(t *testing.T) {
+		generated, err := schema.Generate(map[string]any{"ADMIN_PASSWORD": "hunter2"})
+		require.NoError(t, err)
+		assert.Empty(t, generated)
+	})
+
+	t.Run("should not ship a fixed console password", func(t *testing.T) {
+		for name, handler := range Registry {
+			rconHandler, ok := handler.(RCONHandler)
+			if !ok || rconHandler.GetRCONConfig().PasswordEnv == "" {
+				continue
+			}
+			field, ok := handler.GetConfigSchema().Field(rconHandler.GetRCONConfig().PasswordEnv)
+			require.True(t, ok, name)
+			assert.Empty(t, field.Default, name)
+			assert.True(t, field.Generated, name)
+		}
+	})
+}
+
+func toConfig(env map[string]string) map[string]any {
+	config := make(map[string]any, len(env))
+	for k, v := range env {
+		config[k] = v
+	}
+	return config
+}
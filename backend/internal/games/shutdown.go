@@ -0,0 +1,30 @@
+package games
+
+import (
+	"context"
+	"fmt"
+)
+
+// saveAndExit runs the save commands over the server's remote console, sends
+// the exit command and waits for the server to exit. The console connection
+// usually drops while the exit command runs, so its result is ignored.
+func saveAndExit(ctx context.Context, server Server, saveCommands []string, exitCommand string) error {
+	client, err := server.RCON(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to console: %w", err)
+	}
+	defer client.Close()
+
+	for _, command := range saveCommands {
+		if _, err := client.Execute(ctx, command); err != nil {
+			return fmt.Errorf("failed to run %q: %w", command, err)
+		}
+	}
+
+	_, _ = client.Execute(ctx, exitCommand)
+
+	if err := server.Wait(ctx); err != nil {
+		return fmt.Errorf("server did not exit: %w", err)
+	}
+	return nil
+}
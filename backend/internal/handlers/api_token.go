@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateAPITokenRequest represents the request body for creating an API token.
+type CreateAPITokenRequest struct {
+	Name string `json:"name"`
+	// Scopes are the permissions of the token, such as "game_server:read".
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays is how long the token is valid. Zero creates a token that never expires.
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+// APITokenResponse represents an API token in responses.
+type APITokenResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"tokenPrefix"`
+	Scopes      []string   `json:"scopes"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	// Token is the secret itself, only returned when the token is created.
+	Token string `json:"token,omitempty"`
+}
+
+// APITokenHandler handles personal API token endpoints.
+type APITokenHandler struct {
+	db *gorm.DB
+}
+
+// NewAPITokenHandler creates a new API token handler.
+func NewAPITokenHandler(db *gorm.DB) *APITokenHandler {
+	return &APITokenHandler{db: db}
+}
+
+// newAPITokenResponse converts an API token to its response.
+func newAPITokenResponse(token *models.APIToken) APITokenResponse {
+	scopes := token.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return APITokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      scopes,
+		LastUsedAt:  token.LastUsedAt,
+		ExpiresAt:   token.ExpiresAt,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+// forbidAPIToken refuses a request authenticated with an API token, so that a
+// leaked token cannot be used to mint or revoke tokens.
+func forbidAPIToken(c echo.Context) error {
+	return c.JSON(http.StatusForbidden, ErrorResponse{
+		Error:   "forbidden",
+		Message: "API tokens cannot be managed with an API token",
+	})
+}
+
+// List handles GET /api/tokens.
+func (h *APITokenHandler) List(c echo.Context) error {
+	if middleware.GetAPITokenID(c) != 0 {
+		return forbidAPIToken(c)
+	}
+
+	var tokens []models.APIToken
+	if err := h.db.Where("user_id = ?", middleware.GetUserID(c)).Order("id").Find(&tokens).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to list API tokens",
+		})
+	}
+
+	response := make([]APITokenResponse, 0, len(tokens))
+	for i := range tokens {
+		response = append(response, newAPITokenResponse(&tokens[i]))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Create handles POST /api/tokens.
+// The token is returned only in this response; only its hash is stored.
+func (h *APITokenHandler) Create(c echo.Context) error {
+	if middleware.GetAPITokenID(c) != 0 {
+		return forbidAPIToken(c)
+	}
+
+	var req CreateAPITokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Name is required",
+		})
+	}
+	if len(req.Scopes) == 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "At least one scope is required",
+		})
+	}
+	if req.ExpiresInDays < 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "expiresInDays must not be negative",
+		})
+	}
+
+	userID := middleware.GetUserID(c)
+	allowed, err := h.grantableScopes(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to check permissions",
+		})
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	var invalid []string
+	for _, scope := range req.Scopes {
+		switch {
+		case !slices.Contains(allowed, scope):
+			invalid = append(invalid, scope)
+		case !slices.Contains(scopes, scope):
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(invalid) > 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Scopes must be permissions of your role",
+			Details: map[string][]string{"scopes": invalid},
+		})
+	}
+
+	secret, hash, err := auth.GenerateAPIToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate API token",
+		})
+	}
+
+	token := models.APIToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenHash:   hash,
+		TokenPrefix: secret[:auth.APITokenDisplayLength],
+	}
+	if err := token.SetScopes(scopes); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to store scopes",
+		})
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := h.db.Create(&token).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to create API token",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditLogTargetAPIToken, token.ID, models.AuditLogActionCreate, map[string]any{
+		"name":   token.Name,
+		"scopes": scopes,
+	})
+
+	response := newAPITokenResponse(&token)
+	response.Token = secret
+	return c.JSON(http.StatusCreated, response)
+}
+
+// Delete handles DELETE /api/tokens/:id.
+// Revoked tokens stop working immediately.
+func (h *APITokenHandler) Delete(c echo.Context) error {
+	if middleware.GetAPITokenID(c) != 0 {
+		return forbidAPIToken(c)
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid API token ID",
+		})
+	}
+
+	var token models.APIToken
+	if err := h.db.Where("user_id = ?", middleware.GetUserID(c)).First(&token, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "API token not found",
+		})
+	}
+
+	if err := h.db.Delete(&token).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to revoke API token",
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditLogTargetAPIToken, token.ID, models.AuditLogActionDelete, map[string]any{
+		"name": token.Name,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// grantableScopes returns the scopes a user may give their tokens: the
+// permissions of their role, or every permission for administrators.
+func (h *APITokenHandler) grantableScopes(userID uint) ([]string, error) {
+	var user models.User
+	if err := h.db.Preload("Role.Permissions").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	permissions := user.Role.Permissions
+	for _, perm := range permissions {
+		if perm.Resource == "system" && perm.Action == "admin" {
+			permissions = nil
+			if err := h.db.Find(&permissions).Error; err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	scopes := make([]string, 0, len(permissions))
+	for _, perm := range permissions {
+		scopes = append(scopes, auth.Scope(perm.Resource, perm.Action))
+	}
+	return scopes, nil
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// setupAPITokenTestDB creates a database with an admin and a user with the
+// game_server:read and game_server:start permissions.
+func setupAPITokenTestDB(t *testing.T) (db *gorm.DB, admin, user models.User) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Role{}, &models.Permission{}, &models.User{}, &models.APIToken{}, &models.AuditLog{}))
+
+	for _, perm := range models.DefaultPermissions() {
+		require.NoError(t, db.Create(&perm).Error)
+	}
+	grant := func(role *models.Role, scopes ...[2]string) {
+		require.NoError(t, db.Create(role).Error)
+		for _, scope := range scopes {
+			var perm models.Permission
+			require.NoError(t, db.Where("resource = ? AND action = ?", scope[0], scope[1]).First(&perm).Error)
+			require.NoError(t, db.Model(role).Association("Permissions").Append(&perm))
+		}
+	}
+	adminRole := models.Role{Name: "admin", DisplayName: "Administrator", Priority: 100}
+	grant(&adminRole, [2]string{"system", "admin"})
+	userRole := models.Role{Name: "user", DisplayName: "User", Priority: 10}
+	grant(&userRole, [2]string{"game_server", "read"}, [2]string{"game_server", "start"})
+
+	admin = models.User{Username: "admin", RoleID: adminRole.ID, IsActive: true}
+	user = models.User{Username: "ci", RoleID: userRole.ID, IsActive: true}
+	require.NoError(t, db.Create(&admin).Error)
+	require.NoError(t, db.Create(&user).Error)
+	return db, admin, user
+}
+
+// apiTokenRequest creates a request context for a user of the API token endpoints.
+func apiTokenRequest(method, target, body string, userID uint) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(middleware.ContextKeyUserID, userID)
+	return c, rec
+}
+
+func TestAPITokenHandler_Create(t *testing.T) {
+	db, admin, user := setupAPITokenTestDB(t)
+	handler := NewAPITokenHandler(db)
+
+	t.Run("should return the token once and store only its hash", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"ci","scopes":["game_server:read","game_server:start","game_server:read"]}`, user.ID)
+
+		require.NoError(t, handler.Create(c))
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var response APITokenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.True(t, auth.IsAPIToken(response.Token))
+		assert.Equal(t, response.Token[:auth.APITokenDisplayLength], response.TokenPrefix)
+		assert.Equal(t, []string{"game_server:read", "game_server:start"}, response.Scopes)
+		assert.Nil(t, response.ExpiresAt)
+
+		var stored models.APIToken
+		require.NoError(t, db.First(&stored, response.ID).Error)
+		assert.Equal(t, auth.HashAPIToken(response.Token), stored.TokenHash)
+		assert.NotContains(t, stored.TokenHash, response.Token)
+
+		var audit models.AuditLog
+		require.NoError(t, db.Where("target_type = ?", models.AuditLogTargetAPIToken).First(&audit).Error)
+		assert.Equal(t, models.AuditLogActionCreate, audit.Action)
+	})
+
+	t.Run("should set an expiry date when requested", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"bot","scopes":["game_server:read"],"expiresInDays":30}`, user.ID)
+
+		require.NoError(t, handler.Create(c))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"expiresAt"`)
+	})
+
+	t.Run("should reject scopes outside the role's permissions", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"ci","scopes":["game_server:read","game_server:delete"]}`, user.ID)
+
+		require.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "game_server:delete")
+	})
+
+	t.Run("should let administrators grant any permission", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"ops","scopes":["game_server:delete","mod:create"]}`, admin.ID)
+
+		require.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("should reject unknown scopes for administrators", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"ops","scopes":["everything"]}`, admin.ID)
+
+		require.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should require a name and scopes", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"scopes":["game_server:read"]}`, user.ID)
+		require.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		c, rec = apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"ci"}`, user.ID)
+		require.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should refuse requests authenticated with an API token", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodPost, "/api/tokens", `{"name":"ci","scopes":["game_server:read"]}`, user.ID)
+		c.Set(middleware.ContextKeyAPITokenID, uint(1))
+
+		require.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestAPITokenHandler_ListDelete(t *testing.T) {
+	db, admin, user := setupAPITokenTestDB(t)
+	handler := NewAPITokenHandler(db)
+
+	own := models.APIToken{UserID: user.ID, Name: "ci", TokenHash: "hash-ci", TokenPrefix: "sbk_ci00", Scopes: `["game_server:read"]`}
+	other := models.APIToken{UserID: admin.ID, Name: "ops", TokenHash: "hash-ops", TokenPrefix: "sbk_ops0", Scopes: `["system:admin"]`}
+	require.NoError(t, db.Create(&own).Error)
+	require.NoError(t, db.Create(&other).Error)
+
+	t.Run("should list only the user's own tokens without secrets", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodGet, "/api/tokens", "", user.ID)
+
+		require.NoError(t, handler.List(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response []APITokenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.Len(t, response, 1)
+		assert.Equal(t, "ci", response[0].Name)
+		assert.Equal(t, []string{"game_server:read"}, response[0].Scopes)
+		assert.Empty(t, response[0].Token)
+		assert.NotContains(t, rec.Body.String(), "hash-ci")
+	})
+
+	t.Run("should not revoke tokens of other users", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodDelete, "/api/tokens/", "", user.ID)
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.FormatUint(uint64(other.ID), 10))
+
+		require.NoError(t, handler.Delete(c))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("should revoke the user's own token", func(t *testing.T) {
+		c, rec := apiTokenRequest(http.MethodDelete, "/api/tokens/", "", user.ID)
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.FormatUint(uint64(own.ID), 10))
+
+		require.NoError(t, handler.Delete(c))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		var count int64
+		db.Model(&models.APIToken{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Zero(t, count)
+	})
+}
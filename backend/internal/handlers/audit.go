@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// recordAudit writes an audit log entry for the current request.
+// Failures are ignored so that auditing never blocks the audited action.
+func recordAudit(db *gorm.DB, c echo.Context, targetType models.AuditLogTargetType, targetID uint, action models.AuditLogAction, details map[string]any) {
+	entry := models.AuditLog{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Action:     action,
+		IPAddress:  c.RealIP(),
+	}
+
+	if userID := middleware.GetUserID(c); userID != 0 {
+		entry.UserID = &userID
+	}
+
+	if len(details) > 0 {
+		if data, err := json.Marshal(details); err == nil {
+			entry.DetailsJSON = string(data)
+		}
+	}
+
+	_ = db.Create(&entry).Error
+}
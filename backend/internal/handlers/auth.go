@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
 	"github.com/sweetfish329/sabakan/backend/internal/models"
 	"github.com/sweetfish329/sabakan/backend/internal/redis"
 	"gorm.io/gorm"
@@ -42,6 +46,7 @@ type AuthResponse struct {
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
+	Details any    `json:"details,omitempty"`
 }
 
 // AuthHandler handles authentication endpoints.
@@ -49,6 +54,9 @@ type AuthHandler struct {
 	db           *gorm.DB
 	jwtManager   *auth.JWTManager
 	sessionStore redis.SessionStore
+	limiter      loginLimiter
+	// twoFactorMinPriority is the role priority from which 2FA is required, 0 if never.
+	twoFactorMinPriority int
 }
 
 // NewAuthHandler creates a new authentication handler.
@@ -57,6 +65,7 @@ func NewAuthHandler(db *gorm.DB, jwtManager *auth.JWTManager, sessionStore redis
 		db:           db,
 		jwtManager:   jwtManager,
 		sessionStore: sessionStore,
+		limiter:      loginLimiter{store: sessionStore},
 	}
 }
 
@@ -157,9 +166,15 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		})
 	}
 
+	// Refuse attempts while the username or IP is locked out
+	if locked, err := h.loginLocked(c, 0, req.Username); locked {
+		return err
+	}
+
 	// Find user
 	var user models.User
-	if err := h.db.Where("username = ?", req.Username).First(&user).Error; err != nil {
+	if err := h.db.Preload("Role").Where("username = ?", req.Username).First(&user).Error; err != nil {
+		h.loginFailed(c, 0, req.Username, loginFailureInvalidCredentials)
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Invalid credentials",
@@ -168,6 +183,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 
 	// Check if user is active
 	if !user.IsActive {
+		h.loginFailed(c, user.ID, req.Username, loginFailureAccountDisabled)
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Account is disabled",
@@ -176,29 +192,41 @@ func (h *AuthHandler) Login(c echo.Context) error {
 
 	// Verify password
 	if !auth.VerifyPassword(req.Password, user.PasswordHash) {
+		h.loginFailed(c, user.ID, req.Username, loginFailureInvalidCredentials)
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Invalid credentials",
 		})
 	}
 
-	// Generate tokens
-	accessToken, jti, err := h.jwtManager.GenerateAccessToken(user.ID, user.Username)
+	// A second factor is needed before any token is issued
+	if purpose, required := twoFactorPurpose(&user, h.twoFactorMinPriority); required {
+		return h.challenge(c, &user, purpose)
+	}
+
+	response, err := h.issueTokens(c, &user)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_error",
-			Message: "Failed to generate access token",
+			Message: "Failed to generate tokens",
 		})
 	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// issueTokens issues the access and refresh tokens of a new login.
+func (h *AuthHandler) issueTokens(c echo.Context, user *models.User) (*AuthResponse, error) {
+	// Generate tokens
+	accessToken, jti, err := h.jwtManager.GenerateAccessToken(user.ID, user.Username)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token: %w", err)
+	}
 
 	// Generate refresh token with family ID
 	familyID := uuid.New().String()
-	refreshToken, err := h.jwtManager.GenerateRefreshToken(user.ID, familyID)
+	refreshToken, err := issueRefreshToken(h.db, h.jwtManager, c, user.ID, familyID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to generate refresh token",
-		})
+		return nil, fmt.Errorf("generate refresh token: %w", err)
 	}
 
 	// Store session in Redis if available
@@ -211,12 +239,17 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		_ = h.sessionStore.StoreSession(c.Request().Context(), jti, sessionData, 15*time.Minute)
 	}
 
-	return c.JSON(http.StatusOK, AuthResponse{
+	// Failed attempts before a successful login no longer count towards a lockout
+	if err := h.limiter.succeed(c.Request().Context(), user.Username); err != nil {
+		slog.Warn("Failed to reset failed logins", "username", user.Username, "error", err)
+	}
+
+	return &AuthResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		ExpiresIn:    900, // 15 minutes in seconds
 		TokenType:    "Bearer",
-	})
+	}, nil
 }
 
 // Refresh handles token refresh.
@@ -246,6 +279,28 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 		})
 	}
 
+	// Each refresh token can be exchanged only once
+	if _, err := rotateRefreshToken(h.db, claims, req.RefreshToken); err != nil {
+		switch {
+		case errors.Is(err, errRefreshTokenReused):
+			slog.Warn("Refresh token reused, revoking its login", "user", claims.UserID, "ip", c.RealIP())
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Message: "Refresh token has already been used; please log in again",
+			})
+		case errors.Is(err, errRefreshTokenUnknown):
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Message: "Invalid or expired refresh token",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to rotate refresh token",
+			})
+		}
+	}
+
 	// Get user
 	var user models.User
 	if err := h.db.First(&user, claims.UserID).Error; err != nil {
@@ -272,6 +327,14 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 		})
 	}
 
+	refreshToken, err := issueRefreshToken(h.db, h.jwtManager, c, user.ID, claims.FamilyID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate refresh token",
+		})
+	}
+
 	// Store new session in Redis if available
 	if h.sessionStore != nil {
 		sessionData := &redis.SessionData{
@@ -283,14 +346,31 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, AuthResponse{
-		AccessToken: accessToken,
-		ExpiresIn:   900,
-		TokenType:   "Bearer",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    900,
+		TokenType:    "Bearer",
 	})
 }
 
 // Logout handles user logout.
+// The refresh token of the login may be passed in the body to revoke it and
+// every token rotated from the same login.
 func (h *AuthHandler) Logout(c echo.Context) error {
+	var req RefreshRequest
+	_ = c.Bind(&req)
+	if req.RefreshToken != "" {
+		claims, err := h.jwtManager.ValidateRefreshToken(req.RefreshToken)
+		if err == nil && claims.UserID == middleware.GetUserID(c) {
+			if err := revokeRefreshFamily(h.db, claims.FamilyID); err != nil {
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error:   "internal_error",
+					Message: "Failed to revoke refresh token",
+				})
+			}
+		}
+	}
+
 	// Revoke session in Redis if available
 	if h.sessionStore != nil {
 		// Get JTI from context (set by auth middleware)
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
 	"github.com/sweetfish329/sabakan/backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -29,6 +30,8 @@ func setupAuthTestDB(t *testing.T) *gorm.DB {
 		&models.Permission{},
 		&models.User{},
 		&models.RefreshToken{},
+		&models.RecoveryCode{},
+		&models.AuditLog{},
 	)
 	require.NoError(t, err)
 
@@ -195,6 +198,8 @@ func TestAuthHandler_Refresh_Integration(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEmpty(t, response.AccessToken)
 		assert.NotEqual(t, loginResponse.AccessToken, response.AccessToken)
+		assert.NotEmpty(t, response.RefreshToken)
+		assert.NotEqual(t, loginResponse.RefreshToken, response.RefreshToken)
 	})
 
 	t.Run("should reject invalid refresh token", func(t *testing.T) {
@@ -210,3 +215,134 @@ func TestAuthHandler_Refresh_Integration(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	})
 }
+
+// loginForTest registers and logs in a user and returns the tokens.
+func loginForTest(t *testing.T, handler *AuthHandler, username string) AuthResponse {
+	t.Helper()
+	e := echo.New()
+
+	registerBody := `{"username":"` + username + `","password":"testPassword123!"}`
+	registerReq := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(registerBody))
+	registerReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	require.NoError(t, handler.Register(e.NewContext(registerReq, httptest.NewRecorder())))
+
+	loginBody := `{"username":"` + username + `","password":"testPassword123!"}`
+	loginReq := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(loginBody))
+	loginReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	loginRec := httptest.NewRecorder()
+	require.NoError(t, handler.Login(e.NewContext(loginReq, loginRec)))
+	require.Equal(t, http.StatusOK, loginRec.Code)
+
+	var response AuthResponse
+	require.NoError(t, json.Unmarshal(loginRec.Body.Bytes(), &response))
+	return response
+}
+
+// refreshForTest exchanges a refresh token and returns the response.
+func refreshForTest(t *testing.T, handler *AuthHandler, refreshToken string) (*httptest.ResponseRecorder, AuthResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.Refresh(echo.New().NewContext(req, rec)))
+
+	var response AuthResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	}
+	return rec, response
+}
+
+func TestAuthHandler_Refresh_Rotation(t *testing.T) {
+	db := setupAuthTestDB(t)
+	jwtManager := auth.NewJWTManager(testJWTSecret, 15*time.Minute, 7*24*time.Hour)
+	handler := NewAuthHandler(db, jwtManager, nil)
+
+	t.Run("should store only the hash of refresh tokens", func(t *testing.T) {
+		tokens := loginForTest(t, handler, "hashed")
+
+		var stored models.RefreshToken
+		require.NoError(t, db.Where("token_hash = ?", auth.HashToken(tokens.RefreshToken)).First(&stored).Error)
+		assert.False(t, stored.IsRevoked())
+		assert.True(t, stored.ExpiresAt.After(time.Now().Add(6*24*time.Hour)))
+	})
+
+	t.Run("should revoke the old refresh token when rotating", func(t *testing.T) {
+		tokens := loginForTest(t, handler, "rotate")
+
+		rec, rotated := refreshForTest(t, handler, tokens.RefreshToken)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var old, current models.RefreshToken
+		require.NoError(t, db.Where("token_hash = ?", auth.HashToken(tokens.RefreshToken)).First(&old).Error)
+		require.NoError(t, db.Where("token_hash = ?", auth.HashToken(rotated.RefreshToken)).First(&current).Error)
+		assert.True(t, old.IsRevoked())
+		assert.False(t, current.IsRevoked())
+		assert.Equal(t, old.FamilyID, current.FamilyID)
+
+		rec, _ = refreshForTest(t, handler, rotated.RefreshToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("should revoke the whole family when a used token is replayed", func(t *testing.T) {
+		tokens := loginForTest(t, handler, "replay")
+
+		rec, rotated := refreshForTest(t, handler, tokens.RefreshToken)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec, _ = refreshForTest(t, handler, tokens.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "already been used")
+
+		rec, _ = refreshForTest(t, handler, rotated.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should reject signed refresh tokens that were never issued", func(t *testing.T) {
+		token, err := jwtManager.GenerateRefreshToken(1, "unknown-family")
+		require.NoError(t, err)
+
+		rec, _ := refreshForTest(t, handler, token)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthHandler_Logout_Integration(t *testing.T) {
+	db := setupAuthTestDB(t)
+	jwtManager := auth.NewJWTManager(testJWTSecret, 15*time.Minute, 7*24*time.Hour)
+	handler := NewAuthHandler(db, jwtManager, nil)
+
+	t.Run("should revoke the refresh token family of the login", func(t *testing.T) {
+		tokens := loginForTest(t, handler, "logout")
+		rec, rotated := refreshForTest(t, handler, tokens.RefreshToken)
+		require.Equal(t, http.StatusOK, rec.Code)
+		claims, err := jwtManager.ValidateAccessToken(rotated.AccessToken)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", strings.NewReader(`{"refresh_token":"`+rotated.RefreshToken+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		logoutRec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, logoutRec)
+		c.Set(middleware.ContextKeyUserID, claims.UserID)
+
+		require.NoError(t, handler.Logout(c))
+		assert.Equal(t, http.StatusOK, logoutRec.Code)
+
+		rec, _ = refreshForTest(t, handler, rotated.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should not revoke refresh tokens of other users", func(t *testing.T) {
+		tokens := loginForTest(t, handler, "victim")
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", strings.NewReader(`{"refresh_token":"`+tokens.RefreshToken+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.Set(middleware.ContextKeyUserID, uint(999))
+
+		require.NoError(t, handler.Logout(c))
+
+		rec, _ := refreshForTest(t, handler, tokens.RefreshToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// Console handles GET /api/game-servers/:slug/console.
+// The connection is upgraded to a WebSocket attached to the server's main
+// process. If the command query parameter is set, that command is executed
+// in the container instead. Text messages from the client are written to the
+// process's standard input as lines; output is sent back as JSON log entries.
+func (h *GameServerHandler) Console(c echo.Context) error {
+	server, err := h.findManagedServer(c)
+	if server == nil {
+		return err
+	}
+
+	if !server.Status.IsRunning() {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Game server is not running",
+		})
+	}
+
+	ctx := c.Request().Context()
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	var session *container.Session
+	if command := strings.Fields(c.QueryParam("command")); len(command) > 0 {
+		session, err = runtime.ExecAttach(ctx, server.ContainerID, command)
+	} else {
+		session, err = runtime.Attach(ctx, server.ContainerID)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+	defer session.Close()
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.checkOrigin}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already written an error response.
+		return nil
+	}
+	defer ws.Close()
+
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionConsole, map[string]any{
+		"command": c.QueryParam("command"),
+	})
+
+	// Forward process output to the client
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = session.ReadOutput(func(entry models.ContainerLogEntry) error {
+			return ws.WriteJSON(entry)
+		})
+		_ = ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "process output closed"))
+	}()
+
+	// Forward client input to the process
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+			msg = append(msg, '\n')
+		}
+		if _, err := session.Write(msg); err != nil {
+			break
+		}
+	}
+
+	session.Close()
+	<-done
+	return nil
+}
+
+// checkOrigin reports whether a console connection may be opened from the
+// request's origin. Requests without an Origin header do not come from a
+// browser and are allowed; browsers must connect from the API's own origin or
+// one of the allowed frontend origins.
+func (h *GameServerHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.allowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameServerHandler_CheckOrigin(t *testing.T) {
+	h := NewGameServerHandler(nil, nil)
+	h.SetAllowedOrigins([]string{"http://localhost:4200", "https://sabakan.example.com/"})
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"should allow requests without an origin", "", true},
+		{"should allow the API's own origin", "http://api.example.com:1323", true},
+		{"should allow a configured origin", "http://localhost:4200", true},
+		{"should ignore a trailing slash in configured origins", "https://sabakan.example.com", true},
+		{"should reject other origins", "https://evil.example.com", false},
+		{"should reject a configured host with another scheme", "http://sabakan.example.com", false},
+		{"should reject a configured host with another port", "http://localhost:8080", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://api.example.com:1323/api/game-servers/mc/console", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			assert.Equal(t, tt.allowed, h.checkOrigin(req))
+		})
+	}
+}
@@ -2,32 +2,67 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
 )
 
 // ContainerHandler handles container-related HTTP requests.
+// Containers are addressed on the node given by the node query parameter,
+// the local node by default.
 type ContainerHandler struct {
-	service *container.Service
+	nodes *container.Pool
 }
 
 // NewContainerHandler creates a new container handler.
-func NewContainerHandler(service *container.Service) *ContainerHandler {
+func NewContainerHandler(nodes *container.Pool) *ContainerHandler {
 	return &ContainerHandler{
-		service: service,
+		nodes: nodes,
 	}
 }
 
 // List handles GET /api/containers.
+// Containers of all nodes are listed with their node name. Unreachable nodes
+// are skipped unless no node can be reached.
 func (h *ContainerHandler) List(c echo.Context) error {
-	containers, err := h.service.List(c.Request().Context())
+	result := []models.Container{}
+	var lastErr error
+	reached := false
+	for _, member := range h.nodes.Members() {
+		containers, err := member.Runtime.List(c.Request().Context())
+		if err != nil {
+			slog.Warn("Failed to list containers", "node", member.Name, "error", err)
+			lastErr = err
+			continue
+		}
+		reached = true
+		for _, ctr := range containers {
+			ctr.Node = member.Name
+			result = append(result, ctr)
+		}
+	}
+	if !reached && lastErr != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, lastErr.Error())
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// nodeRuntime returns the runtime of the node selected with the node query
+// parameter, the local node by default.
+func nodeRuntime(c echo.Context, nodes *container.Pool) (container.Runtime, error) {
+	member, err := nodes.Lookup(c.QueryParam("node"))
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return nil, echo.NewHTTPError(http.StatusNotFound, err.Error())
 	}
-	return c.JSON(http.StatusOK, containers)
+	return member.Runtime, nil
 }
 
 // Get handles GET /api/containers/:id.
@@ -36,12 +71,33 @@ func (h *ContainerHandler) Get(c echo.Context) error {
 	if id == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
 	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
 
-	container, err := h.service.Get(c.Request().Context(), id)
+	ctr, err := runtime.Get(c.Request().Context(), id)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, err.Error())
 	}
-	return c.JSON(http.StatusOK, container)
+	maskSecretEnv(ctr.Env)
+	return c.JSON(http.StatusOK, ctr)
+}
+
+// secretEnvMarkers are substrings of environment variable names whose values are hidden.
+var secretEnvMarkers = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY"}
+
+// maskSecretEnv replaces the values of credential-like environment variables in place.
+func maskSecretEnv(env map[string]string) {
+	for key := range env {
+		upper := strings.ToUpper(key)
+		for _, marker := range secretEnvMarkers {
+			if strings.Contains(upper, marker) {
+				env[key] = "********"
+				break
+			}
+		}
+	}
 }
 
 // Start handles POST /api/containers/:id/start.
@@ -50,8 +106,12 @@ func (h *ContainerHandler) Start(c echo.Context) error {
 	if id == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
 	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
 
-	if err := h.service.Start(c.Request().Context(), id); err != nil {
+	if err := runtime.Start(c.Request().Context(), id); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -63,11 +123,28 @@ type StopRequest struct {
 }
 
 // Stop handles POST /api/containers/:id/stop.
+// Containers of game servers are rejected with 409 Conflict, since they are
+// stopped through POST /api/game-servers/:slug/stop.
 func (h *ContainerHandler) Stop(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
 	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	// Game server containers must be shut down through their game server, so
+	// that the game can save before the container is stopped
+	ctr, err := runtime.Get(c.Request().Context(), id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if ctr.Labels["sabakan.managed"] == "true" {
+		return echo.NewHTTPError(http.StatusConflict,
+			fmt.Sprintf("container is managed by game server %q, stop it with POST /api/game-servers/%s/stop", ctr.Labels["sabakan.slug"], ctr.Labels["sabakan.slug"]))
+	}
 
 	// Parse optional timeout from query or body
 	timeout := uint(10) // Default 10 seconds
@@ -77,7 +154,7 @@ func (h *ContainerHandler) Stop(c echo.Context) error {
 		}
 	}
 
-	if err := h.service.Stop(c.Request().Context(), id, timeout); err != nil {
+	if err := runtime.Stop(c.Request().Context(), id, timeout); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -89,18 +166,122 @@ func (h *ContainerHandler) Logs(c echo.Context) error {
 	if id == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
 	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	logs, err := runtime.Logs(c.Request().Context(), id, logLines(c))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, logs)
+}
+
+// StreamLogs handles GET /api/containers/:id/logs/stream.
+// Log entries are pushed as Server-Sent Events until the client disconnects.
+func (h *ContainerHandler) StreamLogs(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
+	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	err = runtime.StreamLogs(c.Request().Context(), id, logLines(c), func(entry models.ContainerLogEntry) error {
+		return writeEvent(res, "log", entry)
+	})
+	if err != nil {
+		// Headers are already sent, so report the failure in-stream.
+		_ = writeEvent(res, "error", map[string]string{"message": err.Error()})
+	}
+	return nil
+}
+
+// Stats handles GET /api/containers/:id/stats.
+func (h *ContainerHandler) Stats(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
+	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
 
-	// Parse optional lines parameter
+	stats, err := runtime.Stats(c.Request().Context(), id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// StreamStats handles GET /api/containers/:id/stats/stream.
+// Samples are pushed as Server-Sent Events every interval seconds (default 2)
+// until the client disconnects or the container stops.
+func (h *ContainerHandler) StreamStats(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "container ID is required")
+	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	interval := 2 * time.Second
+	if i := c.QueryParam("interval"); i != "" {
+		if parsed, err := strconv.Atoi(i); err == nil && parsed > 0 {
+			interval = time.Duration(parsed) * time.Second
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	err = runtime.StreamStats(c.Request().Context(), id, interval, func(stats models.ContainerStats) error {
+		return writeEvent(res, "stats", stats)
+	})
+	if err != nil {
+		// Headers are already sent, so report the failure in-stream.
+		_ = writeEvent(res, "error", map[string]string{"message": err.Error()})
+	}
+	return nil
+}
+
+// logLines returns the number of log lines requested by the lines query parameter.
+func logLines(c echo.Context) int {
 	lines := 100 // Default 100 lines
 	if l := c.QueryParam("lines"); l != "" {
 		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
 			lines = parsed
 		}
 	}
+	return lines
+}
 
-	logs, err := h.service.Logs(c.Request().Context(), id, lines)
+// writeEvent writes a single Server-Sent Event with a JSON payload and flushes it.
+func writeEvent(res *echo.Response, event string, data any) error {
+	payload, err := json.Marshal(data)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, logs)
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
 }
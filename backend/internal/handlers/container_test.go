@@ -2,53 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/container/containertest"
 	"github.com/sweetfish329/sabakan/backend/internal/models"
 )
 
-// mockServer creates a test server that mocks Podman API responses.
-func mockServer(t *testing.T, handlers map[string]http.HandlerFunc) *httptest.Server {
-	t.Helper()
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		for path, handler := range handlers {
-			if r.URL.Path == path {
-				handler(w, r)
-				return
-			}
-		}
-		http.NotFound(w, r)
-	}))
-}
-
 func TestContainerHandler_List(t *testing.T) {
-	// Create mock Podman server
-	mockPodman := mockServer(t, map[string]http.HandlerFunc{
-		"/v5.0.0/libpod/containers/json": func(w http.ResponseWriter, _ *http.Request) {
-			containers := []map[string]interface{}{
-				{
-					"Id":      "abc123",
-					"Names":   []string{"test-container"},
-					"Image":   "nginx:latest",
-					"State":   "running",
-					"Status":  "Up 10 minutes",
-					"Created": 1704067200,
-				},
-			}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(containers)
-		},
-	})
-	defer mockPodman.Close()
-
-	// Create service pointing to mock server
-	svc := container.NewService(mockPodman.URL)
-	handler := NewContainerHandler(svc)
+	runtime := containertest.New()
+	runtime.Containers["abc123"] = &models.Container{
+		ID:     "abc123",
+		Name:   "test-container",
+		Image:  "nginx:latest",
+		State:  models.StateRunning,
+		Status: "Up 10 minutes",
+	}
+	handler := NewContainerHandler(container.NewPool(runtime))
 
 	// Create Echo context
 	e := echo.New()
@@ -69,31 +45,76 @@ func TestContainerHandler_List(t *testing.T) {
 	assert.Equal(t, "abc123", containers[0].ID)
 	assert.Equal(t, "test-container", containers[0].Name)
 	assert.Equal(t, models.StateRunning, containers[0].State)
+	assert.Equal(t, container.LocalNode, containers[0].Node)
 }
 
-func TestContainerHandler_Get(t *testing.T) {
-	mockPodman := mockServer(t, map[string]http.HandlerFunc{
-		"/v5.0.0/libpod/containers/test-container/json": func(w http.ResponseWriter, _ *http.Request) {
-			data := map[string]interface{}{
-				"Id":      "abc123",
-				"Name":    "test-container",
-				"Created": "2024-01-01T00:00:00Z",
-				"State": map[string]string{
-					"Status": "running",
-				},
-				"Config": map[string]interface{}{
-					"Image":  "nginx:latest",
-					"Labels": map[string]string{"app": "test"},
-				},
-			}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(data)
-		},
+func TestContainerHandler_List_Nodes(t *testing.T) {
+	local := containertest.New()
+	local.Containers["abc123"] = &models.Container{ID: "abc123", Name: "local-container", State: models.StateRunning}
+	remote := containertest.New()
+	remote.Containers["def456"] = &models.Container{ID: "def456", Name: "remote-container", State: models.StateExited}
+	offline := containertest.New()
+	offline.Errors["List"] = fmt.Errorf("connection refused")
+
+	nodes := container.NewPool(local)
+	nodes.Set(1, "worker", remote)
+	nodes.Set(2, "offline", offline)
+	handler := NewContainerHandler(nodes)
+
+	e := echo.New()
+
+	t.Run("should list the containers of every reachable node", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.NoError(t, handler.List(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var containers []models.Container
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &containers))
+		assert.Len(t, containers, 2)
+		assert.Equal(t, "local-container", containers[0].Name)
+		assert.Equal(t, container.LocalNode, containers[0].Node)
+		assert.Equal(t, "remote-container", containers[1].Name)
+		assert.Equal(t, "worker", containers[1].Node)
+	})
+
+	t.Run("should address containers on the requested node", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers/def456?node=worker", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("def456")
+
+		assert.NoError(t, handler.Get(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
 	})
-	defer mockPodman.Close()
 
-	svc := container.NewService(mockPodman.URL)
-	handler := NewContainerHandler(svc)
+	t.Run("should return 404 for unknown nodes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers/def456?node=missing", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("def456")
+
+		err := handler.Get(c)
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, he.Code)
+	})
+}
+
+func TestContainerHandler_Get(t *testing.T) {
+	runtime := containertest.New()
+	runtime.Containers["abc123"] = &models.Container{
+		ID:     "abc123",
+		Name:   "test-container",
+		Image:  "nginx:latest",
+		State:  models.StateRunning,
+		Labels: map[string]string{"app": "test"},
+	}
+	handler := NewContainerHandler(container.NewPool(runtime))
 
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodGet, "/api/containers/test-container", nil)
@@ -111,22 +132,78 @@ func TestContainerHandler_Get(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "abc123", container.ID)
 	assert.Equal(t, "test-container", container.Name)
+	assert.Equal(t, "test", container.Labels["app"])
 }
 
-func TestContainerHandler_Start(t *testing.T) {
-	mockPodman := mockServer(t, map[string]http.HandlerFunc{
-		"/v5.0.0/libpod/containers/test-container/start": func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodPost {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
-			w.WriteHeader(http.StatusNoContent)
-		},
+func TestContainerHandler_Get_Details(t *testing.T) {
+	startedAt := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	runtime := containertest.New()
+	runtime.Containers["abc123"] = &models.Container{
+		ID:            "abc123",
+		Name:          "mc",
+		Image:         "itzg/minecraft-server",
+		State:         models.StateExited,
+		ExitCode:      137,
+		OOMKilled:     true,
+		RestartCount:  2,
+		Health:        "unhealthy",
+		RestartPolicy: "on-failure",
+		StartedAt:     &startedAt,
+		Ports:         []models.PortMapping{{HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}},
+		Mounts:        []models.Mount{{Type: "volume", Name: "sabakan-mc-data", Destination: "/data", ReadWrite: true}},
+		Env:           map[string]string{"EULA": "TRUE", "RCON_PASSWORD": "hunter2"},
+	}
+	handler := NewContainerHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	t.Run("should return ports, mounts and exit details", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers/mc", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("mc")
+
+		err := handler.Get(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var got models.Container
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, []models.PortMapping{{HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}}, got.Ports)
+		assert.Equal(t, 137, got.ExitCode)
+		assert.True(t, got.OOMKilled)
+		assert.Equal(t, 2, got.RestartCount)
+		assert.Equal(t, "unhealthy", got.Health)
+		assert.Equal(t, "on-failure", got.RestartPolicy)
+		assert.NotNil(t, got.StartedAt)
+		assert.Nil(t, got.FinishedAt)
+		if assert.Len(t, got.Mounts, 1) {
+			assert.Equal(t, "sabakan-mc-data", got.Mounts[0].Name)
+			assert.Equal(t, "/data", got.Mounts[0].Destination)
+		}
+	})
+
+	t.Run("should mask secret environment variables", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers/mc", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("mc")
+
+		assert.NoError(t, handler.Get(c))
+
+		var got models.Container
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, "TRUE", got.Env["EULA"])
+		assert.Equal(t, "********", got.Env["RCON_PASSWORD"])
+		assert.Equal(t, "hunter2", runtime.Containers["abc123"].Env["RCON_PASSWORD"])
 	})
-	defer mockPodman.Close()
+}
 
-	svc := container.NewService(mockPodman.URL)
-	handler := NewContainerHandler(svc)
+func TestContainerHandler_Start(t *testing.T) {
+	runtime := containertest.New()
+	runtime.Containers["test-container"] = &models.Container{ID: "test-container", State: models.StateExited}
+	handler := NewContainerHandler(container.NewPool(runtime))
 
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodPost, "/api/containers/test-container/start", nil)
@@ -138,46 +215,52 @@ func TestContainerHandler_Start(t *testing.T) {
 	err := handler.Start(c)
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, models.StateRunning, runtime.Containers["test-container"].State)
 }
 
 func TestContainerHandler_Stop(t *testing.T) {
-	mockPodman := mockServer(t, map[string]http.HandlerFunc{
-		"/v5.0.0/libpod/containers/test-container/stop": func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodPost {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
-			w.WriteHeader(http.StatusNoContent)
-		},
-	})
-	defer mockPodman.Close()
+	runtime := containertest.New()
+	runtime.Containers["test-container"] = &models.Container{ID: "test-container", State: models.StateRunning}
+	runtime.Containers["game-container"] = &models.Container{
+		ID:     "game-container",
+		State:  models.StateRunning,
+		Labels: map[string]string{"sabakan.managed": "true", "sabakan.slug": "survival"},
+	}
+	handler := NewContainerHandler(container.NewPool(runtime))
 
-	svc := container.NewService(mockPodman.URL)
-	handler := NewContainerHandler(svc)
+	stop := func(id string) error {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/containers/"+id+"/stop", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		if err := handler.Stop(c); err != nil {
+			return err
+		}
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		return nil
+	}
 
-	e := echo.New()
-	req := httptest.NewRequest(http.MethodPost, "/api/containers/test-container/stop", nil)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
-	c.SetParamNames("id")
-	c.SetParamValues("test-container")
+	t.Run("should stop unmanaged containers", func(t *testing.T) {
+		assert.NoError(t, stop("test-container"))
+		assert.Contains(t, runtime.CallLog(), "Stop test-container")
+	})
 
-	err := handler.Stop(c)
-	assert.NoError(t, err)
-	assert.Equal(t, http.StatusNoContent, rec.Code)
-}
+	t.Run("should reject game server containers", func(t *testing.T) {
+		err := stop("game-container")
 
-func TestContainerHandler_Get_NotFound(t *testing.T) {
-	mockPodman := mockServer(t, map[string]http.HandlerFunc{
-		"/v5.0.0/libpod/containers/nonexistent/json": func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusNotFound)
-			_, _ = w.Write([]byte(`{"cause":"no such container","message":"no such container","response":404}`))
-		},
+		var httpErr *echo.HTTPError
+		if assert.ErrorAs(t, err, &httpErr) {
+			assert.Equal(t, http.StatusConflict, httpErr.Code)
+			assert.Contains(t, httpErr.Message, "/api/game-servers/survival/stop")
+		}
+		assert.NotContains(t, runtime.CallLog(), "Stop game-container")
 	})
-	defer mockPodman.Close()
+}
 
-	svc := container.NewService(mockPodman.URL)
-	handler := NewContainerHandler(svc)
+func TestContainerHandler_Get_NotFound(t *testing.T) {
+	handler := NewContainerHandler(container.NewPool(containertest.New()))
 
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodGet, "/api/containers/nonexistent", nil)
@@ -193,3 +276,50 @@ func TestContainerHandler_Get_NotFound(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, http.StatusNotFound, he.Code)
 }
+
+func TestContainerHandler_StreamLogs(t *testing.T) {
+	runtime := containertest.New()
+	runtime.LogEntries["test-container"] = []models.ContainerLogEntry{{Stream: "stderr", Message: "disk full!"}}
+	handler := NewContainerHandler(container.NewPool(runtime))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/containers/test-container/logs/stream", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("test-container")
+
+	err := handler.StreamLogs(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), "event: log\n")
+	assert.Contains(t, rec.Body.String(), `"stream":"stderr","message":"disk full!"`)
+}
+
+func TestContainerHandler_Stats(t *testing.T) {
+	runtime := containertest.New()
+	runtime.StatSamples["test-container"] = models.ContainerStats{ContainerID: "abc123", CPUPercent: 12.5, MemoryUsage: 1024, MemoryLimit: 4096, PIDs: 7}
+	handler := NewContainerHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	t.Run("should return a stats snapshot", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers/test-container/stats", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("test-container")
+
+		err := handler.Stats(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var stats models.ContainerStats
+		err = json.Unmarshal(rec.Body.Bytes(), &stats)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc123", stats.ContainerID)
+		assert.Equal(t, 12.5, stats.CPUPercent)
+		assert.Equal(t, uint64(1024), stats.MemoryUsage)
+		assert.Equal(t, uint64(7), stats.PIDs)
+	})
+}
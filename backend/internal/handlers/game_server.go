@@ -2,10 +2,20 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
 	"github.com/sweetfish329/sabakan/backend/internal/games"
 	"github.com/sweetfish329/sabakan/backend/internal/middleware"
 	"github.com/sweetfish329/sabakan/backend/internal/models"
@@ -15,6 +25,9 @@ import (
 // slugPattern validates slug format (lowercase letters, numbers, hyphens).
 var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
 
+// digestPattern validates image digests a server can be pinned to.
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
 // CreateGameServerRequest represents the request body for creating a game server.
 type CreateGameServerRequest struct {
 	Slug        string                  `json:"slug"`
@@ -23,9 +36,18 @@ type CreateGameServerRequest struct {
 	Description string                  `json:"description,omitempty"`
 	Ports       []GameServerPortRequest `json:"ports,omitempty"`
 	Envs        []GameServerEnvRequest  `json:"envs,omitempty"`
+	// Node is the name of the node to place the server on. The node is picked automatically when empty.
+	Node string `json:"node,omitempty"`
+	// NodeSelector restricts automatic placement to nodes with all of these labels.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Resources limits what the server's container may use.
+	Resources models.ResourceLimits `json:"resources"`
+	// RestartPolicy is "no" (default), "on-failure", "always" or "unless-stopped".
+	RestartPolicy string `json:"restartPolicy,omitempty"`
 }
 
 // GameServerPortRequest represents a port mapping in the request.
+// A host port of 0 is allocated from the configured port range.
 type GameServerPortRequest struct {
 	HostPort      int    `json:"hostPort"`
 	ContainerPort int    `json:"containerPort"`
@@ -45,16 +67,81 @@ type UpdateGameServerRequest struct {
 	Description string                  `json:"description,omitempty"`
 	Ports       []GameServerPortRequest `json:"ports,omitempty"`
 	Envs        []GameServerEnvRequest  `json:"envs,omitempty"`
+	// PinnedDigest pins the server to an image digest; an empty string unpins it.
+	// It takes effect the next time the container is recreated or upgraded.
+	PinnedDigest *string `json:"pinnedDigest,omitempty"`
+	// Resources replaces the resource limits. They are applied to the
+	// existing container when the runtime supports it.
+	Resources *models.ResourceLimits `json:"resources,omitempty"`
+	// RestartPolicy replaces the restart policy like Resources.
+	RestartPolicy *string `json:"restartPolicy,omitempty"`
 }
 
+// defaultGracefulStopTimeout bounds how long a game may take to save and
+// shut down before its container is stopped.
+const defaultGracefulStopTimeout = 60 * time.Second
+
+// Host ports are allocated from this range unless configured otherwise.
+const (
+	defaultPortRangeStart = 30000
+	defaultPortRangeEnd   = 39999
+)
+
+// defaultBackupDir is where volume backups are written unless configured otherwise.
+const defaultBackupDir = "./backups"
+
 // GameServerHandler handles game server-related HTTP requests.
 type GameServerHandler struct {
-	db *gorm.DB
+	db                  *gorm.DB
+	nodes               *container.Pool
+	ports               *container.PortAllocator
+	gracefulStopTimeout time.Duration
+	backupDir           string
+	broker              *container.StatusBroker
+	readinessInterval   time.Duration
+	allowedOrigins      []string
+
+	probesMu sync.Mutex
+	// probes holds the readiness probes in progress by server ID.
+	probes map[uint]*readinessRun
+}
+
+// NewGameServerHandler creates a new game server handler managing servers on the nodes of a pool.
+func NewGameServerHandler(db *gorm.DB, nodes *container.Pool) *GameServerHandler {
+	return &GameServerHandler{
+		db:                  db,
+		nodes:               nodes,
+		ports:               container.NewPortAllocator(db, nodes, defaultPortRangeStart, defaultPortRangeEnd),
+		gracefulStopTimeout: defaultGracefulStopTimeout,
+		backupDir:           defaultBackupDir,
+		readinessInterval:   defaultReadinessInterval,
+		probes:              make(map[uint]*readinessRun),
+	}
+}
+
+// SetPortRange sets the range host ports are allocated from.
+func (h *GameServerHandler) SetPortRange(start, end int) {
+	if start > 0 && end >= start {
+		h.ports.SetRange(start, end)
+	}
+}
+
+// SetBackupDir sets the directory volume backups are written to.
+func (h *GameServerHandler) SetBackupDir(dir string) {
+	if dir != "" {
+		h.backupDir = dir
+	}
 }
 
-// NewGameServerHandler creates a new game server handler.
-func NewGameServerHandler(db *gorm.DB) *GameServerHandler {
-	return &GameServerHandler{db: db}
+// SetStatusBroker sets the broker status changes are published to.
+func (h *GameServerHandler) SetStatusBroker(broker *container.StatusBroker) {
+	h.broker = broker
+}
+
+// SetAllowedOrigins sets the origins allowed to open console connections
+// besides the API's own origin.
+func (h *GameServerHandler) SetAllowedOrigins(origins []string) {
+	h.allowedOrigins = origins
 }
 
 // Create handles POST /api/game-servers.
@@ -99,23 +186,130 @@ func (h *GameServerHandler) Create(c echo.Context) error {
 		})
 	}
 
-	// Get game handler for default image
-	image := "unknown:latest"
-	if gameHandler, ok := games.Get(req.Game); ok {
-		image = gameHandler.GetDefaultImage()
+	// Validate game
+	gameHandler, ok := games.Get(req.Game)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: fmt.Sprintf("Unknown game %q", req.Game),
+		})
+	}
+
+	// Validate envs against the game's config schema
+	if err := gameHandler.ValidateConfig(envConfig(req.Envs)); err != nil {
+		var fieldErrs games.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_error",
+				Message: "Invalid game configuration",
+				Details: fieldErrs,
+			})
+		}
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	}
+
+	// Generate the console passwords left empty instead of shipping a fixed default
+	generated, err := gameHandler.GetConfigSchema().Generate(envConfig(req.Envs))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: err.Error(),
+		})
+	}
+	req.Envs = withGeneratedEnvs(req.Envs, generated)
+
+	// Validate resource limits against the game's requirements
+	if req.RestartPolicy == "" {
+		req.RestartPolicy = models.RestartPolicyNo
+	}
+	if err := validateResources(gameHandler, req.Resources, req.RestartPolicy); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	}
+
+	// Fall back to the game's default ports when none are given
+	portReqs, err := portRequests(gameHandler, req.Ports)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	}
+	var manualPorts []models.GameServerPort
+	for _, p := range portReqs {
+		if p.HostPort != 0 {
+			manualPorts = append(manualPorts, models.GameServerPort{HostPort: p.HostPort, ContainerPort: p.ContainerPort, Protocol: p.Protocol})
+		}
+	}
+
+	// Place the server on the chosen node or the one with the most room
+	nodeID, err := h.nodes.Place(c.Request().Context(), h.db, container.PlacementRequest{
+		Node:     req.Node,
+		Selector: req.NodeSelector,
+		Ports:    manualPorts,
+		MemoryMB: req.Resources.MemoryMB,
+	})
+	if err != nil {
+		if errors.Is(err, container.ErrNodeNotFound) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_error",
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, container.ErrNoNodeAvailable) {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "no_node_available",
+				Message: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: err.Error(),
+		})
+	}
+
+	// Assign host ports on the node; allocations wait until these ports are stored
+	ports, release, err := h.ports.Allocate(c.Request().Context(), nodeID, portReqs)
+	if err != nil {
+		if errors.Is(err, container.ErrPortConflict) {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "port_conflict",
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, container.ErrNoFreePort) {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "no_free_port",
+				Message: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: err.Error(),
+		})
 	}
+	defer release()
 
 	// Get user ID from context
 	userID := middleware.GetUserID(c)
 
 	// Create game server
 	server := models.GameServer{
-		Slug:        req.Slug,
-		Name:        req.Name,
-		Description: req.Description,
-		Image:       image,
-		Status:      models.GameServerStatusStopped,
-		OwnerID:     userID,
+		Slug:          req.Slug,
+		Name:          req.Name,
+		Description:   req.Description,
+		Game:          req.Game,
+		Image:         gameHandler.GetDefaultImage(),
+		Status:        models.GameServerStatusCreating,
+		OwnerID:       userID,
+		NodeID:        nodeID,
+		Resources:     req.Resources,
+		RestartPolicy: req.RestartPolicy,
 	}
 
 	if err := h.db.Create(&server).Error; err != nil {
@@ -126,37 +320,339 @@ func (h *GameServerHandler) Create(c echo.Context) error {
 	}
 
 	// Create ports
-	for _, p := range req.Ports {
-		protocol := p.Protocol
-		if protocol == "" {
-			protocol = "tcp"
-		}
-		port := models.GameServerPort{
-			GameServerID:  server.ID,
-			HostPort:      p.HostPort,
-			ContainerPort: p.ContainerPort,
-			Protocol:      protocol,
-		}
+	for _, port := range ports {
+		port.GameServerID = server.ID
 		h.db.Create(&port)
 	}
+	release()
 
-	// Create envs
+	// Create envs, keeping fields the game marks as secret masked
 	for _, e := range req.Envs {
+		isSecret := e.IsSecret
+		if field, ok := gameHandler.GetConfigSchema().Field(e.Key); ok && field.Secret {
+			isSecret = true
+		}
 		env := models.GameServerEnv{
 			GameServerID: server.ID,
 			Key:          e.Key,
 			Value:        e.Value,
-			IsSecret:     e.IsSecret,
+			IsSecret:     isSecret,
 		}
 		h.db.Create(&env)
 	}
 
+	// Create volumes for the game's data directories
+	for _, v := range defaultVolumes(&server, gameHandler) {
+		h.db.Create(&v)
+	}
+
 	// Reload with associations
-	h.db.Preload("Ports").Preload("Envs").First(&server, server.ID)
+	h.db.Preload("Ports").Preload("Envs").Preload("Volumes").Preload("Node").First(&server, server.ID)
+
+	// Provision the container
+	if err := h.provision(c.Request().Context(), &server); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
 
 	return c.JSON(http.StatusCreated, server)
 }
 
+// runtime returns the container runtime of the node a server is placed on.
+func (h *GameServerHandler) runtime(server *models.GameServer) (container.Runtime, error) {
+	return h.nodes.Runtime(server.NodeID)
+}
+
+// provision creates the container for a game server and records its ID.
+// The server status moves to stopped on success and to error on failure.
+func (h *GameServerHandler) provision(ctx context.Context, server *models.GameServer) error {
+	runtime, err := h.runtime(server)
+	if err != nil {
+		h.setStatus(server, models.GameServerStatusError)
+		return err
+	}
+
+	containerID, err := createContainer(ctx, runtime, server, containerName(server))
+	if err != nil {
+		h.setStatus(server, models.GameServerStatusError)
+		return err
+	}
+	return h.recordContainer(ctx, runtime, server, containerID)
+}
+
+// createContainer creates the volumes of a game server and a container with
+// the given name, and returns the container ID.
+func createContainer(ctx context.Context, runtime container.Runtime, server *models.GameServer, name string) (string, error) {
+	opts := container.CreateOptions{
+		Name:  name,
+		Image: server.ImageRef(),
+		Env:   containerEnv(server),
+		Labels: map[string]string{
+			"sabakan.managed": "true",
+			"sabakan.slug":    server.Slug,
+			"sabakan.game":    server.Game,
+		},
+		Stdin:         true,
+		Resources:     server.Resources,
+		RestartPolicy: server.RestartPolicy,
+	}
+	// Sabakan reaches the console of servers on a local node over loopback,
+	// so it is not published to the network there
+	console, hasConsole := consolePort(server)
+	local := isLoopback(runtime.Host())
+	for _, p := range server.Ports {
+		mapping := models.PortMapping{
+			HostPort:      uint16(p.HostPort),
+			ContainerPort: uint16(p.ContainerPort),
+			Protocol:      p.Protocol,
+		}
+		if local && hasConsole && p.ContainerPort == console && p.Protocol != "udp" {
+			mapping.HostIP = "127.0.0.1"
+		}
+		opts.Ports = append(opts.Ports, mapping)
+	}
+	for _, v := range server.Volumes {
+		labels := map[string]string{
+			"sabakan.managed": "true",
+			"sabakan.slug":    server.Slug,
+		}
+		if err := runtime.EnsureVolume(ctx, v.VolumeName, labels); err != nil {
+			return "", fmt.Errorf("failed to create volume: %w", err)
+		}
+		opts.Volumes = append(opts.Volumes, container.VolumeMount{
+			Name: v.VolumeName,
+			Dest: v.MountPath,
+		})
+	}
+
+	containerID, err := runtime.Create(ctx, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+	return containerID, nil
+}
+
+// recordContainer records the new container of a game server, which is stopped.
+func (h *GameServerHandler) recordContainer(ctx context.Context, runtime container.Runtime, server *models.GameServer, containerID string) error {
+	server.ContainerID = containerID
+	server.Status = models.GameServerStatusStopped
+	updates := map[string]any{
+		"container_id": server.ContainerID,
+		"status":       server.Status,
+	}
+	// Record the image digest so that update checks know what the container runs
+	if image, err := runtime.GetImage(ctx, server.ImageRef()); err == nil {
+		server.ImageDigest = image.Digest
+		updates["image_digest"] = server.ImageDigest
+	}
+	if err := h.db.Model(server).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record container: %w", err)
+	}
+
+	return nil
+}
+
+// containerName returns the container name used for a game server.
+func containerName(server *models.GameServer) string {
+	return "sabakan-" + server.Slug
+}
+
+// containerEnv merges the game's default environment with the server's own variables.
+func containerEnv(server *models.GameServer) map[string]string {
+	env := make(map[string]string)
+	if gameHandler, ok := games.Get(server.Game); ok {
+		for k, v := range gameHandler.GetDefaultEnv() {
+			env[k] = v
+		}
+	}
+	for _, e := range server.Envs {
+		env[e.Key] = e.Value
+	}
+	return env
+}
+
+// withGeneratedEnvs sets the generated values on the requested envs, adding
+// those that were not requested.
+func withGeneratedEnvs(envs []GameServerEnvRequest, generated map[string]string) []GameServerEnvRequest {
+	for i, e := range envs {
+		if value, ok := generated[e.Key]; ok {
+			envs[i].Value = value
+			envs[i].IsSecret = true
+			delete(generated, e.Key)
+		}
+	}
+	keys := make([]string, 0, len(generated))
+	for key := range generated {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		envs = append(envs, GameServerEnvRequest{Key: key, Value: generated[key], IsSecret: true})
+	}
+	return envs
+}
+
+// consolePort returns the container port of a server's remote console.
+func consolePort(server *models.GameServer) (int, bool) {
+	gameHandler, ok := games.Get(server.Game)
+	if !ok {
+		return 0, false
+	}
+	rconHandler, ok := gameHandler.(games.RCONHandler)
+	if !ok {
+		return 0, false
+	}
+	port, ok := gameHandler.GetDefaultPorts()[rconHandler.GetRCONConfig().Port]
+	return port, ok
+}
+
+// isLoopback reports whether host is a loopback address.
+func isLoopback(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// maskSecrets hides the values of a server's secret envs before it is
+// returned. Secrets are only returned in full when the server is created.
+func maskSecrets(server *models.GameServer) {
+	for i := range server.Envs {
+		server.Envs[i].Value = server.Envs[i].GetVisibleValue()
+	}
+}
+
+// envConfig converts requested envs to the config map validated by game handlers.
+func envConfig(envs []GameServerEnvRequest) map[string]any {
+	config := make(map[string]any, len(envs))
+	for _, e := range envs {
+		config[e.Key] = e.Value
+	}
+	return config
+}
+
+// defaultVolumes builds the volume records for a game's default data volumes.
+func defaultVolumes(server *models.GameServer, gameHandler games.GameHandler) []models.GameServerVolume {
+	defaults := gameHandler.GetDefaultVolumes()
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	volumes := make([]models.GameServerVolume, 0, len(defaults))
+	for _, name := range names {
+		volumes = append(volumes, models.GameServerVolume{
+			GameServerID: server.ID,
+			Name:         name,
+			VolumeName:   fmt.Sprintf("sabakan-%s-%s", server.Slug, name),
+			MountPath:    defaults[name],
+		})
+	}
+	return volumes
+}
+
+// applyResources updates the limits and restart policy of a server's existing
+// container. Runtimes that cannot update containers pick the settings up when
+// the container is recreated.
+func (h *GameServerHandler) applyResources(ctx context.Context, server *models.GameServer) error {
+	if server.ContainerID == "" {
+		return nil
+	}
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return err
+	}
+	err = runtime.Update(ctx, server.ContainerID, container.UpdateOptions{
+		Resources:     server.Resources,
+		RestartPolicy: server.RestartPolicy,
+	})
+	if errors.Is(err, container.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// validateResources checks resource limits and a restart policy. A memory or
+// CPU limit must not be below what the game needs; no limit is always accepted.
+func validateResources(gameHandler games.GameHandler, limits models.ResourceLimits, restartPolicy string) error {
+	switch restartPolicy {
+	case models.RestartPolicyNo, models.RestartPolicyOnFailure, models.RestartPolicyAlways, models.RestartPolicyUnlessStopped:
+	default:
+		return fmt.Errorf("invalid restart policy %q: must be no, on-failure, always or unless-stopped", restartPolicy)
+	}
+
+	if limits.MemoryMB < 0 || limits.CPUs < 0 || limits.CPUShares < 0 || limits.PidsLimit < 0 {
+		return errors.New("resource limits must not be negative")
+	}
+	if limits.CPUShares != 0 && (limits.CPUShares < 2 || limits.CPUShares > 262144) {
+		return errors.New("cpuShares must be between 2 and 262144")
+	}
+
+	if gameHandler == nil {
+		return nil
+	}
+	minimum := games.MinimumRequirements(gameHandler)
+	name := gameHandler.GetMetadata().DisplayName
+	if limits.MemoryMB > 0 && limits.MemoryMB < minimum.MemoryMB {
+		return fmt.Errorf("%s needs a memory limit of at least %d MB", name, minimum.MemoryMB)
+	}
+	if limits.CPUs > 0 && limits.CPUs < minimum.CPUs {
+		return fmt.Errorf("%s needs a CPU limit of at least %g", name, minimum.CPUs)
+	}
+	return nil
+}
+
+// portRequests validates the requested port mappings and returns them as
+// allocation requests. Without requested ports the game's default ports are
+// used, preferring the default host port and allocating another one when it is taken.
+func portRequests(gameHandler games.GameHandler, requested []GameServerPortRequest) ([]container.PortRequest, error) {
+	if len(requested) == 0 {
+		defaults := gameHandler.GetDefaultPorts()
+		names := make([]string, 0, len(defaults))
+		for name := range defaults {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		reqs := make([]container.PortRequest, 0, len(defaults))
+		for _, name := range names {
+			port := defaults[name]
+			reqs = append(reqs, container.PortRequest{
+				Name:          name,
+				ContainerPort: port,
+				Protocol:      games.PortProtocol(gameHandler, name),
+				Preferred:     port,
+			})
+		}
+		return reqs, nil
+	}
+
+	reqs := make([]container.PortRequest, 0, len(requested))
+	for _, p := range requested {
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		if protocol != "tcp" && protocol != "udp" {
+			return nil, fmt.Errorf("invalid protocol %q: must be tcp or udp", p.Protocol)
+		}
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+			return nil, fmt.Errorf("invalid container port %d", p.ContainerPort)
+		}
+		if p.HostPort < 0 || p.HostPort > 65535 {
+			return nil, fmt.Errorf("invalid host port %d", p.HostPort)
+		}
+		reqs = append(reqs, container.PortRequest{
+			Name:          fmt.Sprintf("%d/%s", p.ContainerPort, protocol),
+			ContainerPort: p.ContainerPort,
+			Protocol:      protocol,
+			HostPort:      p.HostPort,
+		})
+	}
+	return reqs, nil
+}
+
 // List handles GET /api/game-servers.
 func (h *GameServerHandler) List(c echo.Context) error {
 	userID := middleware.GetUserID(c)
@@ -165,6 +661,8 @@ func (h *GameServerHandler) List(c echo.Context) error {
 	if err := h.db.Where("owner_id = ?", userID).
 		Preload("Ports").
 		Preload("Envs").
+		Preload("Volumes").
+		Preload("Node").
 		Find(&servers).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_error",
@@ -172,6 +670,9 @@ func (h *GameServerHandler) List(c echo.Context) error {
 		})
 	}
 
+	for i := range servers {
+		maskSecrets(&servers[i])
+	}
 	return c.JSON(http.StatusOK, servers)
 }
 
@@ -185,10 +686,13 @@ func (h *GameServerHandler) Get(c echo.Context) error {
 		})
 	}
 
+	// Like List, only the owner's servers are visible
 	var server models.GameServer
-	if err := h.db.Where("slug = ?", slug).
+	if err := h.db.Where("slug = ? AND owner_id = ?", slug, middleware.GetUserID(c)).
 		Preload("Ports").
 		Preload("Envs").
+		Preload("Volumes").
+		Preload("Node").
 		First(&server).Error; err != nil {
 		return c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
@@ -196,6 +700,7 @@ func (h *GameServerHandler) Get(c echo.Context) error {
 		})
 	}
 
+	maskSecrets(&server)
 	return c.JSON(http.StatusOK, server)
 }
 
@@ -232,6 +737,43 @@ func (h *GameServerHandler) Update(c echo.Context) error {
 	if req.Description != "" {
 		server.Description = req.Description
 	}
+	if req.PinnedDigest != nil {
+		if *req.PinnedDigest != "" && !digestPattern.MatchString(*req.PinnedDigest) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_error",
+				Message: "Pinned digest must be in the form sha256:<64 hex characters>",
+			})
+		}
+		server.PinnedDigest = *req.PinnedDigest
+		if server.PinnedDigest != "" {
+			server.UpdateAvailable = false
+		}
+	}
+
+	if req.Resources != nil || req.RestartPolicy != nil {
+		if req.Resources != nil {
+			server.Resources = *req.Resources
+		}
+		if req.RestartPolicy != nil {
+			server.RestartPolicy = *req.RestartPolicy
+			if server.RestartPolicy == "" {
+				server.RestartPolicy = models.RestartPolicyNo
+			}
+		}
+		gameHandler, _ := games.Get(server.Game)
+		if err := validateResources(gameHandler, server.Resources, server.RestartPolicy); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_error",
+				Message: err.Error(),
+			})
+		}
+		if err := h.applyResources(c.Request().Context(), &server); err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "container_error",
+				Message: err.Error(),
+			})
+		}
+	}
 
 	if err := h.db.Save(&server).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -241,12 +783,14 @@ func (h *GameServerHandler) Update(c echo.Context) error {
 	}
 
 	// Reload with associations
-	h.db.Preload("Ports").Preload("Envs").First(&server, server.ID)
+	h.db.Preload("Ports").Preload("Envs").Preload("Volumes").Preload("Node").First(&server, server.ID)
 
+	maskSecrets(&server)
 	return c.JSON(http.StatusOK, server)
 }
 
 // Delete handles DELETE /api/game-servers/:slug.
+// Volumes are kept unless the purgeVolumes query parameter is true.
 func (h *GameServerHandler) Delete(c echo.Context) error {
 	slug := c.Param("slug")
 	if slug == "" {
@@ -256,17 +800,59 @@ func (h *GameServerHandler) Delete(c echo.Context) error {
 		})
 	}
 
+	purgeVolumes, _ := strconv.ParseBool(c.QueryParam("purgeVolumes"))
+
 	var server models.GameServer
-	if err := h.db.Where("slug = ?", slug).First(&server).Error; err != nil {
+	if err := h.db.Where("slug = ?", slug).Preload("Volumes").First(&server).Error; err != nil {
 		return c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
 			Message: "Game server not found",
 		})
 	}
 
-	// Delete associated ports and envs
+	ctx := c.Request().Context()
+	runtime, err := h.runtime(&server)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	// Stop a running server gracefully first, so that it saves its world.
+	// The container is force-removed regardless if it does not stop.
+	if server.ContainerID != "" && server.Status.IsRunning() {
+		if gracefulErr, err := h.stopServer(ctx, &server, stopTimeout(c)); gracefulErr != nil || err != nil {
+			slog.Warn("Failed to stop game server gracefully before deletion", "slug", server.Slug, "error", errors.Join(gracefulErr, err))
+		}
+	}
+
+	// Remove the container
+	if server.ContainerID != "" {
+		if err := runtime.Remove(ctx, server.ContainerID, true); err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "container_error",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Purge volumes if requested
+	if purgeVolumes {
+		for _, v := range server.Volumes {
+			if err := runtime.RemoveVolume(ctx, v.VolumeName, true); err != nil {
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error:   "container_error",
+					Message: err.Error(),
+				})
+			}
+		}
+	}
+
+	// Delete associated ports, envs and volume records
 	h.db.Where("game_server_id = ?", server.ID).Delete(&models.GameServerPort{})
 	h.db.Where("game_server_id = ?", server.ID).Delete(&models.GameServerEnv{})
+	h.db.Where("game_server_id = ?", server.ID).Delete(&models.GameServerVolume{})
 
 	// Delete the server
 	if err := h.db.Delete(&server).Error; err != nil {
@@ -278,3 +864,312 @@ func (h *GameServerHandler) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// Events handles GET /api/game-servers/events.
+// Status changes of all game servers are pushed as Server-Sent Events until
+// the client disconnects.
+func (h *GameServerHandler) Events(c echo.Context) error {
+	if h.broker == nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "unavailable",
+			Message: "Status events are not available",
+		})
+	}
+	events, unsubscribe := h.broker.Subscribe()
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if err := writeEvent(res, "status", event); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// Start handles POST /api/game-servers/:slug/start.
+// With the wait query parameter, the response is sent once the game is ready
+// or after that many seconds at most.
+func (h *GameServerHandler) Start(c echo.Context) error {
+	server, err := h.findManagedServer(c)
+	if server == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.startServer(ctx, server); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionStart, nil)
+
+	return h.startedResponse(c, server)
+}
+
+// Stop handles POST /api/game-servers/:slug/stop.
+func (h *GameServerHandler) Stop(c echo.Context) error {
+	server, err := h.findManagedServer(c)
+	if server == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	gracefulErr, err := h.stopServer(ctx, server, stopTimeout(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionStop, stopDetails(gracefulErr))
+
+	maskSecrets(server)
+	return c.JSON(http.StatusOK, server)
+}
+
+// Restart handles POST /api/game-servers/:slug/restart.
+// It supports the wait query parameter like Start.
+func (h *GameServerHandler) Restart(c echo.Context) error {
+	server, err := h.findManagedServer(c)
+	if server == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	gracefulErr, err := h.stopServer(ctx, server, stopTimeout(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+	if err := h.startServer(ctx, server); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionRestart, stopDetails(gracefulErr))
+
+	return h.startedResponse(c, server)
+}
+
+// Recreate handles POST /api/game-servers/:slug/recreate.
+// The existing container is removed and a new one is created from the current
+// server configuration. A server that was running is started again.
+func (h *GameServerHandler) Recreate(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Slug is required",
+		})
+	}
+
+	var server models.GameServer
+	if err := h.db.Where("slug = ?", slug).
+		Preload("Ports").
+		Preload("Envs").
+		Preload("Volumes").
+		First(&server).Error; err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Game server not found",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request().Context()), upgradeTimeout)
+	defer cancel()
+	wasRunning := server.Status.IsRunning()
+	oldContainerID := server.ContainerID
+	details := map[string]any{"oldContainerId": oldContainerID}
+
+	if wasRunning && oldContainerID != "" {
+		gracefulErr, err := h.stopServer(ctx, &server, stopTimeout(c))
+		for k, v := range stopDetails(gracefulErr) {
+			details[k] = v
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "container_error",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if err := h.replaceContainer(ctx, &server); err != nil {
+		h.resume(&server, wasRunning && oldContainerID != "")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	if wasRunning {
+		if err := h.startServer(ctx, &server); err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "container_error",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	details["newContainerId"] = server.ContainerID
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionRecreate, details)
+
+	maskSecrets(&server)
+	return c.JSON(http.StatusOK, server)
+}
+
+// replaceContainer provisions a new container from the current server
+// configuration in place of the server's container, if any. The new container
+// is created before the old one is removed, so that the server keeps its old
+// container when the new one cannot be created. The server must be stopped.
+func (h *GameServerHandler) replaceContainer(ctx context.Context, server *models.GameServer) error {
+	if server.ContainerID == "" {
+		server.Status = models.GameServerStatusCreating
+		h.db.Model(server).Update("status", server.Status)
+		return h.provision(ctx, server)
+	}
+
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return err
+	}
+
+	// The old container holds the name until it is removed
+	name := containerName(server)
+	containerID, err := createContainer(ctx, runtime, server, name+"-next")
+	if err != nil {
+		return err
+	}
+	if err := runtime.Remove(ctx, server.ContainerID, true); err != nil {
+		_ = runtime.Remove(ctx, containerID, true)
+		return err
+	}
+	if err := runtime.Rename(ctx, containerID, name); err != nil {
+		slog.Warn("Failed to rename replacement container", "slug", server.Slug, "container", containerID, "error", err)
+	}
+
+	return h.recordContainer(ctx, runtime, server, containerID)
+}
+
+// findManagedServer loads the game server named by the slug parameter and
+// ensures it has a container. When it returns a nil server, the error
+// response has already been written and its result is returned as err.
+func (h *GameServerHandler) findManagedServer(c echo.Context) (*models.GameServer, error) {
+	slug := c.Param("slug")
+	if slug == "" {
+		return nil, c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Slug is required",
+		})
+	}
+
+	var server models.GameServer
+	if err := h.db.Where("slug = ?", slug).First(&server).Error; err != nil {
+		return nil, c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Game server not found",
+		})
+	}
+
+	if server.ContainerID == "" {
+		return nil, c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Game server has no container; recreate it first",
+		})
+	}
+
+	return &server, nil
+}
+
+// startServer starts the server's container and runs the game's start hook.
+func (h *GameServerHandler) startServer(ctx context.Context, server *models.GameServer) error {
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return err
+	}
+	if err := runtime.Start(ctx, server.ContainerID); err != nil {
+		h.setStatus(server, models.GameServerStatusError)
+		return err
+	}
+	h.started(server)
+
+	if gameHandler, ok := games.Get(server.Game); ok {
+		if err := gameHandler.OnStart(ctx, &hookServer{h: h, server: server}); err != nil {
+			return fmt.Errorf("start hook failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// stopServer shuts the server down gracefully through the game's stop hook
+// and then stops its container. If the hook fails or does not finish within
+// the graceful stop timeout, the container is stopped anyway and the hook's
+// error is returned as gracefulErr.
+func (h *GameServerHandler) stopServer(ctx context.Context, server *models.GameServer, timeout uint) (gracefulErr, err error) {
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return nil, err
+	}
+
+	gameHandler, ok := games.Get(server.Game)
+	if ok && server.Status.IsRunning() {
+		hookCtx, cancel := context.WithTimeout(ctx, h.gracefulStopTimeout)
+		gracefulErr = gameHandler.OnStop(hookCtx, &hookServer{h: h, server: server})
+		cancel()
+	}
+
+	if err := runtime.Stop(ctx, server.ContainerID, timeout); err != nil {
+		h.setStatus(server, models.GameServerStatusError)
+		return gracefulErr, err
+	}
+	h.setStatus(server, models.GameServerStatusStopped)
+
+	return gracefulErr, nil
+}
+
+// stopDetails returns audit log details describing how a server was stopped.
+func stopDetails(gracefulErr error) map[string]any {
+	details := map[string]any{"graceful": gracefulErr == nil}
+	if gracefulErr != nil {
+		details["gracefulError"] = gracefulErr.Error()
+	}
+	return details
+}
+
+// setStatus updates and publishes the status of a game server.
+func (h *GameServerHandler) setStatus(server *models.GameServer, status models.GameServerStatus) {
+	server.Status = status
+	h.db.Model(server).Update("status", status)
+	h.broker.Publish(server)
+}
+
+// stopTimeout returns the stop timeout in seconds from the timeout query parameter.
+func stopTimeout(c echo.Context) uint {
+	timeout := uint(10) // Default 10 seconds
+	if t := c.QueryParam("timeout"); t != "" {
+		if parsed, err := strconv.ParseUint(t, 10, 32); err == nil {
+			timeout = uint(parsed)
+		}
+	}
+	return timeout
+}
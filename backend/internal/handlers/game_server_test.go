@@ -3,13 +3,18 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/glebarez/sqlite"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/container/containertest"
 	"github.com/sweetfish329/sabakan/backend/internal/middleware"
 	"github.com/sweetfish329/sabakan/backend/internal/models"
 	"gorm.io/gorm"
@@ -28,9 +33,13 @@ func setupGameServerTestDB(t *testing.T) *gorm.DB {
 		&models.Role{},
 		&models.Permission{},
 		&models.User{},
+		&models.Node{},
 		&models.GameServer{},
 		&models.GameServerPort{},
 		&models.GameServerEnv{},
+		&models.GameServerVolume{},
+		&models.GameServerStat{},
+		&models.AuditLog{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate: %v", err)
@@ -45,9 +54,18 @@ func setupGameServerTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+// newTestGameServerHandler creates a game server handler backed by a fake container runtime.
+func newTestGameServerHandler(t *testing.T, db *gorm.DB) *GameServerHandler {
+	t.Helper()
+	runtime := containertest.New()
+	runtime.CreateID = "container123"
+
+	return NewGameServerHandler(db, container.NewPool(runtime))
+}
+
 func TestGameServerHandler_Create(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	e := echo.New()
 
@@ -76,12 +94,518 @@ func TestGameServerHandler_Create(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "my-minecraft", resp.Slug)
 		assert.Equal(t, "My Minecraft Server", resp.Name)
+		assert.Equal(t, "container123", resp.ContainerID)
+		assert.Equal(t, models.GameServerStatusStopped, resp.Status)
+	})
+}
+
+func TestGameServerHandler_Create_ProvisionsContainer(t *testing.T) {
+	db := setupGameServerTestDB(t)
+
+	runtime := containertest.New()
+	runtime.CreateID = "container123"
+	// Like the local Podman socket
+	runtime.HostName = "127.0.0.1"
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+	e := echo.New()
+
+	reqBody := CreateGameServerRequest{
+		Slug: "survival",
+		Name: "Survival",
+		Game: "minecraft",
+		Envs: []GameServerEnvRequest{{Key: "MEMORY", Value: "4G"}},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(middleware.ContextKeyUserID, uint(1))
+
+	err := handler.Create(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	opts := runtime.CreateOptions["container123"]
+
+	t.Run("should build the container from the server and game defaults", func(t *testing.T) {
+		assert.Equal(t, "sabakan-survival", opts.Name)
+		assert.Equal(t, "itzg/minecraft-server:latest", opts.Image)
+		assert.Equal(t, "4G", opts.Env["MEMORY"])
+		assert.Equal(t, "TRUE", opts.Env["EULA"])
+		assert.Equal(t, "survival", opts.Labels["sabakan.slug"])
+		assert.Len(t, opts.Ports, 2)
+	})
+
+	t.Run("should publish the console only on loopback of a local node", func(t *testing.T) {
+		hostIPs := make(map[uint16]string)
+		for _, p := range opts.Ports {
+			hostIPs[p.ContainerPort] = p.HostIP
+		}
+		assert.Equal(t, "127.0.0.1", hostIPs[25575])
+		assert.Empty(t, hostIPs[25565])
+	})
+
+	t.Run("should generate a secret RCON password", func(t *testing.T) {
+		password := opts.Env["RCON_PASSWORD"]
+		assert.Len(t, password, 32)
+		assert.Contains(t, rec.Body.String(), password, "the password is returned once on creation")
+
+		var stored models.GameServerEnv
+		require.NoError(t, db.Where("key = ?", "RCON_PASSWORD").First(&stored).Error)
+		assert.Equal(t, password, stored.Value)
+		assert.True(t, stored.IsSecret)
+	})
+
+	t.Run("should create and mount the game's data volumes", func(t *testing.T) {
+		assert.Contains(t, runtime.Volumes, "sabakan-survival-data")
+		assert.Equal(t, []container.VolumeMount{{Name: "sabakan-survival-data", Dest: "/data"}}, opts.Volumes)
+	})
+
+	t.Run("should persist default ports, volumes and container ID", func(t *testing.T) {
+		var server models.GameServer
+		db.Preload("Ports").Preload("Volumes").Where("slug = ?", "survival").First(&server)
+		assert.Equal(t, "container123", server.ContainerID)
+		assert.Equal(t, models.GameServerStatusStopped, server.Status)
+		assert.Len(t, server.Ports, 2)
+		assert.Len(t, server.Volumes, 1)
+	})
+}
+
+func TestGameServerHandler_Create_ProvisionFailure(t *testing.T) {
+	db := setupGameServerTestDB(t)
+
+	runtime := containertest.New()
+	runtime.Errors["Create"] = errors.New("image not known")
+
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+	e := echo.New()
+
+	t.Run("should mark the server as errored", func(t *testing.T) {
+		body, _ := json.Marshal(CreateGameServerRequest{Slug: "broken", Name: "Broken", Game: "minecraft"})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		err := handler.Create(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		var server models.GameServer
+		db.Where("slug = ?", "broken").First(&server)
+		assert.Equal(t, models.GameServerStatusError, server.Status)
+		assert.Empty(t, server.ContainerID)
+	})
+}
+
+func TestGameServerHandler_Create_Placement(t *testing.T) {
+	db := setupGameServerTestDB(t)
+
+	local := containertest.New()
+	local.HostInfo = models.HostInfo{MemoryTotal: 8 << 30, MemoryFree: 1 << 30}
+	remote := containertest.New()
+	remote.HostInfo = models.HostInfo{MemoryTotal: 32 << 30, MemoryFree: 16 << 30}
+
+	worker := models.Node{Name: "worker", Endpoint: "tcp://worker:8080", Enabled: true}
+	db.Create(&worker)
+	nodes := container.NewPool(local)
+	nodes.Set(worker.ID, worker.Name, remote)
+
+	handler := NewGameServerHandler(db, nodes)
+	e := echo.New()
+
+	create := func(reqBody CreateGameServerRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+		assert.NoError(t, handler.Create(c))
+		return rec
+	}
+
+	t.Run("should place the server on the node with the most free memory", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "auto", Name: "Auto", Game: "minecraft"})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var resp models.GameServer
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.NotNil(t, resp.NodeID)
+		assert.Equal(t, worker.ID, *resp.NodeID)
+		require.NotNil(t, resp.Node)
+		assert.Equal(t, "worker", resp.Node.Name)
+		assert.Contains(t, remote.CallLog(), "Create sabakan-auto")
+	})
+
+	t.Run("should place the server on the requested node", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "pinned", Name: "Pinned", Game: "factorio", Node: container.LocalNode})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var server models.GameServer
+		db.Where("slug = ?", "pinned").First(&server)
+		assert.Nil(t, server.NodeID)
+		assert.Contains(t, local.CallLog(), "Create sabakan-pinned")
+	})
+
+	t.Run("should allocate another host port when the default port is taken on the node", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "second", Name: "Second", Game: "minecraft", Node: "worker"})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var server models.GameServer
+		db.Preload("Ports", func(db *gorm.DB) *gorm.DB { return db.Order("container_port") }).Where("slug = ?", "second").First(&server)
+		require.Len(t, server.Ports, 2)
+		assert.Equal(t, 30000, server.Ports[0].HostPort)
+		assert.Equal(t, 25565, server.Ports[0].ContainerPort)
+	})
+
+	t.Run("should reject manual host ports taken on the node", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:  "clash",
+			Name:  "Clash",
+			Game:  "minecraft",
+			Node:  "worker",
+			Ports: []GameServerPortRequest{{HostPort: 25565, ContainerPort: 25565}},
+		})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		var resp ErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "port_conflict", resp.Error)
+		assert.Contains(t, resp.Message, `game server "auto"`)
+	})
+
+	t.Run("should reject unknown nodes", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "lost", Name: "Lost", Game: "minecraft", Node: "missing"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGameServerHandler_Create_UnknownGame(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+
+	e := echo.New()
+
+	t.Run("should reject games that are not registered", func(t *testing.T) {
+		body, _ := json.Marshal(CreateGameServerRequest{Slug: "pong", Name: "Pong", Game: "pong"})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		err := handler.Create(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var count int64
+		db.Model(&models.GameServer{}).Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestGameServerHandler_Create_InvalidConfig(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+
+	e := echo.New()
+
+	t.Run("should reject envs that do not match the game's schema", func(t *testing.T) {
+		body, _ := json.Marshal(CreateGameServerRequest{
+			Slug: "bad-config",
+			Name: "Bad Config",
+			Game: "minecraft",
+			Envs: []GameServerEnvRequest{
+				{Key: "MEMORY", Value: "lots"},
+				{Key: "TYPE", Value: "VANILLA"},
+			},
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		err := handler.Create(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{
+			"error": "validation_error",
+			"message": "Invalid game configuration",
+			"details": [{"field": "MEMORY", "message": "must be a memory size such as 512M or 4G"}]
+		}`, rec.Body.String())
+
+		var count int64
+		db.Model(&models.GameServer{}).Where("slug = ?", "bad-config").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("should mark secret fields as secret", func(t *testing.T) {
+		body, _ := json.Marshal(CreateGameServerRequest{
+			Slug: "secret-config",
+			Name: "Secret Config",
+			Game: "minecraft",
+			Envs: []GameServerEnvRequest{{Key: "RCON_PASSWORD", Value: "hunter2"}},
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		err := handler.Create(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var env models.GameServerEnv
+		db.Where("key = ?", "RCON_PASSWORD").First(&env)
+		assert.True(t, env.IsSecret)
+	})
+}
+
+func TestGameServerHandler_Create_Ports(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+	handler.SetPortRange(40000, 40010)
+
+	e := echo.New()
+
+	create := func(reqBody CreateGameServerRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+		assert.NoError(t, handler.Create(c))
+		return rec
+	}
+
+	hostPorts := func(slug string) []int {
+		var server models.GameServer
+		db.Preload("Ports", func(db *gorm.DB) *gorm.DB { return db.Order("container_port") }).Where("slug = ?", slug).First(&server)
+		ports := make([]int, 0, len(server.Ports))
+		for _, p := range server.Ports {
+			ports = append(ports, p.HostPort)
+		}
+		return ports
+	}
+
+	t.Run("should give the first server the game's default ports", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "first", Name: "First", Game: "minecraft"})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, []int{25565, 25575}, hostPorts("first"))
+	})
+
+	t.Run("should allocate ports from the range for the next server", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "second", Name: "Second", Game: "minecraft"})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, []int{40000, 40001}, hostPorts("second"))
+	})
+
+	t.Run("should allocate requested ports with host port 0", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:  "auto",
+			Name:  "Auto",
+			Game:  "minecraft",
+			Ports: []GameServerPortRequest{{ContainerPort: 25565}},
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, []int{40002}, hostPorts("auto"))
+	})
+
+	t.Run("should reject conflicting host ports", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:  "clash",
+			Name:  "Clash",
+			Game:  "minecraft",
+			Ports: []GameServerPortRequest{{HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}},
+		})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.JSONEq(t, `{
+			"error": "port_conflict",
+			"message": "host port is already in use: 25565/tcp is used by game server \"first\""
+		}`, rec.Body.String())
+
+		var count int64
+		db.Model(&models.GameServer{}).Where("slug = ?", "clash").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("should accept the same port number over another protocol", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:  "bedrock",
+			Name:  "Bedrock",
+			Game:  "minecraft",
+			Ports: []GameServerPortRequest{{HostPort: 25565, ContainerPort: 19132, Protocol: "udp"}},
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("should reject invalid protocols", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:  "sctp",
+			Name:  "SCTP",
+			Game:  "minecraft",
+			Ports: []GameServerPortRequest{{HostPort: 5000, ContainerPort: 5000, Protocol: "sctp"}},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGameServerHandler_Create_Resources(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	runtime := containertest.New()
+	runtime.CreateID = "container123"
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+	e := echo.New()
+
+	create := func(reqBody CreateGameServerRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+		assert.NoError(t, handler.Create(c))
+		return rec
+	}
+
+	t.Run("should create the container with the limits and restart policy", func(t *testing.T) {
+		limits := models.ResourceLimits{MemoryMB: 8192, CPUs: 4, PidsLimit: 2048}
+		rec := create(CreateGameServerRequest{
+			Slug:          "factory",
+			Name:          "Factory",
+			Game:          "satisfactory",
+			Resources:     limits,
+			RestartPolicy: models.RestartPolicyUnlessStopped,
+		})
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		assert.Equal(t, container.UpdateOptions{Resources: limits, RestartPolicy: "unless-stopped"}, runtime.Settings["container123"])
+
+		var server models.GameServer
+		db.Where("slug = ?", "factory").First(&server)
+		assert.Equal(t, limits, server.Resources)
+		assert.Equal(t, models.RestartPolicyUnlessStopped, server.RestartPolicy)
+	})
+
+	t.Run("should default to no restart policy", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "plain", Name: "Plain", Game: "minecraft"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var server models.GameServer
+		db.Where("slug = ?", "plain").First(&server)
+		assert.Equal(t, models.RestartPolicyNo, server.RestartPolicy)
+	})
+
+	t.Run("should reject memory limits below the game's minimum", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:      "small",
+			Name:      "Small",
+			Game:      "satisfactory",
+			Resources: models.ResourceLimits{MemoryMB: 4096},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "at least 8192 MB")
+	})
+
+	t.Run("should reject invalid restart policies", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{Slug: "sometimes", Name: "Sometimes", Game: "minecraft", RestartPolicy: "sometimes"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should reject negative limits", func(t *testing.T) {
+		rec := create(CreateGameServerRequest{
+			Slug:      "negative",
+			Name:      "Negative",
+			Game:      "minecraft",
+			Resources: models.ResourceLimits{CPUs: -1},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGameServerHandler_Update_Resources(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	runtime := containertest.New()
+	runtime.Containers["container123"] = &models.Container{ID: "container123", Name: "sabakan-mc"}
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+	db.Create(&models.GameServer{Slug: "mc", Name: "MC", Game: "minecraft", Image: "itzg/minecraft-server:latest", OwnerID: 1, ContainerID: "container123"})
+
+	e := echo.New()
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/game-servers/mc", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("mc")
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		assert.NoError(t, handler.Update(c))
+		return rec
+	}
+
+	t.Run("should apply new limits to the container", func(t *testing.T) {
+		rec := update(`{"resources":{"memoryMb":2048,"cpus":1.5},"restartPolicy":"on-failure"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Contains(t, runtime.CallLog(), "Update container123")
+		assert.Equal(t, container.UpdateOptions{
+			Resources:     models.ResourceLimits{MemoryMB: 2048, CPUs: 1.5},
+			RestartPolicy: "on-failure",
+		}, runtime.Settings["container123"])
+
+		var server models.GameServer
+		db.Where("slug = ?", "mc").First(&server)
+		assert.Equal(t, 2048, server.Resources.MemoryMB)
+		assert.Equal(t, models.RestartPolicyOnFailure, server.RestartPolicy)
+	})
+
+	t.Run("should keep the limits when only the restart policy changes", func(t *testing.T) {
+		rec := update(`{"restartPolicy":"always"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 2048, runtime.Settings["container123"].Resources.MemoryMB)
+		assert.Equal(t, "always", runtime.Settings["container123"].RestartPolicy)
+	})
+
+	t.Run("should reject limits below the game's minimum without touching the container", func(t *testing.T) {
+		rec := update(`{"resources":{"memoryMb":256}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, 2048, runtime.Settings["container123"].Resources.MemoryMB)
+	})
+
+	t.Run("should report runtime failures", func(t *testing.T) {
+		runtime.Errors["Update"] = errors.New("cgroup v1 does not support updates")
+		defer delete(runtime.Errors, "Update")
+
+		rec := update(`{"resources":{"memoryMb":4096}}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		var server models.GameServer
+		db.Where("slug = ?", "mc").First(&server)
+		assert.Equal(t, 2048, server.Resources.MemoryMB)
 	})
 }
 
 func TestGameServerHandler_Create_InvalidSlug(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	e := echo.New()
 
@@ -128,7 +652,7 @@ func TestGameServerHandler_Create_InvalidSlug(t *testing.T) {
 
 func TestGameServerHandler_Create_DuplicateSlug(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	// Create existing server
 	db.Create(&models.GameServer{
@@ -163,7 +687,7 @@ func TestGameServerHandler_Create_DuplicateSlug(t *testing.T) {
 
 func TestGameServerHandler_List(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	// Create test servers
 	db.Create(&models.GameServer{Slug: "server1", Name: "Server 1", Image: "test:latest", OwnerID: 1})
@@ -192,13 +716,28 @@ func TestGameServerHandler_List(t *testing.T) {
 
 func TestGameServerHandler_Get(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	// Create test server
-	db.Create(&models.GameServer{Slug: "my-server", Name: "My Server", Image: "test:latest", OwnerID: 1})
+	owned := models.GameServer{Slug: "my-server", Name: "My Server", Image: "test:latest", OwnerID: 1}
+	db.Create(&owned)
+	db.Create(&models.GameServerEnv{GameServerID: owned.ID, Key: "RCON_PASSWORD", Value: "hunter2", IsSecret: true})
+	db.Create(&models.GameServerEnv{GameServerID: owned.ID, Key: "MEMORY", Value: "4G"})
+	db.Create(&models.GameServer{Slug: "their-server", Name: "Their Server", Image: "test:latest", OwnerID: 2})
 
 	e := echo.New()
 
+	get := func(slug string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/game-servers/"+slug, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		c.Set(middleware.ContextKeyUserID, uint(1))
+		require.NoError(t, handler.Get(c))
+		return rec
+	}
+
 	t.Run("should get a game server by slug", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/game-servers/my-server", nil)
 		rec := httptest.NewRecorder()
@@ -217,11 +756,30 @@ func TestGameServerHandler_Get(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "my-server", server.Slug)
 	})
+
+	t.Run("should mask secret envs", func(t *testing.T) {
+		rec := get("my-server")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "hunter2")
+
+		var server models.GameServer
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &server))
+		values := make(map[string]string)
+		for _, env := range server.Envs {
+			values[env.Key] = env.Value
+		}
+		assert.Equal(t, map[string]string{"RCON_PASSWORD": "********", "MEMORY": "4G"}, values)
+	})
+
+	t.Run("should not return servers of other users", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("their-server").Code)
+	})
 }
 
 func TestGameServerHandler_Get_NotFound(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	e := echo.New()
 
@@ -242,7 +800,7 @@ func TestGameServerHandler_Get_NotFound(t *testing.T) {
 
 func TestGameServerHandler_Delete(t *testing.T) {
 	db := setupGameServerTestDB(t)
-	handler := NewGameServerHandler(db)
+	handler := newTestGameServerHandler(t, db)
 
 	// Create test server
 	db.Create(&models.GameServer{Slug: "to-delete", Name: "To Delete", Image: "test:latest", OwnerID: 1})
@@ -268,3 +826,317 @@ func TestGameServerHandler_Delete(t *testing.T) {
 		assert.Equal(t, int64(0), count)
 	})
 }
+
+func TestGameServerHandler_Delete_Volumes(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCalls []string
+	}{
+		{
+			name:  "should keep volumes by default",
+			query: "",
+			expectedCalls: []string{
+				"Remove container123",
+			},
+		},
+		{
+			name:  "should purge volumes when requested",
+			query: "?purgeVolumes=true",
+			expectedCalls: []string{
+				"Remove container123",
+				"RemoveVolume sabakan-world-data",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupGameServerTestDB(t)
+			runtime := containertest.New()
+			runtime.Containers["container123"] = &models.Container{ID: "container123", State: models.StateExited}
+			runtime.Volumes["sabakan-world-data"] = nil
+			handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+			server := models.GameServer{Slug: "world", Name: "World", Image: "test:latest", ContainerID: "container123", OwnerID: 1}
+			db.Create(&server)
+			db.Create(&models.GameServerVolume{GameServerID: server.ID, Name: "data", VolumeName: "sabakan-world-data", MountPath: "/data"})
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/game-servers/world"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("slug")
+			c.SetParamValues("world")
+			c.Set(middleware.ContextKeyUserID, uint(1))
+
+			err := handler.Delete(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Equal(t, tt.expectedCalls, runtime.CallLog())
+		})
+	}
+}
+
+// lifecycleRequest invokes a game server lifecycle action for the given slug.
+func lifecycleRequest(t *testing.T, action echo.HandlerFunc, slug string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/game-servers/"+slug, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	c.Set(middleware.ContextKeyUserID, uint(1))
+
+	assert.NoError(t, action(c))
+	return rec
+}
+
+func TestGameServerHandler_Lifecycle(t *testing.T) {
+	db := setupGameServerTestDB(t)
+
+	runtime := containertest.New()
+	runtime.CreateID = "new-container"
+	runtime.Containers["old-container"] = &models.Container{ID: "old-container", Name: "sabakan-lifecycle", State: models.StateExited}
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+	// lifecycleCalls runs an action and returns the runtime calls it made,
+	// leaving out those of readiness probes, which inspect the container and
+	// read its logs in the background.
+	lifecycleCalls := func(action echo.HandlerFunc) (*httptest.ResponseRecorder, []string) {
+		before := len(runtime.CallLog())
+		rec := lifecycleRequest(t, action, "lifecycle")
+		var calls []string
+		for _, call := range runtime.CallLog()[before:] {
+			if !strings.HasPrefix(call, "Get ") && !strings.HasPrefix(call, "Logs ") {
+				calls = append(calls, call)
+			}
+		}
+		return rec, calls
+	}
+
+	server := models.GameServer{
+		Slug:        "lifecycle",
+		Name:        "Lifecycle",
+		Game:        "minecraft",
+		Image:       "itzg/minecraft-server:latest",
+		Status:      models.GameServerStatusStopped,
+		ContainerID: "old-container",
+		OwnerID:     1,
+	}
+	db.Create(&server)
+
+	t.Run("should start the server's container", func(t *testing.T) {
+		rec, calls := lifecycleCalls(handler.Start)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"Start old-container"}, calls)
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, models.GameServerStatusStarting, updated.Status)
+	})
+
+	t.Run("should restart the server's container", func(t *testing.T) {
+		rec, calls := lifecycleCalls(handler.Restart)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{
+			"Stop old-container",
+			"Start old-container",
+		}, calls)
+	})
+
+	t.Run("should recreate a running server and start it again", func(t *testing.T) {
+		rec, calls := lifecycleCalls(handler.Recreate)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{
+			"Stop old-container",
+			"Create sabakan-lifecycle-next",
+			"Remove old-container",
+			"Rename new-container sabakan-lifecycle",
+			"GetImage itzg/minecraft-server:latest",
+			"Start new-container",
+		}, calls)
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, "new-container", updated.ContainerID)
+		assert.Equal(t, models.GameServerStatusStarting, updated.Status)
+	})
+
+	t.Run("should stop the server's container", func(t *testing.T) {
+		rec, calls := lifecycleCalls(handler.Stop)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"Stop new-container"}, calls)
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, models.GameServerStatusStopped, updated.Status)
+	})
+
+	t.Run("should record audit log entries", func(t *testing.T) {
+		var actions []models.AuditLogAction
+		db.Model(&models.AuditLog{}).Where("target_id = ?", server.ID).Order("id").Pluck("action", &actions)
+		assert.Equal(t, []models.AuditLogAction{
+			models.AuditLogActionStart,
+			models.AuditLogActionRestart,
+			models.AuditLogActionRecreate,
+			models.AuditLogActionStop,
+		}, actions)
+	})
+}
+
+func TestGameServerHandler_Stop_Graceful(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	commands := make(chan string, 10)
+	port := fakeRCONServer(t, "hunter2", commands)
+
+	runtime := containertest.New()
+	runtime.Containers["mc"] = &models.Container{ID: "mc", State: models.StateRunning}
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+	server := models.GameServer{
+		Slug:        "mc",
+		Name:        "Minecraft",
+		Game:        "minecraft",
+		Image:       "itzg/minecraft-server:latest",
+		Status:      models.GameServerStatusRunning,
+		ContainerID: "mc",
+		OwnerID:     1,
+	}
+	db.Create(&server)
+	db.Create(&models.GameServerPort{GameServerID: server.ID, HostPort: port, ContainerPort: 25575, Protocol: "tcp"})
+	db.Create(&models.GameServerEnv{GameServerID: server.ID, Key: "RCON_PASSWORD", Value: "hunter2", IsSecret: true})
+
+	t.Run("should save and stop the game before stopping the container", func(t *testing.T) {
+		rec := lifecycleRequest(t, handler.Stop, "mc")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		close(commands)
+		var received []string
+		for command := range commands {
+			received = append(received, command)
+		}
+		assert.Equal(t, []string{"save-all flush", "stop"}, received)
+		assert.Equal(t, []string{"Wait mc", "Stop mc"}, runtime.CallLog())
+
+		var audit models.AuditLog
+		db.Last(&audit)
+		assert.Contains(t, audit.DetailsJSON, `"graceful":true`)
+	})
+}
+
+func TestGameServerHandler_Delete_Graceful(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	commands := make(chan string, 10)
+	port := fakeRCONServer(t, "hunter2", commands)
+
+	runtime := containertest.New()
+	runtime.Containers["mc"] = &models.Container{ID: "mc", State: models.StateRunning}
+	runtime.Containers["stuck"] = &models.Container{ID: "stuck", State: models.StateRunning}
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+	server := models.GameServer{
+		Slug:        "mc",
+		Name:        "Minecraft",
+		Game:        "minecraft",
+		Image:       "itzg/minecraft-server:latest",
+		Status:      models.GameServerStatusRunning,
+		ContainerID: "mc",
+		OwnerID:     1,
+	}
+	db.Create(&server)
+	db.Create(&models.GameServerPort{GameServerID: server.ID, HostPort: port, ContainerPort: 25575, Protocol: "tcp"})
+	db.Create(&models.GameServerEnv{GameServerID: server.ID, Key: "RCON_PASSWORD", Value: "hunter2", IsSecret: true})
+
+	t.Run("should save and stop a running game before removing the container", func(t *testing.T) {
+		rec := lifecycleRequest(t, handler.Delete, "mc")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		close(commands)
+		var received []string
+		for command := range commands {
+			received = append(received, command)
+		}
+		assert.Equal(t, []string{"save-all flush", "stop"}, received)
+		assert.Equal(t, []string{"Wait mc", "Stop mc", "Remove mc"}, runtime.CallLog())
+	})
+
+	t.Run("should force-remove the container if it does not stop", func(t *testing.T) {
+		runtime.Calls = nil
+		runtime.Errors["Stop"] = errors.New("container is stuck")
+		// No RCON port is published, so the graceful shutdown cannot connect either
+		broken := models.GameServer{
+			Slug:        "stuck",
+			Name:        "Stuck",
+			Game:        "minecraft",
+			Image:       "itzg/minecraft-server:latest",
+			Status:      models.GameServerStatusRunning,
+			ContainerID: "stuck",
+			OwnerID:     1,
+		}
+		db.Create(&broken)
+
+		rec := lifecycleRequest(t, handler.Delete, "stuck")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, []string{"Stop stuck", "Remove stuck"}, runtime.CallLog())
+
+		var count int64
+		db.Model(&models.GameServer{}).Where("slug = ?", "stuck").Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestGameServerHandler_Stop_GracefulFailure(t *testing.T) {
+	db := setupGameServerTestDB(t)
+
+	runtime := containertest.New()
+	runtime.Containers["mc"] = &models.Container{ID: "mc", State: models.StateRunning}
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+
+	// No RCON port is published, so the graceful shutdown cannot connect
+	db.Create(&models.GameServer{
+		Slug:        "mc",
+		Name:        "Minecraft",
+		Game:        "minecraft",
+		Image:       "itzg/minecraft-server:latest",
+		Status:      models.GameServerStatusRunning,
+		ContainerID: "mc",
+		OwnerID:     1,
+	})
+
+	t.Run("should fall back to stopping the container", func(t *testing.T) {
+		rec := lifecycleRequest(t, handler.Stop, "mc")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"Stop mc"}, runtime.CallLog())
+
+		var audit models.AuditLog
+		db.Last(&audit)
+		assert.Contains(t, audit.DetailsJSON, `"graceful":false`)
+	})
+}
+
+func TestGameServerHandler_Start_NoContainer(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+
+	db.Create(&models.GameServer{Slug: "no-container", Name: "No Container", Image: "test:latest", OwnerID: 1})
+
+	t.Run("should reject servers without a container", func(t *testing.T) {
+		rec := lifecycleRequest(t, handler.Start, "no-container")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("should return 404 for nonexistent server", func(t *testing.T) {
+		rec := lifecycleRequest(t, handler.Start, "nonexistent")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
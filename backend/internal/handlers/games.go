@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/games"
+)
+
+// GameInfo describes a supported game.
+type GameInfo struct {
+	ID string `json:"id"`
+	games.Metadata
+	Image string            `json:"image"`
+	Ports []GamePortInfo    `json:"ports"`
+	Env   map[string]string `json:"env"`
+	// Requirements are the smallest resource limits servers of the game accept.
+	Requirements games.Requirements `json:"requirements"`
+}
+
+// GamePortInfo describes a named default port of a game.
+type GamePortInfo struct {
+	Name     string `json:"name"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// GamesHandler handles requests about the supported games.
+type GamesHandler struct{}
+
+// NewGamesHandler creates a new games handler.
+func NewGamesHandler() *GamesHandler {
+	return &GamesHandler{}
+}
+
+// List handles GET /api/games.
+func (h *GamesHandler) List(c echo.Context) error {
+	names := games.List()
+	infos := make([]GameInfo, 0, len(names))
+	for _, name := range names {
+		gameHandler, _ := games.Get(name)
+		infos = append(infos, gameInfo(name, gameHandler))
+	}
+	return c.JSON(http.StatusOK, infos)
+}
+
+// Get handles GET /api/games/:id.
+func (h *GamesHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	gameHandler, ok := games.Get(id)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Game not found")
+	}
+	return c.JSON(http.StatusOK, gameInfo(id, gameHandler))
+}
+
+// Schema handles GET /api/games/:id/schema.
+func (h *GamesHandler) Schema(c echo.Context) error {
+	gameHandler, ok := games.Get(c.Param("id"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Game not found")
+	}
+	return c.JSON(http.StatusOK, gameHandler.GetConfigSchema())
+}
+
+// gameInfo builds the API representation of a game handler.
+func gameInfo(id string, gameHandler games.GameHandler) GameInfo {
+	defaultPorts := gameHandler.GetDefaultPorts()
+	ports := make([]GamePortInfo, 0, len(defaultPorts))
+	for name, port := range defaultPorts {
+		ports = append(ports, GamePortInfo{
+			Name:     name,
+			Port:     port,
+			Protocol: games.PortProtocol(gameHandler, name),
+		})
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+
+	return GameInfo{
+		ID:           id,
+		Metadata:     gameHandler.GetMetadata(),
+		Image:        gameHandler.GetDefaultImage(),
+		Ports:        ports,
+		Env:          gameHandler.GetDefaultEnv(),
+		Requirements: games.MinimumRequirements(gameHandler),
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/games"
+)
+
+func TestGamesHandler_List(t *testing.T) {
+	handler := NewGamesHandler()
+	e := echo.New()
+
+	t.Run("should list every registered game", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/games", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.List(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var infos []GameInfo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &infos))
+		assert.Len(t, infos, len(games.Registry))
+		for _, info := range infos {
+			assert.NotEmpty(t, info.DisplayName, info.ID)
+			assert.NotEmpty(t, info.Image, info.ID)
+		}
+	})
+}
+
+func TestGamesHandler_Get(t *testing.T) {
+	handler := NewGamesHandler()
+	e := echo.New()
+
+	t.Run("should return the game with named ports", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/palworld", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("palworld")
+
+		err := handler.Get(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var info GameInfo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+		assert.Equal(t, "palworld", info.ID)
+		assert.Equal(t, "Palworld", info.DisplayName)
+		assert.Equal(t, "thijsvanloef/palworld-server-docker:latest", info.Image)
+		assert.Contains(t, info.Ports, GamePortInfo{Name: "game", Port: 8211, Protocol: "udp"})
+		// The admin password is generated per server, never a fixed default
+		assert.Empty(t, info.Env["ADMIN_PASSWORD"])
+	})
+
+	t.Run("should return 404 for unknown games", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/pong", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("pong")
+
+		err := handler.Get(c)
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, he.Code)
+	})
+}
+
+func TestGamesHandler_Schema(t *testing.T) {
+	handler := NewGamesHandler()
+	e := echo.New()
+
+	t.Run("should return the game's config schema", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/minecraft/schema", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("minecraft")
+
+		err := handler.Schema(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var schema games.ConfigSchema
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schema))
+		field, ok := schema.Field("RCON_PASSWORD")
+		require.True(t, ok)
+		assert.True(t, field.Secret)
+	})
+
+	t.Run("should return 404 for unknown games", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/pong/schema", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("pong")
+
+		err := handler.Schema(c)
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, he.Code)
+	})
+}
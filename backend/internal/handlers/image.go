@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// ImageHandler handles container image HTTP requests.
+// Images are managed on the node given by the node query parameter, the local node by default.
+type ImageHandler struct {
+	nodes *container.Pool
+}
+
+// NewImageHandler creates a new ImageHandler.
+func NewImageHandler(nodes *container.Pool) *ImageHandler {
+	return &ImageHandler{nodes: nodes}
+}
+
+// PullImageRequest represents the request body for pulling an image.
+type PullImageRequest struct {
+	Reference string `json:"reference"`
+}
+
+// List handles GET /api/images.
+func (h *ImageHandler) List(c echo.Context) error {
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+	images, err := runtime.ListImages(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, images)
+}
+
+// Get handles GET /api/images/:id.
+// References containing slashes must be URL-encoded (e.g. itzg%2Fminecraft-server).
+func (h *ImageHandler) Get(c echo.Context) error {
+	name, err := imageParam(c)
+	if err != nil {
+		return err
+	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	image, err := runtime.GetImage(c.Request().Context(), name)
+	if err != nil {
+		return imageError(err)
+	}
+	return c.JSON(http.StatusOK, image)
+}
+
+// Pull handles POST /api/images/pull.
+// Progress is streamed as Server-Sent Events: "progress" events while the
+// layers are copied, then a "done" event with the image ID or an "error" event.
+func (h *ImageHandler) Pull(c echo.Context) error {
+	var req PullImageRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Reference == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "image reference is required")
+	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	id, err := runtime.PullImage(c.Request().Context(), req.Reference, func(progress models.ImagePullProgress) error {
+		return writeEvent(res, "progress", progress)
+	})
+	if err != nil {
+		// Headers are already sent, so report the failure in-stream.
+		_ = writeEvent(res, "error", map[string]string{"message": err.Error()})
+		return nil
+	}
+	_ = writeEvent(res, "done", map[string]string{"id": id, "reference": req.Reference})
+	return nil
+}
+
+// Delete handles DELETE /api/images/:id.
+// Images used by containers are only removed with ?force=true.
+func (h *ImageHandler) Delete(c echo.Context) error {
+	name, err := imageParam(c)
+	if err != nil {
+		return err
+	}
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+
+	force := c.QueryParam("force") == "true"
+	if err := runtime.RemoveImage(c.Request().Context(), name, force); err != nil {
+		return imageError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Prune handles POST /api/images/prune and removes dangling images.
+func (h *ImageHandler) Prune(c echo.Context) error {
+	runtime, err := nodeRuntime(c, h.nodes)
+	if err != nil {
+		return err
+	}
+	report, err := runtime.PruneImages(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// imageParam returns the unescaped image ID or reference from the path.
+func imageParam(c echo.Context) (string, error) {
+	name, err := url.PathUnescape(c.Param("id"))
+	if err != nil || name == "" {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "image ID is required")
+	}
+	return name, nil
+}
+
+// imageError maps image service errors to HTTP errors.
+func imageError(err error) error {
+	switch {
+	case errors.Is(err, container.ErrImageNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, container.ErrImageInUse):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/container/containertest"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestImageHandler_List(t *testing.T) {
+	runtime := containertest.New()
+	runtime.Images["img1"] = &models.Image{ID: "img1", Tags: []string{"docker.io/itzg/minecraft-server:latest"}, Size: 512, Containers: 1}
+	runtime.Images["img2"] = &models.Image{ID: "img2", Tags: []string{}, Size: 128, Dangling: true}
+
+	handler := NewImageHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	t.Run("should list images", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/images", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.List(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var images []models.Image
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &images))
+		byID := make(map[string]models.Image)
+		for _, image := range images {
+			byID[image.ID] = image
+		}
+		if assert.Len(t, byID, 2) {
+			assert.Equal(t, []string{"docker.io/itzg/minecraft-server:latest"}, byID["img1"].Tags)
+			assert.Equal(t, 1, byID["img1"].Containers)
+			assert.Empty(t, byID["img2"].Tags)
+			assert.True(t, byID["img2"].Dangling)
+		}
+	})
+}
+
+func TestImageHandler_Get(t *testing.T) {
+	runtime := containertest.New()
+	runtime.Images["itzg/minecraft-server:latest"] = &models.Image{ID: "img1", Tags: []string{"docker.io/itzg/minecraft-server:latest"}, Size: 512}
+
+	handler := NewImageHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	get := func(id string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodGet, "/api/images/"+id, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return rec, handler.Get(c)
+	}
+
+	t.Run("should inspect an URL-encoded reference", func(t *testing.T) {
+		rec, err := get("itzg%2Fminecraft-server:latest")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var image models.Image
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &image))
+		assert.Equal(t, "img1", image.ID)
+	})
+
+	t.Run("should return 404 for a missing image", func(t *testing.T) {
+		_, err := get("missing")
+		he, ok := err.(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, http.StatusNotFound, he.Code)
+		}
+	})
+}
+
+func TestImageHandler_Pull(t *testing.T) {
+	runtime := containertest.New()
+	handler := NewImageHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	pull := func(body string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodPost, "/api/images/pull", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		return rec, handler.Pull(c)
+	}
+
+	t.Run("should stream pull progress", func(t *testing.T) {
+		rec, err := pull(`{"reference":"itzg/minecraft-server:latest"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Body.String(), "event: progress\ndata: {\"message\":\"Pulled itzg/minecraft-server:latest\"}")
+		assert.Contains(t, rec.Body.String(), "event: done\ndata: {\"id\":\"sha256:itzg/minecraft-server:latest\"")
+	})
+
+	t.Run("should report pull failures in-stream", func(t *testing.T) {
+		runtime.Errors["PullImage"] = errors.New("manifest unknown")
+		defer delete(runtime.Errors, "PullImage")
+
+		rec, err := pull(`{"reference":"nope:latest"}`)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "event: error\n")
+		assert.Contains(t, rec.Body.String(), "manifest unknown")
+		assert.NotContains(t, rec.Body.String(), "event: done")
+	})
+
+	t.Run("should require a reference", func(t *testing.T) {
+		_, err := pull(`{}`)
+		he, ok := err.(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, he.Code)
+		}
+	})
+}
+
+func TestImageHandler_Delete(t *testing.T) {
+	runtime := containertest.New()
+	runtime.Images["unused"] = &models.Image{ID: "unused"}
+	runtime.Images["used"] = &models.Image{ID: "used", Containers: 1}
+
+	handler := NewImageHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	remove := func(id, query string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/images/"+id+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return rec, handler.Delete(c)
+	}
+
+	t.Run("should remove an image", func(t *testing.T) {
+		rec, err := remove("unused", "")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("should return 409 for an image in use", func(t *testing.T) {
+		_, err := remove("used", "")
+		he, ok := err.(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, http.StatusConflict, he.Code)
+		}
+	})
+
+	t.Run("should remove an image in use with force", func(t *testing.T) {
+		rec, err := remove("used", "?force=true")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, runtime.Images)
+	})
+}
+
+func TestImageHandler_Prune(t *testing.T) {
+	runtime := containertest.New()
+	runtime.Images["img1"] = &models.Image{ID: "img1", Tags: []string{"mc:latest"}}
+	runtime.Images["img2"] = &models.Image{ID: "img2", Size: 128, Dangling: true}
+	runtime.Images["img3"] = &models.Image{ID: "img3", Size: 64, Dangling: true}
+
+	handler := NewImageHandler(container.NewPool(runtime))
+	e := echo.New()
+
+	t.Run("should prune dangling images", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/images/prune", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Prune(c)
+		assert.NoError(t, err)
+
+		var report models.ImagePruneReport
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.ElementsMatch(t, []string{"img2", "img3"}, report.Deleted)
+		assert.Equal(t, uint64(192), report.ReclaimedSpace)
+	})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
+)
+
+// Reasons of failed logins recorded in the audit log.
+const (
+	loginFailureInvalidCredentials = "invalid_credentials"
+	loginFailureAccountDisabled    = "account_disabled"
+	loginFailureInvalidCode        = "invalid_code"
+	loginFailureLocked             = "locked"
+)
+
+const (
+	// loginMaxFailuresPerUser is how many failed logins lock a username.
+	loginMaxFailuresPerUser = 5
+	// loginMaxFailuresPerIP is how many failed logins lock a client IP. It is
+	// higher, since an IP may be shared by a whole household.
+	loginMaxFailuresPerIP = 20
+	// loginLockoutBase is the first lockout, doubled with every further failure.
+	loginLockoutBase = 30 * time.Second
+	// loginLockoutMax caps the lockout.
+	loginLockoutMax = time.Hour
+	// loginFailureWindow is how long failed logins are counted.
+	loginFailureWindow = 24 * time.Hour
+)
+
+// loginLimiter locks usernames and client IPs out of login after repeated
+// failures, for exponentially longer with every further failure.
+type loginLimiter struct {
+	store redis.CounterStore
+}
+
+// loginUserKey returns the key under which failed logins of a username are counted.
+func loginUserKey(username string) string {
+	return "login:user:" + strings.ToLower(username)
+}
+
+// loginIPKey returns the key under which failed logins from an IP are counted.
+func loginIPKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// lockout returns how long to lock a key out after failures, if they reached maxFailures.
+func lockout(failures, maxFailures int64) time.Duration {
+	if failures < maxFailures {
+		return 0
+	}
+	wait := loginLockoutBase
+	for range failures - maxFailures {
+		wait *= 2
+		if wait >= loginLockoutMax {
+			return loginLockoutMax
+		}
+	}
+	return wait
+}
+
+// lockedFor returns how long logins of a username or from an IP stay locked.
+func (l *loginLimiter) lockedFor(ctx context.Context, username, ip string) (time.Duration, error) {
+	if l.store == nil {
+		return 0, nil
+	}
+
+	userWait, err := l.store.LockedFor(ctx, loginUserKey(username))
+	if err != nil {
+		return 0, err
+	}
+	ipWait, err := l.store.LockedFor(ctx, loginIPKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	return max(userWait, ipWait), nil
+}
+
+// fail records a failed login and returns how long it locked the username or IP, if at all.
+func (l *loginLimiter) fail(ctx context.Context, username, ip string) (time.Duration, error) {
+	if l.store == nil {
+		return 0, nil
+	}
+
+	var wait time.Duration
+	for _, key := range []struct {
+		name        string
+		maxFailures int64
+	}{
+		{loginUserKey(username), loginMaxFailuresPerUser},
+		{loginIPKey(ip), loginMaxFailuresPerIP},
+	} {
+		failures, _, err := l.store.Increment(ctx, key.name, loginFailureWindow)
+		if err != nil {
+			return 0, err
+		}
+		if keyWait := lockout(failures, key.maxFailures); keyWait > 0 {
+			if err := l.store.Lock(ctx, key.name, keyWait); err != nil {
+				return 0, err
+			}
+			wait = max(wait, keyWait)
+		}
+	}
+	return wait, nil
+}
+
+// succeed forgets the failed logins of a username. Failures from the IP are
+// kept, so that logging into one account does not allow guessing others.
+func (l *loginLimiter) succeed(ctx context.Context, username string) error {
+	if l.store == nil {
+		return nil
+	}
+	return l.store.ResetCounter(ctx, loginUserKey(username))
+}
+
+// tooManyAttempts responds to a login while it is locked.
+func tooManyAttempts(c echo.Context, wait time.Duration) error {
+	c.Response().Header().Set("Retry-After", middleware.RetryAfter(wait))
+	return c.JSON(http.StatusTooManyRequests, ErrorResponse{
+		Error:   "too_many_attempts",
+		Message: "Too many failed login attempts, try again later",
+	})
+}
+
+// loginLocked responds with 429 and records the attempt if logins of a
+// username or from the client IP are locked. It returns false otherwise.
+func (h *AuthHandler) loginLocked(c echo.Context, userID uint, username string) (bool, error) {
+	wait, err := h.limiter.lockedFor(c.Request().Context(), username, c.RealIP())
+	if err != nil {
+		slog.Warn("Failed to check login lockout", "username", username, "error", err)
+		return false, nil
+	}
+	if wait <= 0 {
+		return false, nil
+	}
+	h.loginFailed(c, userID, username, loginFailureLocked)
+	return true, tooManyAttempts(c, wait)
+}
+
+// loginFailed records a failed login in the audit log and counts it towards a
+// lockout. userID is 0 if the username does not exist.
+func (h *AuthHandler) loginFailed(c echo.Context, userID uint, username, reason string) {
+	recordAudit(h.db, c, models.AuditLogTargetUser, userID, models.AuditLogActionLoginFailed, map[string]any{
+		"username": username,
+		"reason":   reason,
+	})
+	if reason == loginFailureLocked {
+		return
+	}
+	if wait, err := h.limiter.fail(c.Request().Context(), username, c.RealIP()); err != nil {
+		slog.Warn("Failed to count failed login", "username", username, "error", err)
+	} else if wait > 0 {
+		slog.Warn("Login locked after repeated failures", "username", username, "ip", c.RealIP(), "lockout", wait)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
+)
+
+// attemptLogin logs in from an IP and returns the response.
+func attemptLogin(t *testing.T, handler *AuthHandler, username, password, ip string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"`+username+`","password":"`+password+`"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = ip + ":12345"
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.Login(echo.New().NewContext(req, rec)))
+	return rec
+}
+
+func TestLockout(t *testing.T) {
+	t.Run("should lock out exponentially longer after the maximum failures", func(t *testing.T) {
+		assert.Zero(t, lockout(4, 5))
+		assert.Equal(t, 30*time.Second, lockout(5, 5))
+		assert.Equal(t, time.Minute, lockout(6, 5))
+		assert.Equal(t, 2*time.Minute, lockout(7, 5))
+	})
+
+	t.Run("should cap the lockout", func(t *testing.T) {
+		assert.Equal(t, time.Hour, lockout(12, 5))
+		assert.Equal(t, time.Hour, lockout(1000, 5))
+	})
+}
+
+func TestAuthHandler_Login_Lockout(t *testing.T) {
+	db := setupAuthTestDB(t)
+	jwtManager := auth.NewJWTManager(testJWTSecret, 15*time.Minute, 7*24*time.Hour)
+	store := redis.NewMemorySessionStore(redis.DefaultCleanupInterval)
+	defer store.Close()
+	handler := NewAuthHandler(db, jwtManager, store)
+	loginForTest(t, handler, "carol")
+	loginForTest(t, handler, "dave")
+
+	t.Run("should forget failures after a successful login", func(t *testing.T) {
+		for range loginMaxFailuresPerUser - 1 {
+			assert.Equal(t, http.StatusUnauthorized, attemptLogin(t, handler, "dave", "wrong", "198.51.100.1").Code)
+		}
+		assert.Equal(t, http.StatusOK, attemptLogin(t, handler, "dave", "testPassword123!", "198.51.100.1").Code)
+
+		assert.Equal(t, http.StatusUnauthorized, attemptLogin(t, handler, "dave", "wrong", "198.51.100.1").Code)
+		assert.Equal(t, http.StatusOK, attemptLogin(t, handler, "dave", "testPassword123!", "198.51.100.1").Code)
+	})
+
+	t.Run("should lock a username out after repeated failures", func(t *testing.T) {
+		for range loginMaxFailuresPerUser {
+			assert.Equal(t, http.StatusUnauthorized, attemptLogin(t, handler, "carol", "wrong", "198.51.100.2").Code)
+		}
+
+		// Even the right password is refused, from any IP
+		rec := attemptLogin(t, handler, "carol", "testPassword123!", "198.51.100.3")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+		// Other users are not affected
+		assert.Equal(t, http.StatusOK, attemptLogin(t, handler, "dave", "testPassword123!", "198.51.100.2").Code)
+	})
+
+	t.Run("should record failed attempts in the audit log", func(t *testing.T) {
+		var user models.User
+		require.NoError(t, db.Where("username = ?", "carol").First(&user).Error)
+
+		var logs []models.AuditLog
+		require.NoError(t, db.Where("action = ? AND target_id = ?", models.AuditLogActionLoginFailed, user.ID).Order("id").Find(&logs).Error)
+		require.Len(t, logs, loginMaxFailuresPerUser)
+		assert.Equal(t, models.AuditLogTargetUser, logs[0].TargetType)
+		assert.Equal(t, "198.51.100.2", logs[0].IPAddress)
+		assert.Contains(t, logs[0].DetailsJSON, loginFailureInvalidCredentials)
+
+		var locked models.AuditLog
+		require.NoError(t, db.Where("action = ? AND details_json LIKE ?", models.AuditLogActionLoginFailed, "%"+loginFailureLocked+"%").First(&locked).Error)
+		assert.Contains(t, locked.DetailsJSON, "carol")
+	})
+
+	t.Run("should lock an IP out after failures across usernames", func(t *testing.T) {
+		for i := range loginMaxFailuresPerIP {
+			rec := attemptLogin(t, handler, "nobody"+strconv.Itoa(i), "wrong", "203.0.113.1")
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, attemptLogin(t, handler, "dave", "testPassword123!", "203.0.113.1").Code)
+		assert.Equal(t, http.StatusOK, attemptLogin(t, handler, "dave", "testPassword123!", "203.0.113.2").Code)
+
+		var unknown models.AuditLog
+		require.NoError(t, db.Where("action = ? AND details_json LIKE ?", models.AuditLogActionLoginFailed, "%nobody0%").First(&unknown).Error)
+		assert.Zero(t, unknown.TargetID)
+	})
+
+	t.Run("should count wrong 2FA codes towards the lockout", func(t *testing.T) {
+		loginForTest(t, handler, "erin")
+		var user models.User
+		require.NoError(t, db.Where("username = ?", "erin").First(&user).Error)
+		secret, err := auth.GenerateTOTPSecret()
+		require.NoError(t, err)
+		require.NoError(t, db.Model(&user).Updates(map[string]any{"totp_secret": secret, "totp_enabled": true}).Error)
+
+		challenge := loginChallengeForTest(t, handler, "erin")
+		body := `{"challenge_token":"` + challenge.ChallengeToken + `","recovery_code":"wrong"}`
+		for range loginMaxFailuresPerUser {
+			assert.Equal(t, http.StatusUnauthorized, twoFactorRequest(t, handler.VerifyTwoFactor, body, 0).Code)
+		}
+
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+		require.NoError(t, err)
+		rec := twoFactorRequest(t, handler.VerifyTwoFactor, `{"challenge_token":"`+challenge.ChallengeToken+`","code":"`+code+`"}`, 0)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	})
+
+	t.Run("should count wrong codes when changing 2FA settings", func(t *testing.T) {
+		loginForTest(t, handler, "frank")
+		var user models.User
+		require.NoError(t, db.Where("username = ?", "frank").First(&user).Error)
+		secret, err := auth.GenerateTOTPSecret()
+		require.NoError(t, err)
+		require.NoError(t, db.Model(&user).Updates(map[string]any{"totp_secret": secret, "totp_enabled": true}).Error)
+
+		for range loginMaxFailuresPerUser {
+			rec := twoFactorRequest(t, handler.RegenerateRecoveryCodes, `{"recovery_code":"wrong"}`, user.ID)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, twoFactorRequest(t, handler.DisableTwoFactor, `{"code":"`+code+`"}`, user.ID).Code)
+		assert.Equal(t, http.StatusTooManyRequests, twoFactorRequest(t, handler.RegenerateRecoveryCodes, `{"code":"`+code+`"}`, user.ID).Code)
+	})
+
+	t.Run("should count wrong codes when enabling 2FA", func(t *testing.T) {
+		loginForTest(t, handler, "grace")
+		var user models.User
+		require.NoError(t, db.Where("username = ?", "grace").First(&user).Error)
+		secret, err := auth.GenerateTOTPSecret()
+		require.NoError(t, err)
+		require.NoError(t, db.Model(&user).Update("totp_secret", secret).Error)
+
+		for range loginMaxFailuresPerUser {
+			assert.Equal(t, http.StatusUnauthorized, twoFactorRequest(t, handler.EnableTwoFactor, `{"code":"000000"}`, user.ID).Code)
+		}
+
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+		require.NoError(t, err)
+		rec := twoFactorRequest(t, handler.EnableTwoFactor, `{"code":"`+code+`"}`, user.ID)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+		require.NoError(t, db.First(&user, user.ID).Error)
+		assert.False(t, user.TOTPEnabled)
+	})
+}
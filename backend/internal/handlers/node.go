@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// NodeHandler handles HTTP requests for the nodes game servers run on.
+type NodeHandler struct {
+	db    *gorm.DB
+	nodes *container.Pool
+	// newRuntime creates the runtime of a node, container.NewRuntime unless replaced in tests.
+	newRuntime func(kind, endpoint string, opts container.ConnectOptions) (container.Runtime, error)
+}
+
+// NewNodeHandler creates a new node handler.
+func NewNodeHandler(db *gorm.DB, nodes *container.Pool) *NodeHandler {
+	return &NodeHandler{db: db, nodes: nodes, newRuntime: container.NewRuntime}
+}
+
+// CreateNodeRequest represents the request body for adding a node.
+type CreateNodeRequest struct {
+	Name          string            `json:"name"`
+	Runtime       string            `json:"runtime"`
+	Endpoint      string            `json:"endpoint"`
+	TLSCACert     string            `json:"tlsCaCert"`
+	TLSCert       string            `json:"tlsCert"`
+	TLSKey        string            `json:"tlsKey"`
+	TLSInsecure   bool              `json:"tlsInsecure"`
+	SSHKeyPath    string            `json:"sshKeyPath"`
+	SSHKnownHosts string            `json:"sshKnownHosts"`
+	Labels        map[string]string `json:"labels"`
+	MemoryMB      int               `json:"memoryMb"`
+	MaxServers    int               `json:"maxServers"`
+	Enabled       *bool             `json:"enabled"`
+}
+
+// UpdateNodeRequest represents the request body for updating a node.
+type UpdateNodeRequest struct {
+	Runtime       *string            `json:"runtime"`
+	Endpoint      *string            `json:"endpoint"`
+	TLSCACert     *string            `json:"tlsCaCert"`
+	TLSCert       *string            `json:"tlsCert"`
+	TLSKey        *string            `json:"tlsKey"`
+	TLSInsecure   *bool              `json:"tlsInsecure"`
+	SSHKeyPath    *string            `json:"sshKeyPath"`
+	SSHKnownHosts *string            `json:"sshKnownHosts"`
+	Labels        *map[string]string `json:"labels"`
+	MemoryMB      *int               `json:"memoryMb"`
+	MaxServers    *int               `json:"maxServers"`
+	Enabled       *bool              `json:"enabled"`
+}
+
+// NodeResponse is a node with its current state.
+type NodeResponse struct {
+	models.Node
+	// Servers is the number of game servers placed on the node.
+	Servers int64 `json:"servers"`
+	// Host describes the machine; it is omitted when the node is unreachable.
+	Host *models.HostInfo `json:"host,omitempty"`
+	// Error explains why the node is unreachable.
+	Error string `json:"error,omitempty"`
+}
+
+// List handles GET /api/nodes.
+// The local node from config.toml is listed first with ID 0.
+func (h *NodeHandler) List(c echo.Context) error {
+	var nodes []models.Node
+	if err := h.db.Order("name").Find(&nodes).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch nodes")
+	}
+
+	ctx := c.Request().Context()
+	result := []NodeResponse{h.status(ctx, models.Node{Name: container.LocalNode, Enabled: true})}
+	for _, node := range nodes {
+		result = append(result, h.status(ctx, node))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Get handles GET /api/nodes/:id.
+func (h *NodeHandler) Get(c echo.Context) error {
+	node, err := h.find(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, h.status(c.Request().Context(), *node))
+}
+
+// Create handles POST /api/nodes.
+// The node must be reachable before it is added.
+func (h *NodeHandler) Create(c echo.Context) error {
+	var req CreateNodeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if !slugPattern.MatchString(req.Name) || req.Name == container.LocalNode {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name must contain only lowercase letters, numbers, and hyphens and must not be \"local\"")
+	}
+	var count int64
+	h.db.Model(&models.Node{}).Where("name = ?", req.Name).Count(&count)
+	if count > 0 {
+		return echo.NewHTTPError(http.StatusConflict, "A node with this name already exists")
+	}
+
+	node := models.Node{
+		Name:          req.Name,
+		Runtime:       req.Runtime,
+		Endpoint:      req.Endpoint,
+		TLSCACert:     req.TLSCACert,
+		TLSCert:       req.TLSCert,
+		TLSKey:        req.TLSKey,
+		TLSInsecure:   req.TLSInsecure,
+		SSHKeyPath:    req.SSHKeyPath,
+		SSHKnownHosts: req.SSHKnownHosts,
+		Labels:        req.Labels,
+		MemoryMB:      req.MemoryMB,
+		MaxServers:    req.MaxServers,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+	}
+	if node.Runtime == "" {
+		node.Runtime = container.RuntimePodman
+	}
+	runtime, err := h.connect(c.Request().Context(), node)
+	if err != nil {
+		return err
+	}
+
+	if err := h.db.Create(&node).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create node")
+	}
+	// GORM skips zero values that have a default, so persist a disabled node explicitly
+	if !node.Enabled {
+		h.db.Model(&node).Update("enabled", false)
+	}
+	h.nodes.Set(node.ID, node.Name, runtime)
+
+	return c.JSON(http.StatusCreated, h.status(c.Request().Context(), node))
+}
+
+// Update handles PUT /api/nodes/:id.
+// Connection changes are verified before they are saved.
+func (h *NodeHandler) Update(c echo.Context) error {
+	node, err := h.find(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateNodeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	// Update only provided fields
+	if req.Runtime != nil {
+		node.Runtime = *req.Runtime
+	}
+	if req.Endpoint != nil {
+		node.Endpoint = *req.Endpoint
+	}
+	if req.TLSCACert != nil {
+		node.TLSCACert = *req.TLSCACert
+	}
+	if req.TLSCert != nil {
+		node.TLSCert = *req.TLSCert
+	}
+	if req.TLSKey != nil {
+		node.TLSKey = *req.TLSKey
+	}
+	if req.TLSInsecure != nil {
+		node.TLSInsecure = *req.TLSInsecure
+	}
+	if req.SSHKeyPath != nil {
+		node.SSHKeyPath = *req.SSHKeyPath
+	}
+	if req.SSHKnownHosts != nil {
+		node.SSHKnownHosts = *req.SSHKnownHosts
+	}
+	if req.Labels != nil {
+		node.Labels = *req.Labels
+	}
+	if req.MemoryMB != nil {
+		node.MemoryMB = *req.MemoryMB
+	}
+	if req.MaxServers != nil {
+		node.MaxServers = *req.MaxServers
+	}
+	if req.Enabled != nil {
+		node.Enabled = *req.Enabled
+	}
+
+	runtime, err := h.connect(c.Request().Context(), *node)
+	if err != nil {
+		return err
+	}
+
+	if err := h.db.Save(node).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update node")
+	}
+	h.nodes.Set(node.ID, node.Name, runtime)
+
+	return c.JSON(http.StatusOK, h.status(c.Request().Context(), *node))
+}
+
+// Delete handles DELETE /api/nodes/:id.
+// Nodes that still host game servers cannot be removed.
+func (h *NodeHandler) Delete(c echo.Context) error {
+	node, err := h.find(c)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	h.db.Model(&models.GameServer{}).Where("node_id = ?", node.ID).Count(&count)
+	if count > 0 {
+		return echo.NewHTTPError(http.StatusConflict, "Node still hosts game servers")
+	}
+
+	if err := h.db.Delete(node).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete node")
+	}
+	h.nodes.Remove(node.ID)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// find loads the node named by the id parameter.
+func (h *NodeHandler) find(c echo.Context) (*models.Node, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid node ID")
+	}
+
+	var node models.Node
+	if err := h.db.First(&node, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "Node not found")
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch node")
+	}
+	return &node, nil
+}
+
+// connect creates the runtime of a node and checks that it is reachable.
+func (h *NodeHandler) connect(ctx context.Context, node models.Node) (container.Runtime, error) {
+	if node.Endpoint == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Endpoint is required")
+	}
+
+	runtime, err := h.newRuntime(node.Runtime, node.Endpoint, container.ConnectOptions{
+		TLSCACert:     node.TLSCACert,
+		TLSCert:       node.TLSCert,
+		TLSKey:        node.TLSKey,
+		TLSInsecure:   node.TLSInsecure,
+		SSHKeyPath:    node.SSHKeyPath,
+		SSHKnownHosts: node.SSHKnownHosts,
+	})
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := runtime.Info(ctx); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadGateway, "Failed to connect to node: "+err.Error())
+	}
+	return runtime, nil
+}
+
+// status returns a node with its server count and host information.
+func (h *NodeHandler) status(ctx context.Context, node models.Node) NodeResponse {
+	resp := NodeResponse{Node: node}
+
+	var nodeID *uint
+	if node.ID != 0 {
+		nodeID = &node.ID
+	}
+	query := h.db.Model(&models.GameServer{})
+	if nodeID == nil {
+		query = query.Where("node_id IS NULL")
+	} else {
+		query = query.Where("node_id = ?", *nodeID)
+	}
+	query.Count(&resp.Servers)
+
+	runtime, err := h.nodes.Runtime(nodeID)
+	if err == nil {
+		resp.Host, err = runtime.Info(ctx)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/container/containertest"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// setupNodeTestDB creates an in-memory SQLite database for testing.
+func setupNodeTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err, "Failed to create test database")
+
+	err = db.AutoMigrate(&models.Node{}, &models.GameServer{})
+	require.NoError(t, err, "Failed to migrate")
+
+	return db
+}
+
+func TestNodeHandler_Create(t *testing.T) {
+	db := setupNodeTestDB(t)
+	nodes := container.NewPool(containertest.New())
+	handler := NewNodeHandler(db, nodes)
+	e := echo.New()
+
+	worker := containertest.New()
+	worker.HostInfo = models.HostInfo{Hostname: "worker", CPUs: 8, MemoryTotal: 16 << 30, Version: "5.0.0"}
+	offline := containertest.New()
+	offline.Errors["Info"] = fmt.Errorf("connection refused")
+	endpoints := map[string]*containertest.Runtime{
+		"tcp://worker:8080":  worker,
+		"tcp://offline:8080": offline,
+	}
+	handler.newRuntime = func(kind, endpoint string, opts container.ConnectOptions) (container.Runtime, error) {
+		// Reject unknown runtimes like a real connection
+		if _, err := container.NewRuntime(kind, endpoint, opts); err != nil {
+			return nil, err
+		}
+		return endpoints[endpoint], nil
+	}
+
+	create := func(body string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodPost, "/api/nodes", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return rec, handler.Create(e.NewContext(req, rec))
+	}
+
+	t.Run("should add a reachable node to the pool", func(t *testing.T) {
+		rec, err := create(`{"name":"worker","endpoint":"tcp://worker:8080","labels":{"region":"tokyo"},"maxServers":4}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var resp NodeResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "worker", resp.Name)
+		assert.Equal(t, container.RuntimePodman, resp.Runtime)
+		assert.Equal(t, "tokyo", resp.Labels["region"])
+		require.NotNil(t, resp.Host)
+		assert.Equal(t, 8, resp.Host.CPUs)
+
+		member, err := nodes.Lookup("worker")
+		require.NoError(t, err)
+		assert.Equal(t, resp.ID, *member.ID)
+	})
+
+	t.Run("should reject duplicate names", func(t *testing.T) {
+		_, err := create(`{"name":"worker","endpoint":"tcp://worker:8080"}`)
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusConflict, he.Code)
+	})
+
+	t.Run("should reserve the local node name", func(t *testing.T) {
+		_, err := create(`{"name":"local","endpoint":"tcp://worker:8080"}`)
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+	})
+
+	t.Run("should reject unknown runtimes", func(t *testing.T) {
+		_, err := create(`{"name":"lxc","runtime":"lxc","endpoint":"tcp://worker:8080"}`)
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+	})
+
+	t.Run("should reject unreachable nodes", func(t *testing.T) {
+		_, err := create(`{"name":"offline","endpoint":"tcp://offline:8080"}`)
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusBadGateway, he.Code)
+
+		var count int64
+		db.Model(&models.Node{}).Where("name = ?", "offline").Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestNodeHandler_List(t *testing.T) {
+	db := setupNodeTestDB(t)
+	worker := models.Node{Name: "worker", Endpoint: "tcp://worker:8080", Enabled: true}
+	require.NoError(t, db.Create(&worker).Error)
+	require.NoError(t, db.Create(&models.GameServer{Slug: "mc", Name: "mc", Image: "mc", NodeID: &worker.ID}).Error)
+
+	nodes := container.NewPool(containertest.New())
+	nodes.Set(worker.ID, worker.Name, containertest.New())
+	handler := NewNodeHandler(db, nodes)
+	e := echo.New()
+
+	t.Run("should list the local node first", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+		rec := httptest.NewRecorder()
+
+		err := handler.List(e.NewContext(req, rec))
+
+		assert.NoError(t, err)
+		var resp []NodeResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp, 2)
+		assert.Equal(t, container.LocalNode, resp[0].Name)
+		assert.Zero(t, resp[0].Servers)
+		assert.Equal(t, "worker", resp[1].Name)
+		assert.Equal(t, int64(1), resp[1].Servers)
+	})
+}
+
+func TestNodeHandler_Delete(t *testing.T) {
+	db := setupNodeTestDB(t)
+	busy := models.Node{Name: "busy", Endpoint: "tcp://busy:8080", Enabled: true}
+	idle := models.Node{Name: "idle", Endpoint: "tcp://idle:8080", Enabled: true}
+	require.NoError(t, db.Create(&busy).Error)
+	require.NoError(t, db.Create(&idle).Error)
+	require.NoError(t, db.Create(&models.GameServer{Slug: "mc", Name: "mc", Image: "mc", NodeID: &busy.ID}).Error)
+
+	nodes := container.NewPool(containertest.New())
+	nodes.Set(busy.ID, busy.Name, containertest.New())
+	nodes.Set(idle.ID, idle.Name, containertest.New())
+	handler := NewNodeHandler(db, nodes)
+	e := echo.New()
+
+	remove := func(id string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/nodes/"+id, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return rec, handler.Delete(c)
+	}
+
+	t.Run("should refuse to delete nodes with game servers", func(t *testing.T) {
+		_, err := remove("1")
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusConflict, he.Code)
+	})
+
+	t.Run("should delete idle nodes and remove them from the pool", func(t *testing.T) {
+		rec, err := remove("2")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		_, err = nodes.Lookup("idle")
+		assert.ErrorIs(t, err, container.ErrNodeNotFound)
+	})
+
+	t.Run("should return 404 for non-existent node", func(t *testing.T) {
+		_, err := remove("999")
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, he.Code)
+	})
+}
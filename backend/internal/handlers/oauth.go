@@ -23,6 +23,8 @@ type OAuthHandler struct {
 	sessionStore redis.SessionStore
 	oauthConfig  *config.OAuthConfig
 	frontendURL  string
+	// twoFactorMinPriority is the role priority from which 2FA is required, 0 if never.
+	twoFactorMinPriority int
 }
 
 // NewOAuthHandler creates a new OAuth handler.
@@ -42,6 +44,11 @@ func NewOAuthHandler(
 	}
 }
 
+// SetTwoFactorPolicy requires 2FA for roles with at least minPriority. 0 disables the policy.
+func (h *OAuthHandler) SetTwoFactorPolicy(minPriority int) {
+	h.twoFactorMinPriority = minPriority
+}
+
 // Authorize redirects to the OAuth provider's authorization URL.
 func (h *OAuthHandler) Authorize(c echo.Context) error {
 	providerName := c.Param("provider")
@@ -113,6 +120,24 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 		return c.Redirect(http.StatusTemporaryRedirect, h.frontendURL+"?error=user_creation_failed")
 	}
 
+	// A second factor is needed before any token is issued; the frontend
+	// finishes the login with the challenge token
+	if err := h.db.First(&user.Role, user.RoleID).Error; err != nil {
+		return c.Redirect(http.StatusTemporaryRedirect, h.frontendURL+"?error=user_creation_failed")
+	}
+	if purpose, required := twoFactorPurpose(user, h.twoFactorMinPriority); required {
+		challengeToken, err := h.jwtManager.GenerateChallengeToken(user.ID, purpose)
+		if err != nil {
+			return c.Redirect(http.StatusTemporaryRedirect, h.frontendURL+"?error=token_generation_failed")
+		}
+		step := "verify"
+		if purpose == auth.ChallengeEnroll {
+			step = "enroll"
+		}
+		redirectURL := h.frontendURL + "?challenge_token=" + challengeToken + "&two_factor=" + step
+		return c.Redirect(http.StatusTemporaryRedirect, redirectURL)
+	}
+
 	// Generate tokens
 	accessToken, jti, err := h.jwtManager.GenerateAccessToken(user.ID, user.Username)
 	if err != nil {
@@ -120,7 +145,7 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 	}
 
 	familyID := uuid.New().String()
-	refreshToken, err := h.jwtManager.GenerateRefreshToken(user.ID, familyID)
+	refreshToken, err := issueRefreshToken(h.db, h.jwtManager, c, user.ID, familyID)
 	if err != nil {
 		return c.Redirect(http.StatusTemporaryRedirect, h.frontendURL+"?error=token_generation_failed")
 	}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/games"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"github.com/sweetfish329/sabakan/backend/internal/rcon"
+)
+
+// RCONRequest represents the request body for running an RCON command.
+type RCONRequest struct {
+	Command string `json:"command"`
+}
+
+// RCONResponse represents the result of an RCON command.
+type RCONResponse struct {
+	Response string `json:"response"`
+}
+
+// errNoRCON is returned when a game server does not provide RCON.
+var errNoRCON = errors.New("game does not support RCON")
+
+// RCON handles POST /api/game-servers/:slug/rcon.
+func (h *GameServerHandler) RCON(c echo.Context) error {
+	var req RCONRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	req.Command = strings.TrimSpace(req.Command)
+	if req.Command == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Command is required",
+		})
+	}
+
+	server, err := h.findManagedServer(c)
+	if server == nil {
+		return err
+	}
+
+	if !server.Status.IsRunning() {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Game server is not running",
+		})
+	}
+
+	ctx := c.Request().Context()
+	client, err := h.dialRCON(ctx, server)
+	if errors.Is(err, errNoRCON) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "unsupported",
+			Message: "This game does not support RCON",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, ErrorResponse{
+			Error:   "rcon_error",
+			Message: err.Error(),
+		})
+	}
+	defer client.Close()
+
+	response, err := client.Execute(ctx, req.Command)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, ErrorResponse{
+			Error:   "rcon_error",
+			Message: err.Error(),
+		})
+	}
+
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionRCON, map[string]any{
+		"command": req.Command,
+	})
+
+	return c.JSON(http.StatusOK, RCONResponse{Response: response})
+}
+
+// hookServer exposes a game server to the lifecycle hooks of its game.
+type hookServer struct {
+	h      *GameServerHandler
+	server *models.GameServer
+}
+
+// ContainerID returns the ID of the server's container.
+func (s *hookServer) ContainerID() string {
+	return s.server.ContainerID
+}
+
+// RCON connects to the server's remote console.
+func (s *hookServer) RCON(ctx context.Context) (rcon.Client, error) {
+	return s.h.dialRCON(ctx, s.server)
+}
+
+// Wait blocks until the server's container has exited.
+func (s *hookServer) Wait(ctx context.Context) error {
+	runtime, err := s.h.runtime(s.server)
+	if err != nil {
+		return err
+	}
+	_, err = runtime.Wait(ctx, s.server.ContainerID)
+	return err
+}
+
+// dialRCON connects to the remote console of a running game server.
+func (h *GameServerHandler) dialRCON(ctx context.Context, server *models.GameServer) (rcon.Client, error) {
+	gameHandler, ok := games.Get(server.Game)
+	if !ok {
+		return nil, errNoRCON
+	}
+	rconHandler, ok := gameHandler.(games.RCONHandler)
+	if !ok {
+		return nil, errNoRCON
+	}
+	config := rconHandler.GetRCONConfig()
+
+	var loaded models.GameServer
+	if err := h.db.Preload("Ports").Preload("Envs").First(&loaded, server.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load game server: %w", err)
+	}
+
+	hostPort, err := rconHostPort(&loaded, gameHandler, config.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := h.rconPassword(ctx, &loaded, config)
+	if err != nil {
+		return nil, err
+	}
+
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(runtime.Host(), strconv.Itoa(hostPort))
+	return rcon.Dial(ctx, config.Protocol, addr, password)
+}
+
+// rconHostPort returns the host port that the named RCON port is published on.
+func rconHostPort(server *models.GameServer, gameHandler games.GameHandler, portName string) (int, error) {
+	containerPort, ok := gameHandler.GetDefaultPorts()[portName]
+	if !ok {
+		return 0, fmt.Errorf("game has no %q port", portName)
+	}
+
+	for _, port := range server.Ports {
+		if port.ContainerPort == containerPort && port.Protocol != "udp" {
+			return port.HostPort, nil
+		}
+	}
+	return 0, fmt.Errorf("RCON port %d is not published", containerPort)
+}
+
+// rconPassword returns the RCON password from the server's environment
+// or, if the game keeps it in a file, from the container.
+func (h *GameServerHandler) rconPassword(ctx context.Context, server *models.GameServer, config games.RCONConfig) (string, error) {
+	if config.PasswordEnv != "" {
+		return containerEnv(server)[config.PasswordEnv], nil
+	}
+	if config.PasswordFile == "" {
+		return "", nil
+	}
+
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return "", err
+	}
+	result, err := runtime.Exec(ctx, server.ContainerID, []string{"cat", config.PasswordFile})
+	if err != nil {
+		return "", fmt.Errorf("failed to read RCON password: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("failed to read RCON password: %s", strings.TrimSpace(result.Stderr))
+	}
+	return strings.TrimSpace(result.Stdout), nil
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// fakeRCONServer starts a Source RCON server that answers every command
+// with "ok: <command>" and returns its port. Received commands are sent to
+// commands if it is not nil.
+func fakeRCONServer(t *testing.T, password string, commands chan<- string) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	write := func(w io.Writer, id, packetType int32, body string) {
+		var buf bytes.Buffer
+		_ = binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+		_ = binary.Write(&buf, binary.LittleEndian, id)
+		_ = binary.Write(&buf, binary.LittleEndian, packetType)
+		buf.WriteString(body + "\x00\x00")
+		_, _ = w.Write(buf.Bytes())
+	}
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		for {
+			var size int32
+			if err := binary.Read(conn, binary.LittleEndian, &size); err != nil {
+				return
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(conn, data); err != nil {
+				return
+			}
+			id := int32(binary.LittleEndian.Uint32(data[0:4]))
+			body := string(bytes.TrimRight(data[8:], "\x00"))
+			if binary.LittleEndian.Uint32(data[4:8]) == 3 {
+				if body != password {
+					id = -1
+				}
+				write(conn, id, 2, "")
+				continue
+			}
+			if binary.LittleEndian.Uint32(data[4:8]) == 0 {
+				// Mirror the end-of-response marker
+				write(conn, id, 0, "")
+				continue
+			}
+			if commands != nil {
+				commands <- body
+			}
+			write(conn, id, 0, "ok: "+body)
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestGameServerHandler_RCON(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+	port := fakeRCONServer(t, "hunter2", nil)
+
+	server := models.GameServer{
+		Slug:        "mc",
+		Name:        "Minecraft",
+		Game:        "minecraft",
+		Image:       "itzg/minecraft-server:latest",
+		Status:      models.GameServerStatusRunning,
+		ContainerID: "container123",
+	}
+	db.Create(&server)
+	db.Create(&models.GameServerPort{GameServerID: server.ID, HostPort: port, ContainerPort: 25575, Protocol: "tcp"})
+	db.Create(&models.GameServerEnv{GameServerID: server.ID, Key: "RCON_PASSWORD", Value: "hunter2", IsSecret: true})
+
+	rconRequest := func(slug, body string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers/"+slug+"/rcon", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		assert.NoError(t, handler.RCON(c))
+		return rec
+	}
+
+	t.Run("should run the command with the server's password", func(t *testing.T) {
+		rec := rconRequest("mc", `{"command":"save-all"}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp RCONResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "ok: save-all", resp.Response)
+
+		var audit models.AuditLog
+		require.NoError(t, db.Last(&audit).Error)
+		assert.Equal(t, models.AuditLogActionRCON, audit.Action)
+	})
+
+	t.Run("should reject an empty command", func(t *testing.T) {
+		rec := rconRequest("mc", `{"command":"  "}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should reject games without RCON", func(t *testing.T) {
+		db.Create(&models.GameServer{
+			Slug:        "sf",
+			Name:        "Satisfactory",
+			Game:        "satisfactory",
+			Image:       "wolveix/satisfactory-server:latest",
+			Status:      models.GameServerStatusRunning,
+			ContainerID: "container456",
+		})
+
+		rec := rconRequest("sf", `{"command":"save"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
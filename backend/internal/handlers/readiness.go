@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/games"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+const (
+	// defaultReadinessTimeout bounds how long a game may take to become ready
+	// when its probe does not say otherwise.
+	defaultReadinessTimeout = 10 * time.Minute
+	// defaultReadinessInterval is the pause between two probe attempts.
+	defaultReadinessInterval = 5 * time.Second
+	// readinessLogLines is how many earlier log lines a log probe looks at, so
+	// that a line printed before the probe attached is not missed.
+	readinessLogLines = 1000
+)
+
+// errNotReady is returned when a game server did not become ready.
+var errNotReady = errors.New("game server did not become ready")
+
+// errLogMatched ends a log stream once the readiness line has been found.
+var errLogMatched = errors.New("readiness line found")
+
+// readinessRun is a readiness probe in progress.
+type readinessRun struct {
+	// containerID is the container being probed.
+	containerID string
+	// cancel stops the probe when it is superseded.
+	cancel context.CancelFunc
+	// done is closed when the probe has finished.
+	done chan struct{}
+	// err is why the server did not become ready, nil if it did.
+	err error
+}
+
+// Started sets the status of a server whose container runs. Servers of games
+// with a readiness probe become starting and are probed in the background until
+// they are ready; the others become running.
+// It implements container.ReadinessProber.
+func (h *GameServerHandler) Started(server models.GameServer) {
+	h.started(&server)
+}
+
+// started is Started for a server whose status is kept up to date.
+func (h *GameServerHandler) started(server *models.GameServer) {
+	gameHandler, ok := games.Get(server.Game)
+	var probe *games.ReadinessProbe
+	if ok {
+		probe = games.Readiness(gameHandler)
+	}
+	if probe == nil {
+		if server.Status != models.GameServerStatusRunning {
+			h.setStatus(server, models.GameServerStatusRunning)
+		}
+		return
+	}
+
+	h.probesMu.Lock()
+	defer h.probesMu.Unlock()
+	if run, ok := h.probes[server.ID]; ok {
+		if run.containerID == server.ContainerID && server.Status == models.GameServerStatusStarting {
+			return
+		}
+		// The server was stopped or recreated since the probe started
+		run.cancel()
+	}
+
+	timeout := probe.Timeout
+	if timeout == 0 {
+		timeout = defaultReadinessTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	run := &readinessRun{containerID: server.ContainerID, cancel: cancel, done: make(chan struct{})}
+	h.probes[server.ID] = run
+	if server.Status != models.GameServerStatusStarting {
+		h.setStatus(server, models.GameServerStatusStarting)
+	}
+	go h.probeReadiness(ctx, *server, gameHandler, *probe, timeout, run)
+}
+
+// probeReadiness probes a starting server until it is ready, stops starting,
+// the probe is superseded or it times out, in which case the server is marked
+// as failed.
+func (h *GameServerHandler) probeReadiness(ctx context.Context, server models.GameServer, gameHandler games.GameHandler, probe games.ReadinessProbe, timeout time.Duration, run *readinessRun) {
+	defer run.cancel()
+
+	err := h.waitForProbe(ctx, &server, gameHandler, probe)
+	switch {
+	case err == nil:
+		h.transition(&server, models.GameServerStatusStarting, models.GameServerStatusReady)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("%w within %s", errNotReady, timeout)
+		if h.transition(&server, models.GameServerStatusStarting, models.GameServerStatusError) {
+			slog.Warn("Game server did not become ready", "server", server.Slug, "timeout", timeout)
+		}
+	case ctx.Err() != nil:
+		err = fmt.Errorf("%w: the server was restarted", errNotReady)
+	}
+
+	h.probesMu.Lock()
+	if h.probes[server.ID] == run {
+		delete(h.probes, server.ID)
+	}
+	run.err = err
+	close(run.done)
+	h.probesMu.Unlock()
+}
+
+// waitForProbe repeats the probe of a server until it succeeds, ctx ends or
+// the server is no longer starting.
+func (h *GameServerHandler) waitForProbe(ctx context.Context, server *models.GameServer, gameHandler games.GameHandler, probe games.ReadinessProbe) error {
+	for {
+		if err := h.probeOnce(ctx, server, gameHandler, probe); err == nil {
+			return nil
+		}
+
+		var current models.GameServer
+		if err := h.db.Select("status").First(&current, server.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != models.GameServerStatusStarting {
+			return fmt.Errorf("%w: server is %s", errNotReady, current.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(h.readinessInterval):
+		}
+	}
+}
+
+// probeOnce checks once whether a server is ready.
+func (h *GameServerHandler) probeOnce(ctx context.Context, server *models.GameServer, gameHandler games.GameHandler, probe games.ReadinessProbe) error {
+	switch probe.Type {
+	case games.ProbeLog:
+		return h.probeLog(ctx, server, probe.Pattern)
+	case games.ProbePort:
+		return h.probePort(ctx, server, gameHandler, probe.Port)
+	case games.ProbeRCON:
+		client, err := h.dialRCON(ctx, server)
+		if err != nil {
+			return err
+		}
+		return client.Close()
+	default:
+		return fmt.Errorf("unknown probe type %q", probe.Type)
+	}
+}
+
+// probeLog follows the logs of a server's container since it was started
+// until a line matches pattern.
+func (h *GameServerHandler) probeLog(ctx context.Context, server *models.GameServer, pattern *regexp.Regexp) error {
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return err
+	}
+	ctr, err := runtime.Get(ctx, server.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	err = runtime.StreamLogs(ctx, server.ContainerID, readinessLogLines, func(entry models.ContainerLogEntry) error {
+		// Lines of an earlier run of the container do not count
+		if ctr.StartedAt != nil && !entry.Timestamp.IsZero() && entry.Timestamp.Before(*ctr.StartedAt) {
+			return nil
+		}
+		if pattern.MatchString(entry.Message) {
+			return errLogMatched
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errLogMatched):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return errors.New("log stream ended")
+	}
+}
+
+// probePort connects to the host port the named port of a server is published on.
+func (h *GameServerHandler) probePort(ctx context.Context, server *models.GameServer, gameHandler games.GameHandler, name string) error {
+	containerPort, ok := gameHandler.GetDefaultPorts()[name]
+	if !ok {
+		return fmt.Errorf("game has no %q port", name)
+	}
+	protocol := games.PortProtocol(gameHandler, name)
+	if protocol != "tcp" {
+		return fmt.Errorf("cannot probe %s port %q, only tcp ports", protocol, name)
+	}
+
+	var ports []models.GameServerPort
+	if err := h.db.Where("game_server_id = ? AND container_port = ? AND protocol = ?", server.ID, containerPort, protocol).Find(&ports).Error; err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("port %d/%s is not published", containerPort, protocol)
+	}
+
+	runtime, err := h.runtime(server)
+	if err != nil {
+		return err
+	}
+	return dialPort(ctx, net.JoinHostPort(runtime.Host(), strconv.Itoa(ports[0].HostPort)))
+}
+
+// dialPort checks whether a tcp port accepts connections.
+func dialPort(ctx context.Context, addr string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// transition changes the status of a server from one status to another.
+// It reports false and leaves the server alone if its status was changed meanwhile.
+func (h *GameServerHandler) transition(server *models.GameServer, from, to models.GameServerStatus) bool {
+	result := h.db.Model(&models.GameServer{}).Where("id = ? AND status = ?", server.ID, from).Update("status", to)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	server.Status = to
+	h.broker.Publish(server)
+	return true
+}
+
+// waitReady blocks until a started server is ready, its probe gives up or ctx ends.
+func (h *GameServerHandler) waitReady(ctx context.Context, server *models.GameServer) error {
+	h.probesMu.Lock()
+	run, ok := h.probes[server.ID]
+	h.probesMu.Unlock()
+	if ok {
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var current models.GameServer
+	if err := h.db.Select("status").First(&current, server.ID).Error; err != nil {
+		return err
+	}
+	server.Status = current.Status
+	if server.Status == models.GameServerStatusReady || server.Status == models.GameServerStatusRunning {
+		return nil
+	}
+	if ok && run.err != nil {
+		return run.err
+	}
+	return fmt.Errorf("%w: server is %s", errNotReady, server.Status)
+}
+
+// startedResponse writes the response to a request that started a server.
+// If the wait query parameter gives a number of seconds, it first waits up to
+// that long for the server to become ready.
+func (h *GameServerHandler) startedResponse(c echo.Context, server *models.GameServer) error {
+	wait, _ := strconv.ParseUint(c.QueryParam("wait"), 10, 32)
+	if wait > 0 {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(wait)*time.Second)
+		defer cancel()
+
+		err := h.waitReady(ctx, server)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return c.JSON(http.StatusGatewayTimeout, ErrorResponse{
+				Error:   "ready_timeout",
+				Message: fmt.Sprintf("Game server did not become ready within %d seconds", wait),
+			})
+		case err != nil:
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "not_ready",
+				Message: err.Error(),
+			})
+		}
+	}
+	maskSecrets(server)
+	return c.JSON(http.StatusOK, server)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/config"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/container/containertest"
+	"github.com/sweetfish329/sabakan/backend/internal/games"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// registerProbeGame registers a custom game with a readiness probe for the duration of a test.
+func registerProbeGame(t *testing.T, id string, readiness config.ReadinessInfo) {
+	t.Helper()
+	handler, err := games.NewCustomHandler(&config.GameConfig{
+		Game:      config.GameInfo{ID: id},
+		Container: config.ContainerInfo{Image: "example/" + id, Ports: []string{"game=7000"}},
+		Readiness: readiness,
+	})
+	require.NoError(t, err)
+	games.Register(id, handler)
+	t.Cleanup(func() { delete(games.Registry, id) })
+}
+
+// newReadinessTestHandler creates a game server handler probing servers every 10ms.
+func newReadinessTestHandler(t *testing.T, db *gorm.DB, runtime *containertest.Runtime) *GameServerHandler {
+	t.Helper()
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+	handler.readinessInterval = 10 * time.Millisecond
+	handler.SetStatusBroker(container.NewStatusBroker())
+	return handler
+}
+
+// startRequest calls Start for a server with the given query string.
+func startRequest(t *testing.T, handler *GameServerHandler, slug, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/game-servers/"+slug+"/start?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	c.Set(middleware.ContextKeyUserID, uint(1))
+
+	require.NoError(t, handler.Start(c))
+	return rec
+}
+
+func TestGameServerHandler_Start_Readiness(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	runtime := containertest.New()
+	handler := newReadinessTestHandler(t, db, runtime)
+
+	status := func(slug string) models.GameServerStatus {
+		var server models.GameServer
+		require.NoError(t, db.Where("slug = ?", slug).First(&server).Error)
+		return server.Status
+	}
+
+	t.Run("should become ready once the log line is printed", func(t *testing.T) {
+		runtime.Containers["mc"] = &models.Container{ID: "mc", Name: "sabakan-mc"}
+		runtime.LogEntries["mc"] = []models.ContainerLogEntry{
+			{Message: "[Server thread/INFO]: Preparing spawn area: 84%"},
+			{Message: `[Server thread/INFO]: Done (12.345s)! For help, type "help"`},
+		}
+		db.Create(&models.GameServer{Slug: "mc", Name: "MC", Game: "minecraft", Image: "mc", OwnerID: 1, ContainerID: "mc"})
+
+		events, unsubscribe := handler.broker.Subscribe()
+		defer unsubscribe()
+
+		rec := startRequest(t, handler, "mc", "wait=5")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"ready"`)
+		assert.Equal(t, models.GameServerStatusReady, status("mc"))
+		assert.Equal(t, models.GameServerStatusStarting, (<-events).Status)
+		assert.Equal(t, models.GameServerStatusReady, (<-events).Status)
+	})
+
+	t.Run("should stay starting until the log line is printed", func(t *testing.T) {
+		runtime.Containers["slow"] = &models.Container{ID: "slow", Name: "sabakan-slow"}
+		runtime.LogEntries["slow"] = []models.ContainerLogEntry{{Message: "Preparing level"}}
+		db.Create(&models.GameServer{Slug: "slow", Name: "Slow", Game: "minecraft", Image: "mc", OwnerID: 1, ContainerID: "slow"})
+
+		rec := startRequest(t, handler, "slow", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, models.GameServerStatusStarting, status("slow"))
+
+		rec = startRequest(t, handler, "slow", "wait=1")
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+		assert.Contains(t, rec.Body.String(), "ready_timeout")
+		assert.Equal(t, models.GameServerStatusStarting, status("slow"))
+	})
+
+	t.Run("should mark games without a probe as running", func(t *testing.T) {
+		runtime.Containers["plain"] = &models.Container{ID: "plain", Name: "sabakan-plain"}
+		db.Create(&models.GameServer{Slug: "plain", Name: "Plain", Game: "unknown", Image: "plain", OwnerID: 1, ContainerID: "plain"})
+
+		rec := startRequest(t, handler, "plain", "wait=5")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, models.GameServerStatusRunning, status("plain"))
+	})
+}
+
+func TestGameServerHandler_Readiness_Port(t *testing.T) {
+	registerProbeGame(t, "probe-port", config.ReadinessInfo{Port: "game"})
+	db := setupGameServerTestDB(t)
+	runtime := containertest.New()
+	runtime.HostName = "127.0.0.1"
+	handler := newReadinessTestHandler(t, db, runtime)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	hostPort := listener.Addr().(*net.TCPAddr).Port
+
+	runtime.Containers["port"] = &models.Container{ID: "port", Name: "sabakan-port"}
+	server := models.GameServer{
+		Slug:        "port",
+		Name:        "Port",
+		Game:        "probe-port",
+		Image:       "example/probe-port",
+		OwnerID:     1,
+		ContainerID: "port",
+		Ports:       []models.GameServerPort{{HostPort: hostPort, ContainerPort: 7000, Protocol: "tcp"}},
+	}
+	require.NoError(t, db.Create(&server).Error)
+
+	t.Run("should become ready once the port accepts connections", func(t *testing.T) {
+		rec := startRequest(t, handler, "port", "wait=5")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"ready"`)
+	})
+}
+
+func TestGameServerHandler_Readiness_Timeout(t *testing.T) {
+	registerProbeGame(t, "probe-timeout", config.ReadinessInfo{Log: "never printed", Timeout: 1})
+	db := setupGameServerTestDB(t)
+	runtime := containertest.New()
+	handler := newReadinessTestHandler(t, db, runtime)
+
+	runtime.Containers["hang"] = &models.Container{ID: "hang", Name: "sabakan-hang"}
+	server := models.GameServer{Slug: "hang", Name: "Hang", Game: "probe-timeout", Image: "example/probe-timeout", OwnerID: 1, ContainerID: "hang"}
+	require.NoError(t, db.Create(&server).Error)
+
+	t.Run("should mark servers that never become ready as failed", func(t *testing.T) {
+		rec := startRequest(t, handler, "hang", "wait=5")
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), "did not become ready within 1s")
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, models.GameServerStatusError, updated.Status)
+	})
+}
+
+func TestGameServerHandler_Readiness_Stopped(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	runtime := containertest.New()
+	handler := newReadinessTestHandler(t, db, runtime)
+
+	runtime.Containers["stop"] = &models.Container{ID: "stop", Name: "sabakan-stop"}
+	server := models.GameServer{Slug: "stop", Name: "Stop", Game: "minecraft", Image: "mc", OwnerID: 1, ContainerID: "stop"}
+	require.NoError(t, db.Create(&server).Error)
+
+	t.Run("should stop probing servers that are stopped", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, startRequest(t, handler, "stop", "").Code)
+
+		handler.setStatus(&server, models.GameServerStatusStopped)
+
+		err := handler.waitReady(t.Context(), &server)
+		assert.ErrorIs(t, err, errNotReady)
+		assert.Equal(t, models.GameServerStatusStopped, server.Status)
+	})
+
+	t.Run("should probe again when the server is started again", func(t *testing.T) {
+		runtime.LogEntries["stop"] = []models.ContainerLogEntry{{Message: "Done (1.0s)! For help"}}
+
+		rec := startRequest(t, handler, "stop", "wait=5")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"ready"`)
+	})
+}
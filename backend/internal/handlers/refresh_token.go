@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// errRefreshTokenReused is returned when a refresh token that was already
+// rotated or revoked is presented again.
+var errRefreshTokenReused = errors.New("refresh token reused")
+
+// errRefreshTokenUnknown is returned when a refresh token was never issued or has expired.
+var errRefreshTokenUnknown = errors.New("unknown or expired refresh token")
+
+// issueRefreshToken creates a refresh token of a login and stores its hash.
+// All refresh tokens rotated from one login share its familyID.
+func issueRefreshToken(db *gorm.DB, jwtManager *auth.JWTManager, c echo.Context, userID uint, familyID string) (string, error) {
+	token, err := jwtManager.GenerateRefreshToken(userID, familyID)
+	if err != nil {
+		return "", err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    userID,
+		TokenHash: auth.HashToken(token),
+		FamilyID:  familyID,
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		ExpiresAt: time.Now().Add(jwtManager.RefreshTokenExpiry()),
+	}
+	if err := db.Create(&stored).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// rotateRefreshToken revokes a refresh token so that it can be exchanged only
+// once. A token that was already revoked is being replayed, possibly by
+// someone who stole it, so its whole family is revoked as well.
+func rotateRefreshToken(db *gorm.DB, claims *auth.RefreshTokenClaims, token string) (*models.RefreshToken, error) {
+	var stored models.RefreshToken
+	if err := db.Where("token_hash = ?", auth.HashToken(token)).First(&stored).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errRefreshTokenUnknown
+		}
+		return nil, err
+	}
+	if stored.UserID != claims.UserID || stored.FamilyID != claims.FamilyID || stored.IsExpired() {
+		return nil, errRefreshTokenUnknown
+	}
+
+	if !stored.IsRevoked() {
+		// Only one of two concurrent refreshes with the same token wins
+		result := db.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return &stored, nil
+		}
+	}
+
+	if err := revokeRefreshFamily(db, stored.FamilyID); err != nil {
+		return nil, err
+	}
+	return &stored, errRefreshTokenReused
+}
+
+// revokeRefreshFamily revokes every refresh token rotated from the same login.
+func revokeRefreshFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
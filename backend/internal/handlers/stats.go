@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// statRanges maps the range query parameter to the resolution and age of the samples returned.
+var statRanges = map[string]struct {
+	resolution models.StatResolution
+	age        time.Duration
+}{
+	"24h": {models.StatResolutionMinute, 24 * time.Hour},
+	"7d":  {models.StatResolutionHour, 7 * 24 * time.Hour},
+}
+
+// Stats handles GET /api/game-servers/:slug/stats.
+// The range query parameter selects per-minute samples of the last 24 hours
+// (24h, default) or hourly averages of the last 7 days (7d).
+func (h *GameServerHandler) Stats(c echo.Context) error {
+	rangeParam := c.QueryParam("range")
+	if rangeParam == "" {
+		rangeParam = "24h"
+	}
+	statRange, ok := statRanges[rangeParam]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Range must be 24h or 7d",
+		})
+	}
+
+	var server models.GameServer
+	if err := h.db.Where("slug = ?", c.Param("slug")).First(&server).Error; err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Game server not found",
+		})
+	}
+
+	stats := []models.GameServerStat{}
+	since := time.Now().UTC().Add(-statRange.age)
+	err := h.db.Where("game_server_id = ? AND resolution = ? AND timestamp >= ?", server.ID, statRange.resolution, since).
+		Order("timestamp").
+		Find(&stats).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to fetch stats",
+		})
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestGameServerHandler_Stats(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+
+	server := models.GameServer{Slug: "my-server", Name: "My Server", Image: "test:latest", OwnerID: 1}
+	db.Create(&server)
+
+	now := time.Now().UTC()
+	db.Create(&[]models.GameServerStat{
+		{GameServerID: server.ID, Resolution: models.StatResolutionMinute, Timestamp: now.Add(-time.Minute), CPUPercent: 20},
+		{GameServerID: server.ID, Resolution: models.StatResolutionMinute, Timestamp: now.Add(-2 * time.Minute), CPUPercent: 10},
+		{GameServerID: server.ID, Resolution: models.StatResolutionMinute, Timestamp: now.Add(-25 * time.Hour), CPUPercent: 99},
+		{GameServerID: server.ID, Resolution: models.StatResolutionHour, Timestamp: now.Add(-3 * time.Hour), CPUPercent: 15},
+	})
+
+	e := echo.New()
+
+	request := func(slug, rangeParam string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/game-servers/"+slug+"/stats?range="+rangeParam, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+
+		assert.NoError(t, handler.Stats(c))
+		return rec
+	}
+
+	t.Run("should return minute samples of the last 24 hours in order", func(t *testing.T) {
+		rec := request("my-server", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var stats []models.GameServerStat
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		if assert.Len(t, stats, 2) {
+			assert.Equal(t, 10.0, stats[0].CPUPercent)
+			assert.Equal(t, 20.0, stats[1].CPUPercent)
+		}
+	})
+
+	t.Run("should return hourly samples for the 7d range", func(t *testing.T) {
+		rec := request("my-server", "7d")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var stats []models.GameServerStat
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		if assert.Len(t, stats, 1) {
+			assert.Equal(t, 15.0, stats[0].CPUPercent)
+		}
+	})
+
+	t.Run("should reject an unknown range", func(t *testing.T) {
+		rec := request("my-server", "30d")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should return 404 for nonexistent server", func(t *testing.T) {
+		rec := request("nonexistent", "24h")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// TwoFactorRequest represents the request body of the 2FA endpoints.
+// ChallengeToken is only used during login; signed in users are identified by their access token.
+type TwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// TwoFactorChallengeResponse is returned by login when a second factor is needed.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	EnrollmentRequired bool   `json:"enrollment_required,omitempty"`
+	ChallengeToken     string `json:"challenge_token"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+// TwoFactorEnrollResponse carries the secret to add to an authenticator app.
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse carries recovery codes, which are shown only once.
+// When 2FA was enabled during login, it also carries the tokens of the login.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	*AuthResponse
+}
+
+// SetTwoFactorPolicy requires 2FA for roles with at least minPriority. 0 disables the policy.
+func (h *AuthHandler) SetTwoFactorPolicy(minPriority int) {
+	h.twoFactorMinPriority = minPriority
+}
+
+// twoFactorPurpose reports whether a user who entered their password needs a
+// second step before login, and whether that step is verifying a code or
+// enrolling because the role of the user requires 2FA. The role must be loaded.
+func twoFactorPurpose(user *models.User, minPriority int) (auth.ChallengePurpose, bool) {
+	if user.TOTPEnabled {
+		return auth.ChallengeVerify, true
+	}
+	if roleRequiresTwoFactor(user.Role, minPriority) {
+		return auth.ChallengeEnroll, true
+	}
+	return "", false
+}
+
+// roleRequiresTwoFactor reports whether the 2FA policy applies to a role.
+func roleRequiresTwoFactor(role models.Role, minPriority int) bool {
+	return minPriority > 0 && role.Priority >= minPriority
+}
+
+// challenge responds to a login with a challenge token for the second step.
+func (h *AuthHandler) challenge(c echo.Context, user *models.User, purpose auth.ChallengePurpose) error {
+	token, err := h.jwtManager.GenerateChallengeToken(user.ID, purpose)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate challenge token",
+		})
+	}
+
+	return c.JSON(http.StatusOK, TwoFactorChallengeResponse{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: purpose == auth.ChallengeEnroll,
+		ChallengeToken:     token,
+		ExpiresIn:          int(auth.ChallengeTokenExpiry.Seconds()),
+	})
+}
+
+// twoFactorUser returns the user a 2FA request is about: the signed in user,
+// or during login the holder of a challenge token for purpose. It writes an
+// error response and returns nil if there is none.
+func (h *AuthHandler) twoFactorUser(c echo.Context, req *TwoFactorRequest, purpose auth.ChallengePurpose) (*models.User, error) {
+	userID := middleware.GetUserID(c)
+	if userID != 0 {
+		// A leaked API token must not be able to change the second factor
+		if middleware.GetAPITokenID(c) != 0 {
+			return nil, c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "forbidden",
+				Message: "Two-factor authentication cannot be managed with an API token",
+			})
+		}
+	} else {
+		claims, err := h.jwtManager.ValidateChallengeToken(req.ChallengeToken, purpose)
+		if err != nil {
+			return nil, c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Message: "Invalid or expired challenge token",
+			})
+		}
+		userID = claims.UserID
+	}
+
+	var user models.User
+	if err := h.db.Preload("Role").First(&user, userID).Error; err != nil || !user.IsActive {
+		return nil, c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not found or inactive",
+		})
+	}
+	return &user, nil
+}
+
+// acceptTOTP checks a TOTP code of a user and records its time step, so that
+// every code is accepted at most once even by concurrent requests.
+func (h *AuthHandler) acceptTOTP(user *models.User, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false, nil
+	}
+
+	result := h.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	user.TOTPLastStep = step
+	return result.RowsAffected == 1, nil
+}
+
+// useRecoveryCode marks an unused recovery code of a user as used.
+func (h *AuthHandler) useRecoveryCode(user *models.User, code string) (bool, error) {
+	result := h.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, auth.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// checkCode checks the TOTP code of a request, or its recovery code if it has none.
+func (h *AuthHandler) checkCode(user *models.User, req *TwoFactorRequest) (bool, error) {
+	if req.Code != "" {
+		return h.acceptTOTP(user, req.Code)
+	}
+	return h.useRecoveryCode(user, req.RecoveryCode)
+}
+
+// replaceRecoveryCodes generates new recovery codes for a user, invalidating the old ones.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	stored := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		stored[i] = models.RecoveryCode{UserID: userID, CodeHash: auth.HashRecoveryCode(code)}
+	}
+	if err := tx.Create(&stored).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// invalidCode responds to a wrong TOTP or recovery code.
+func invalidCode(c echo.Context) error {
+	return c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Error:   "invalid_code",
+		Message: "Invalid or already used code",
+	})
+}
+
+// VerifyTwoFactor handles POST /auth/2fa/verify, the second step of a login
+// with 2FA enabled. It accepts a TOTP code or a recovery code.
+func (h *AuthHandler) VerifyTwoFactor(c echo.Context) error {
+	var req TwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Code or recovery code is required",
+		})
+	}
+
+	user, err := h.twoFactorUser(c, &req, auth.ChallengeVerify)
+	if user == nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+	// Wrong codes count towards the lockout of the password step, so that
+	// codes cannot be guessed either
+	if locked, err := h.loginLocked(c, user.ID, user.Username); locked {
+		return err
+	}
+
+	ok, err := h.checkCode(user, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to verify code",
+		})
+	}
+	if !ok {
+		h.loginFailed(c, user.ID, user.Username, loginFailureInvalidCode)
+		return invalidCode(c)
+	}
+
+	response, err := h.issueTokens(c, user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate tokens",
+		})
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// EnrollTwoFactor handles POST /auth/2fa/enroll and POST /api/2fa/enroll.
+// It generates a new secret, which takes effect once confirmed with EnableTwoFactor.
+func (h *AuthHandler) EnrollTwoFactor(c echo.Context) error {
+	var req TwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.twoFactorUser(c, &req, auth.ChallengeEnroll)
+	if user == nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Two-factor authentication is already enabled",
+		})
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate secret",
+		})
+	}
+	if err := h.db.Model(user).Update("totp_secret", secret).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to save secret",
+		})
+	}
+
+	return c.JSON(http.StatusOK, TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(user.Username, secret),
+	})
+}
+
+// EnableTwoFactor handles POST /auth/2fa/enable and POST /api/2fa/enable.
+// A code of the enrolled secret enables 2FA and returns new recovery codes.
+// During login, it also finishes the login.
+func (h *AuthHandler) EnableTwoFactor(c echo.Context) error {
+	var req TwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+	if req.Code == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Code is required",
+		})
+	}
+
+	user, err := h.twoFactorUser(c, &req, auth.ChallengeEnroll)
+	if user == nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Two-factor authentication is already enabled",
+		})
+	}
+	if user.TOTPSecret == "" {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Two-factor authentication is not enrolled",
+		})
+	}
+	if locked, err := h.loginLocked(c, user.ID, user.Username); locked {
+		return err
+	}
+
+	ok, err := h.acceptTOTP(user, req.Code)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to verify code",
+		})
+	}
+	if !ok {
+		h.loginFailed(c, user.ID, user.Username, loginFailureInvalidCode)
+		return invalidCode(c)
+	}
+
+	var codes []string
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		generated, err := replaceRecoveryCodes(tx, user.ID)
+		codes = generated
+		return err
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to enable two-factor authentication",
+		})
+	}
+	recordAudit(h.db, c, models.AuditLogTargetUser, user.ID, models.AuditLogActionUpdate, map[string]any{
+		"twoFactor": "enabled",
+	})
+
+	response := RecoveryCodesResponse{RecoveryCodes: codes}
+	if middleware.GetUserID(c) == 0 {
+		response.AuthResponse, err = h.issueTokens(c, user)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to generate tokens",
+			})
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// DisableTwoFactor handles POST /api/2fa/disable.
+// It needs a current TOTP or recovery code and is refused if the role of the user requires 2FA.
+func (h *AuthHandler) DisableTwoFactor(c echo.Context) error {
+	var req TwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.twoFactorUser(c, &req, auth.ChallengeVerify)
+	if user == nil {
+		return err
+	}
+	if roleRequiresTwoFactor(user.Role, h.twoFactorMinPriority) {
+		return c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "forbidden",
+			Message: "Two-factor authentication is required for your role",
+		})
+	}
+	if confirmed, err := h.confirmTwoFactor(c, &req, user); !confirmed {
+		return err
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]any{"totp_enabled": false, "totp_secret": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to disable two-factor authentication",
+		})
+	}
+	recordAudit(h.db, c, models.AuditLogTargetUser, user.ID, models.AuditLogActionUpdate, map[string]any{
+		"twoFactor": "disabled",
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles POST /api/2fa/recovery-codes.
+// It needs a current TOTP or recovery code and replaces all recovery codes.
+func (h *AuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	var req TwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.twoFactorUser(c, &req, auth.ChallengeVerify)
+	if user == nil {
+		return err
+	}
+	if confirmed, err := h.confirmTwoFactor(c, &req, user); !confirmed {
+		return err
+	}
+
+	codes, err := replaceRecoveryCodes(h.db, user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate recovery codes",
+		})
+	}
+
+	return c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// confirmTwoFactor checks the TOTP or recovery code a user with 2FA enabled
+// sent to confirm a change. It writes an error response and returns false if
+// the code is missing or wrong. Wrong codes count towards the login lockout.
+func (h *AuthHandler) confirmTwoFactor(c echo.Context, req *TwoFactorRequest, user *models.User) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return false, c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Code or recovery code is required",
+		})
+	}
+	if locked, err := h.loginLocked(c, user.ID, user.Username); locked {
+		return false, err
+	}
+
+	ok, err := h.checkCode(user, req)
+	if err != nil {
+		return false, c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to verify code",
+		})
+	}
+	if !ok {
+		h.loginFailed(c, user.ID, user.Username, loginFailureInvalidCode)
+		return false, invalidCode(c)
+	}
+	return true, nil
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+// twoFactorRequest calls a 2FA endpoint, as the signed in user if userID is not 0.
+func twoFactorRequest(t *testing.T, endpoint echo.HandlerFunc, body string, userID uint) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if userID != 0 {
+		c.Set(middleware.ContextKeyUserID, userID)
+	}
+	require.NoError(t, endpoint(c))
+	return rec
+}
+
+// loginChallengeForTest logs in a registered user who needs a second factor.
+func loginChallengeForTest(t *testing.T, handler *AuthHandler, username string) TwoFactorChallengeResponse {
+	t.Helper()
+	rec := twoFactorRequest(t, handler.Login, `{"username":"`+username+`","password":"testPassword123!"}`, 0)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response TwoFactorChallengeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.True(t, response.TwoFactorRequired)
+	require.NotEmpty(t, response.ChallengeToken)
+	return response
+}
+
+// totpCodeForTest returns the TOTP code of a secret at the current time step plus offset.
+func totpCodeForTest(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+offset)
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthHandler_TwoFactorLogin(t *testing.T) {
+	db := setupAuthTestDB(t)
+	jwtManager := auth.NewJWTManager(testJWTSecret, 15*time.Minute, 7*24*time.Hour)
+	handler := NewAuthHandler(db, jwtManager, nil)
+	loginForTest(t, handler, "alice")
+	handler.SetTwoFactorPolicy(10)
+
+	var secret string
+	var recoveryCodes []string
+
+	t.Run("should require enrollment when the role requires 2FA", func(t *testing.T) {
+		challenge := loginChallengeForTest(t, handler, "alice")
+		assert.True(t, challenge.EnrollmentRequired)
+		assert.Equal(t, 300, challenge.ExpiresIn)
+
+		rec := twoFactorRequest(t, handler.EnrollTwoFactor, `{"challenge_token":"`+challenge.ChallengeToken+`"}`, 0)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var enroll TwoFactorEnrollResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &enroll))
+		assert.Contains(t, enroll.OTPAuthURI, "otpauth://totp/Sabakan:alice")
+		secret = enroll.Secret
+
+		rec = twoFactorRequest(t, handler.EnableTwoFactor, `{"challenge_token":"`+challenge.ChallengeToken+`","code":"000000x"}`, 0)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		code := totpCodeForTest(t, secret, 0)
+		rec = twoFactorRequest(t, handler.EnableTwoFactor, `{"challenge_token":"`+challenge.ChallengeToken+`","code":"`+code+`"}`, 0)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var enabled RecoveryCodesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &enabled))
+		assert.Len(t, enabled.RecoveryCodes, auth.RecoveryCodeCount)
+		require.NotNil(t, enabled.AuthResponse)
+		assert.NotEmpty(t, enabled.AccessToken)
+		assert.NotEmpty(t, enabled.RefreshToken)
+		recoveryCodes = enabled.RecoveryCodes
+
+		var user models.User
+		require.NoError(t, db.Where("username = ?", "alice").First(&user).Error)
+		assert.True(t, user.TOTPEnabled)
+	})
+
+	t.Run("should reject an enroll challenge token for verification", func(t *testing.T) {
+		token, err := jwtManager.GenerateChallengeToken(1, auth.ChallengeEnroll)
+		require.NoError(t, err)
+
+		rec := twoFactorRequest(t, handler.VerifyTwoFactor, `{"challenge_token":"`+token+`","code":"123456"}`, 0)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should ask for a code once 2FA is enabled", func(t *testing.T) {
+		challenge := loginChallengeForTest(t, handler, "alice")
+		assert.False(t, challenge.EnrollmentRequired)
+
+		// The code used to enable 2FA cannot be replayed
+		var user models.User
+		require.NoError(t, db.Where("username = ?", "alice").First(&user).Error)
+		used, err := auth.TOTPCode(secret, user.TOTPLastStep)
+		require.NoError(t, err)
+		rec := twoFactorRequest(t, handler.VerifyTwoFactor, `{"challenge_token":"`+challenge.ChallengeToken+`","code":"`+used+`"}`, 0)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = twoFactorRequest(t, handler.VerifyTwoFactor, `{"challenge_token":"`+challenge.ChallengeToken+`","code":"`+totpCodeForTest(t, secret, 1)+`"}`, 0)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.AccessToken)
+	})
+
+	t.Run("should accept each recovery code once", func(t *testing.T) {
+		challenge := loginChallengeForTest(t, handler, "alice")
+		body := `{"challenge_token":"` + challenge.ChallengeToken + `","recovery_code":"` + strings.ToUpper(recoveryCodes[0]) + `"}`
+
+		rec := twoFactorRequest(t, handler.VerifyTwoFactor, body, 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = twoFactorRequest(t, handler.VerifyTwoFactor, body, 0)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should reject an invalid challenge token", func(t *testing.T) {
+		rec := twoFactorRequest(t, handler.VerifyTwoFactor, `{"challenge_token":"invalid","code":"123456"}`, 0)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthHandler_TwoFactorSettings(t *testing.T) {
+	db := setupAuthTestDB(t)
+	jwtManager := auth.NewJWTManager(testJWTSecret, 15*time.Minute, 7*24*time.Hour)
+	handler := NewAuthHandler(db, jwtManager, nil)
+	loginForTest(t, handler, "bob")
+
+	var user models.User
+	require.NoError(t, db.Where("username = ?", "bob").First(&user).Error)
+
+	var secret string
+
+	t.Run("should enable 2FA for a signed in user", func(t *testing.T) {
+		rec := twoFactorRequest(t, handler.EnrollTwoFactor, `{}`, user.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var enroll TwoFactorEnrollResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &enroll))
+		secret = enroll.Secret
+
+		rec = twoFactorRequest(t, handler.EnableTwoFactor, `{"code":"`+totpCodeForTest(t, secret, -1)+`"}`, user.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "access_token")
+
+		var audit models.AuditLog
+		require.NoError(t, db.Where("target_type = ? AND target_id = ?", models.AuditLogTargetUser, user.ID).First(&audit).Error)
+		assert.Equal(t, models.AuditLogActionUpdate, audit.Action)
+	})
+
+	t.Run("should not enroll again while 2FA is enabled", func(t *testing.T) {
+		rec := twoFactorRequest(t, handler.EnrollTwoFactor, `{}`, user.ID)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("should replace the recovery codes", func(t *testing.T) {
+		rec := twoFactorRequest(t, handler.RegenerateRecoveryCodes, `{"code":"`+totpCodeForTest(t, secret, 0)+`"}`, user.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var count int64
+		db.Model(&models.RecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(auth.RecoveryCodeCount), count)
+	})
+
+	t.Run("should refuse requests authenticated with an API token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/2fa/disable", strings.NewReader(`{"code":"`+totpCodeForTest(t, secret, 1)+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.Set(middleware.ContextKeyUserID, user.ID)
+		c.Set(middleware.ContextKeyAPITokenID, uint(1))
+
+		require.NoError(t, handler.DisableTwoFactor(c))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("should not disable 2FA required by the role", func(t *testing.T) {
+		handler.SetTwoFactorPolicy(10)
+		defer handler.SetTwoFactorPolicy(0)
+
+		rec := twoFactorRequest(t, handler.DisableTwoFactor, `{"code":"`+totpCodeForTest(t, secret, 1)+`"}`, user.ID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("should disable 2FA with a valid code", func(t *testing.T) {
+		rec := twoFactorRequest(t, handler.DisableTwoFactor, `{"code":"000000"}`, user.ID)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = twoFactorRequest(t, handler.DisableTwoFactor, `{"code":"`+totpCodeForTest(t, secret, 1)+`"}`, user.ID)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		var updated models.User
+		require.NoError(t, db.First(&updated, user.ID).Error)
+		assert.False(t, updated.TOTPEnabled)
+		assert.Empty(t, updated.TOTPSecret)
+
+		var count int64
+		db.Model(&models.RecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Zero(t, count)
+
+		// Login no longer asks for a code
+		loginRec := twoFactorRequest(t, handler.Login, `{"username":"bob","password":"testPassword123!"}`, 0)
+		assert.Contains(t, loginRec.Body.String(), "access_token")
+	})
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+const (
+	// upgradeTimeout bounds an upgrade or recreation including the image pull.
+	upgradeTimeout = time.Hour
+	// resumeTimeout bounds restarting a server after an aborted upgrade.
+	resumeTimeout = 2 * time.Minute
+)
+
+// Upgrade handles POST /api/game-servers/:slug/upgrade.
+// The server is stopped, its volumes are backed up, the image is pulled again
+// and the container is recreated with the same ports, volumes and environment.
+// A server that was running is started again. Pinned servers are upgraded to
+// their pinned digest.
+func (h *GameServerHandler) Upgrade(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Slug is required",
+		})
+	}
+
+	var server models.GameServer
+	if err := h.db.Where("slug = ?", slug).
+		Preload("Ports").
+		Preload("Envs").
+		Preload("Volumes").
+		First(&server).Error; err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Game server not found",
+		})
+	}
+
+	// A client disconnecting during a long pull must not abort the upgrade
+	// halfway and leave the server stopped
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request().Context()), upgradeTimeout)
+	defer cancel()
+	runtime, err := h.runtime(&server)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+	wasRunning := server.Status.IsRunning() && server.ContainerID != ""
+	details := map[string]any{
+		"image":          server.ImageRef(),
+		"oldContainerId": server.ContainerID,
+		"oldImageDigest": server.ImageDigest,
+	}
+
+	if wasRunning {
+		gracefulErr, err := h.stopServer(ctx, &server, stopTimeout(c))
+		for k, v := range stopDetails(gracefulErr) {
+			details[k] = v
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "container_error",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	backups, err := h.backupVolumes(ctx, runtime, &server)
+	if err != nil {
+		h.resume(&server, wasRunning)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "backup_error",
+			Message: err.Error(),
+		})
+	}
+	details["backups"] = backups
+
+	if _, err := runtime.PullImage(ctx, server.ImageRef(), nil); err != nil {
+		h.resume(&server, wasRunning)
+		return c.JSON(http.StatusBadGateway, ErrorResponse{
+			Error:   "pull_error",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.replaceContainer(ctx, &server); err != nil {
+		h.resume(&server, wasRunning)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "container_error",
+			Message: err.Error(),
+		})
+	}
+
+	server.UpdateAvailable = false
+	h.db.Model(&server).Update("update_available", false)
+
+	if wasRunning {
+		if err := h.startServer(ctx, &server); err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "container_error",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	details["newContainerId"] = server.ContainerID
+	details["newImageDigest"] = server.ImageDigest
+	recordAudit(h.db, c, models.AuditLogTargetGameServer, server.ID, models.AuditLogActionUpgrade, details)
+
+	maskSecrets(&server)
+	return c.JSON(http.StatusOK, server)
+}
+
+// resume starts a server again after an aborted upgrade or recreation if it
+// was running before. It uses a fresh context, since the aborted operation
+// may have failed because its own context was cancelled or timed out.
+func (h *GameServerHandler) resume(server *models.GameServer, wasRunning bool) {
+	if !wasRunning {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resumeTimeout)
+	defer cancel()
+	_ = h.startServer(ctx, server)
+}
+
+// backupVolumes exports every volume of a server into a tar archive under
+// <backup dir>/<slug>/ and returns the archive paths.
+func (h *GameServerHandler) backupVolumes(ctx context.Context, runtime container.Runtime, server *models.GameServer) ([]string, error) {
+	paths := []string{}
+	if len(server.Volumes) == 0 {
+		return paths, nil
+	}
+
+	dir := filepath.Join(h.backupDir, server.Slug)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	timestamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, v := range server.Volumes {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.tar", timestamp, v.Name))
+		if err := exportVolume(ctx, runtime, v.VolumeName, path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// exportVolume writes a volume archive to path, removing partial files on failure.
+func exportVolume(ctx context.Context, runtime container.Runtime, volume, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	err = runtime.ExportVolume(ctx, volume, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to back up volume %s: %w", volume, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/container"
+	"github.com/sweetfish329/sabakan/backend/internal/container/containertest"
+	"github.com/sweetfish329/sabakan/backend/internal/middleware"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+)
+
+func TestGameServerHandler_Upgrade(t *testing.T) {
+	db := setupGameServerTestDB(t)
+
+	runtime := containertest.New()
+	runtime.CreateID = "new-container"
+	runtime.Containers["old-container"] = &models.Container{ID: "old-container", Name: "sabakan-upgrade", State: models.StateRunning}
+	runtime.Volumes["sabakan-upgrade-data"] = nil
+	runtime.Images["itzg/minecraft-server:latest"] = &models.Image{ID: "img-new", Digest: "sha256:new"}
+
+	backupDir := t.TempDir()
+	handler := NewGameServerHandler(db, container.NewPool(runtime))
+	handler.SetBackupDir(backupDir)
+
+	// upgradeCalls returns the calls made since the given number of calls.
+	upgradeCalls := func(since int) []string {
+		return runtime.CallLog()[since:]
+	}
+
+	server := models.GameServer{
+		Slug:            "upgrade",
+		Name:            "Upgrade",
+		Image:           "itzg/minecraft-server:latest",
+		Status:          models.GameServerStatusRunning,
+		ContainerID:     "old-container",
+		ImageDigest:     "sha256:old",
+		UpdateAvailable: true,
+		OwnerID:         1,
+	}
+	db.Create(&server)
+	db.Create(&models.GameServerVolume{GameServerID: server.ID, Name: "data", VolumeName: "sabakan-upgrade-data", MountPath: "/data"})
+
+	t.Run("should back up, pull, recreate and restart the server", func(t *testing.T) {
+		since := len(runtime.CallLog())
+		rec := lifecycleRequest(t, handler.Upgrade, "upgrade")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{
+			"Stop old-container",
+			"ExportVolume sabakan-upgrade-data",
+			"PullImage itzg/minecraft-server:latest",
+			"EnsureVolume sabakan-upgrade-data",
+			"Create sabakan-upgrade-next",
+			"Remove old-container",
+			"Rename new-container sabakan-upgrade",
+			"GetImage itzg/minecraft-server:latest",
+			"Start new-container",
+		}, upgradeCalls(since))
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, "new-container", updated.ContainerID)
+		assert.Equal(t, "sha256:new", updated.ImageDigest)
+		assert.False(t, updated.UpdateAvailable)
+		assert.Equal(t, models.GameServerStatusRunning, updated.Status)
+
+		backups, err := filepath.Glob(filepath.Join(backupDir, "upgrade", "*-data.tar"))
+		require.NoError(t, err)
+		if assert.Len(t, backups, 1) {
+			data, err := os.ReadFile(backups[0])
+			require.NoError(t, err)
+			// The fake runtime writes the volume name in place of an archive
+			assert.Equal(t, "sabakan-upgrade-data", string(data))
+		}
+
+		var audit models.AuditLog
+		db.Where("action = ?", models.AuditLogActionUpgrade).First(&audit)
+		assert.Equal(t, server.ID, audit.TargetID)
+	})
+
+	t.Run("should keep the container and restart it when the pull fails", func(t *testing.T) {
+		runtime.Errors["PullImage"] = errors.New("connection refused")
+		defer delete(runtime.Errors, "PullImage")
+
+		since := len(runtime.CallLog())
+		rec := lifecycleRequest(t, handler.Upgrade, "upgrade")
+
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		calls := upgradeCalls(since)
+		assert.NotContains(t, calls, "Remove new-container")
+		assert.Equal(t, "Start new-container", calls[len(calls)-1])
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, "new-container", updated.ContainerID)
+		assert.Equal(t, models.GameServerStatusRunning, updated.Status)
+	})
+
+	t.Run("should keep the container and restart it when the new one cannot be created", func(t *testing.T) {
+		runtime.Errors["Create"] = errors.New("image not usable")
+		defer delete(runtime.Errors, "Create")
+
+		since := len(runtime.CallLog())
+		rec := lifecycleRequest(t, handler.Upgrade, "upgrade")
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		calls := upgradeCalls(since)
+		assert.Contains(t, calls, "Create sabakan-upgrade-next")
+		assert.NotContains(t, calls, "Remove new-container")
+		assert.Equal(t, "Start new-container", calls[len(calls)-1])
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, "new-container", updated.ContainerID)
+		assert.Equal(t, models.GameServerStatusRunning, updated.Status)
+	})
+
+	t.Run("should finish the upgrade when the client disconnects", func(t *testing.T) {
+		runtime.CreateID = "newer-container"
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodPost, "/api/game-servers/upgrade/upgrade", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("upgrade")
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		since := len(runtime.CallLog())
+		require.NoError(t, handler.Upgrade(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		calls := upgradeCalls(since)
+		assert.Equal(t, "Start newer-container", calls[len(calls)-1])
+
+		var updated models.GameServer
+		db.First(&updated, server.ID)
+		assert.Equal(t, "newer-container", updated.ContainerID)
+		assert.Equal(t, models.GameServerStatusRunning, updated.Status)
+	})
+}
+
+func TestGameServerHandler_Update_PinnedDigest(t *testing.T) {
+	db := setupGameServerTestDB(t)
+	handler := newTestGameServerHandler(t, db)
+
+	db.Create(&models.GameServer{Slug: "pin", Name: "Pin", Image: "itzg/minecraft-server:latest", OwnerID: 1, UpdateAvailable: true})
+
+	e := echo.New()
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/game-servers/pin", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("pin")
+		c.Set(middleware.ContextKeyUserID, uint(1))
+
+		assert.NoError(t, handler.Update(c))
+		return rec
+	}
+
+	digest := "sha256:" + string(bytes.Repeat([]byte("a"), 64))
+
+	t.Run("should pin the server to a digest", func(t *testing.T) {
+		rec := update(`{"pinnedDigest":"` + digest + `"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var server models.GameServer
+		db.Where("slug = ?", "pin").First(&server)
+		assert.Equal(t, digest, server.PinnedDigest)
+		assert.False(t, server.UpdateAvailable)
+		assert.Equal(t, "itzg/minecraft-server@"+digest, server.ImageRef())
+	})
+
+	t.Run("should reject malformed digests", func(t *testing.T) {
+		rec := update(`{"pinnedDigest":"latest"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should unpin with an empty digest", func(t *testing.T) {
+		rec := update(`{"pinnedDigest":""}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var server models.GameServer
+		db.Where("slug = ?", "pin").First(&server)
+		assert.Empty(t, server.PinnedDigest)
+	})
+}
@@ -4,10 +4,13 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
 	"github.com/sweetfish329/sabakan/backend/internal/redis"
+	"gorm.io/gorm"
 )
 
 // Context keys for user information.
@@ -16,12 +19,18 @@ const (
 	ContextKeyUsername = "username"
 	ContextKeyJTI      = "jti"
 	ContextKeyClaims   = "claims"
+	// ContextKeyAPITokenID is set instead of the JWT keys when a request
+	// authenticates with a personal API token.
+	ContextKeyAPITokenID = "api_token_id"
+	// ContextKeyScopes holds the scopes of the API token of a request.
+	ContextKeyScopes = "scopes"
 )
 
-// AuthMiddleware handles JWT authentication.
+// AuthMiddleware handles JWT and API token authentication.
 type AuthMiddleware struct {
 	jwtManager   *auth.JWTManager
 	sessionStore redis.SessionStore
+	db           *gorm.DB
 }
 
 // NewAuthMiddleware creates a new authentication middleware.
@@ -32,10 +41,29 @@ func NewAuthMiddleware(jwtManager *auth.JWTManager, sessionStore redis.SessionSt
 	}
 }
 
-// Authenticate returns a middleware that validates JWT tokens.
+// SetDB enables authentication with personal API tokens stored in db.
+func (m *AuthMiddleware) SetDB(db *gorm.DB) {
+	m.db = db
+}
+
+// Authenticate returns a middleware that validates JWT tokens, and API tokens
+// if they are enabled.
 func (m *AuthMiddleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authHeader := c.Request().Header.Get("Authorization")
+		if authHeader == "" && isWebSocketUpgrade(c.Request()) {
+			// Browsers cannot set headers on WebSocket requests
+			if token := c.QueryParam("access_token"); token != "" {
+				// Long-lived API tokens must not end up in URLs, only short-lived access tokens
+				if auth.IsAPIToken(token) {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error":   "unauthorized",
+						"message": "API tokens must be sent in the Authorization header",
+					})
+				}
+				authHeader = "Bearer " + token
+			}
+		}
 		if authHeader == "" {
 			return c.JSON(http.StatusUnauthorized, map[string]string{
 				"error":   "unauthorized",
@@ -59,6 +87,10 @@ func (m *AuthMiddleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
+		if auth.IsAPIToken(tokenString) && m.db != nil {
+			return m.authenticateAPIToken(c, tokenString, next)
+		}
+
 		// Validate token
 		claims, err := m.jwtManager.ValidateAccessToken(tokenString)
 		if err != nil {
@@ -95,6 +127,34 @@ func (m *AuthMiddleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// authenticateAPIToken authenticates a request with a personal API token and
+// records when the token was used.
+func (m *AuthMiddleware) authenticateAPIToken(c echo.Context, tokenString string, next echo.HandlerFunc) error {
+	var token models.APIToken
+	err := m.db.Preload("User").Where("token_hash = ?", auth.HashAPIToken(tokenString)).First(&token).Error
+	if err != nil || token.IsExpired() || token.User.ID == 0 || !token.User.IsActive {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error":   "unauthorized",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	// Failing to record the last use must not fail the request
+	_ = m.db.Model(&token).UpdateColumn("last_used_at", time.Now()).Error
+
+	c.Set(ContextKeyUserID, token.UserID)
+	c.Set(ContextKeyUsername, token.User.Username)
+	c.Set(ContextKeyAPITokenID, token.ID)
+	c.Set(ContextKeyScopes, token.ScopeList())
+
+	return next(c)
+}
+
+// isWebSocketUpgrade reports whether the request asks for a WebSocket upgrade.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
 // GetUserID retrieves the user ID from the context.
 func GetUserID(c echo.Context) uint {
 	userID, ok := c.Get(ContextKeyUserID).(uint)
@@ -130,3 +190,22 @@ func GetClaims(c echo.Context) *auth.AccessTokenClaims {
 	}
 	return claims
 }
+
+// GetAPITokenID retrieves the ID of the API token the request authenticated with.
+// It returns 0 for requests authenticated with a JWT.
+func GetAPITokenID(c echo.Context) uint {
+	tokenID, ok := c.Get(ContextKeyAPITokenID).(uint)
+	if !ok {
+		return 0
+	}
+	return tokenID
+}
+
+// GetScopes retrieves the scopes of the API token the request authenticated with.
+func GetScopes(c echo.Context) []string {
+	scopes, ok := c.Get(ContextKeyScopes).([]string)
+	if !ok {
+		return nil
+	}
+	return scopes
+}
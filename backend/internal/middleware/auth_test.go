@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -8,7 +9,10 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/sweetfish329/sabakan/backend/internal/auth"
+	"github.com/sweetfish329/sabakan/backend/internal/models"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
 )
 
 const testSecret = "test-secret-key-for-middleware-32b!"
@@ -173,3 +177,157 @@ func TestAuthMiddleware_ContextValues(t *testing.T) {
 		assert.Equal(t, "contextuser", capturedUsername)
 	})
 }
+
+func TestAuthMiddleware_QueryToken(t *testing.T) {
+	e := echo.New()
+	jwtManager := auth.NewJWTManager(testSecret, 15*time.Minute, 7*24*time.Hour)
+	middleware := NewAuthMiddleware(jwtManager, nil)
+
+	handler := middleware.Authenticate(func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	token, _, _ := jwtManager.GenerateAccessToken(123, "testuser")
+
+	t.Run("should accept access_token query for WebSocket upgrades", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?access_token="+token, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("should reject API tokens in the access_token query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?access_token="+auth.APITokenPrefix+"secret", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Authorization header")
+	})
+
+	t.Run("should ignore access_token query for plain requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?access_token="+token, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthMiddleware_APIToken(t *testing.T) {
+	e := echo.New()
+	db := setupTestDB(t)
+	seedTestData(t, db)
+	require.NoError(t, db.AutoMigrate(&models.APIToken{}))
+	jwtManager := auth.NewJWTManager(testSecret, 15*time.Minute, 7*24*time.Hour)
+	middleware := NewAuthMiddleware(jwtManager, nil)
+	middleware.SetDB(db)
+
+	var user models.User
+	require.NoError(t, db.Where("username = ?", "regularuser").First(&user).Error)
+
+	createToken := func(t *testing.T, expiresAt *time.Time) string {
+		secret, hash, err := auth.GenerateAPIToken()
+		require.NoError(t, err)
+		token := models.APIToken{UserID: user.ID, Name: "ci", TokenHash: hash, TokenPrefix: secret[:auth.APITokenDisplayLength], ExpiresAt: expiresAt}
+		require.NoError(t, token.SetScopes([]string{"game_server:read"}))
+		require.NoError(t, db.Create(&token).Error)
+		return secret
+	}
+
+	var capturedUserID, capturedTokenID uint
+	var capturedScopes []string
+	handler := middleware.Authenticate(func(c echo.Context) error {
+		capturedUserID = GetUserID(c)
+		capturedTokenID = GetAPITokenID(c)
+		capturedScopes = GetScopes(c)
+		return c.String(http.StatusOK, "OK")
+	})
+
+	request := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler(e.NewContext(req, rec)))
+		return rec
+	}
+
+	t.Run("should authenticate the owner of a valid API token", func(t *testing.T) {
+		token := createToken(t, nil)
+
+		rec := request(token)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, user.ID, capturedUserID)
+		assert.NotZero(t, capturedTokenID)
+		assert.Equal(t, []string{"game_server:read"}, capturedScopes)
+
+		var stored models.APIToken
+		require.NoError(t, db.First(&stored, capturedTokenID).Error)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("should reject unknown API tokens", func(t *testing.T) {
+		rec := request(auth.APITokenPrefix + "unknown")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should reject expired API tokens", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		token := createToken(t, &expiresAt)
+
+		rec := request(token)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should reject API tokens of deactivated users", func(t *testing.T) {
+		token := createToken(t, nil)
+		require.NoError(t, db.Model(&user).Update("is_active", false).Error)
+		defer db.Model(&user).Update("is_active", true)
+
+		rec := request(token)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	e := echo.New()
+	jwtManager := auth.NewJWTManager(testSecret, 15*time.Minute, 7*24*time.Hour)
+	sessionStore := redis.NewMemorySessionStore(redis.DefaultCleanupInterval)
+	defer sessionStore.Close()
+	middleware := NewAuthMiddleware(jwtManager, sessionStore)
+
+	handler := middleware.Authenticate(func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	t.Run("should reject tokens revoked in the session store", func(t *testing.T) {
+		token, jti, _ := jwtManager.GenerateAccessToken(123, "testuser")
+		require.NoError(t, sessionStore.RevokeSession(context.Background(), jti, time.Hour))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		assert.NoError(t, handler(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "revoked")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/auth"
 	"github.com/sweetfish329/sabakan/backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -19,6 +20,7 @@ func NewPermissionMiddleware(db *gorm.DB) *PermissionMiddleware {
 }
 
 // RequirePermission returns a middleware that checks if the user has the required permission.
+// Requests authenticated with an API token also need the permission in the token's scopes.
 func (m *PermissionMiddleware) RequirePermission(resource, action string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -31,6 +33,14 @@ func (m *PermissionMiddleware) RequirePermission(resource, action string) echo.M
 				})
 			}
 
+			// API tokens are limited to their scopes on top of the owner's role
+			if GetAPITokenID(c) != 0 && !auth.HasScope(GetScopes(c), resource, action) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error":   "forbidden",
+					"message": "The API token does not have the required scope",
+				})
+			}
+
 			// Check if user has the required permission
 			hasPermission, err := m.checkPermission(userID, resource, action)
 			if err != nil {
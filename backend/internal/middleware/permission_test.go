@@ -238,5 +238,44 @@ func TestPermissionMiddleware_MissingUserContext(t *testing.T) {
 	})
 }
 
+func TestPermissionMiddleware_APITokenScopes(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestData(t, db)
+	permMiddleware := NewPermissionMiddleware(db)
+
+	e := echo.New()
+
+	request := func(username string, scopes []string, resource, action string) int {
+		var user models.User
+		db.Where("username = ?", username).First(&user)
+
+		handler := permMiddleware.RequirePermission(resource, action)(func(c echo.Context) error {
+			return c.String(http.StatusOK, "OK")
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(ContextKeyUserID, user.ID)
+		c.Set(ContextKeyAPITokenID, uint(1))
+		c.Set(ContextKeyScopes, scopes)
+
+		assert.NoError(t, handler(c))
+		return rec.Code
+	}
+
+	t.Run("should allow actions in the token's scopes", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("regularuser", []string{"game_server:read"}, "game_server", "read"))
+	})
+
+	t.Run("should deny actions outside the token's scopes", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("admin", []string{"game_server:read"}, "game_server", "delete"))
+	})
+
+	t.Run("should deny scopes the owner's role no longer has", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("regularuser", []string{"game_server:delete"}, "game_server", "delete"))
+	})
+}
+
 // Helper to suppress unused variable warnings
 var _ = context.Background
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
+)
+
+// RateLimiter limits how many requests each client IP makes. Requests are
+// counted in the session store, so that instances sharing Redis share limits.
+type RateLimiter struct {
+	store redis.CounterStore
+}
+
+// NewRateLimiter creates a rate limiter counting in store. Without a store, nothing is limited.
+func NewRateLimiter(store redis.CounterStore) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+// Limit returns a middleware that allows limit requests per window from each
+// client IP. name keeps the counters of route groups apart. A limit of zero
+// or less allows every request.
+func (r *RateLimiter) Limit(name string, limit int, window time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if r.store == nil || limit <= 0 {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			key := "ratelimit:" + name + ":" + c.RealIP()
+			count, resetIn, err := r.store.Increment(c.Request().Context(), key, window)
+			if err != nil {
+				// An unavailable store must not take the API down with it
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			header.Set("X-RateLimit-Remaining", strconv.FormatInt(max(int64(limit)-count, 0), 10))
+			if count > int64(limit) {
+				header.Set("Retry-After", RetryAfter(resetIn))
+				return c.JSON(http.StatusTooManyRequests, map[string]string{
+					"error":   "too_many_requests",
+					"message": "Rate limit exceeded, try again later",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RetryAfter formats a wait as the value of a Retry-After header, in whole seconds rounded up.
+func RetryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
+)
+
+func TestRateLimiter_Limit(t *testing.T) {
+	store := redis.NewMemorySessionStore(redis.DefaultCleanupInterval)
+	defer store.Close()
+	limiter := NewRateLimiter(store)
+	e := echo.New()
+
+	request := func(handler echo.HandlerFunc, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":12345"
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler(e.NewContext(req, rec)))
+		return rec
+	}
+	ok := func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	}
+
+	t.Run("should reject requests over the limit", func(t *testing.T) {
+		handler := limiter.Limit("test", 2, time.Minute)(ok)
+
+		rec := request(handler, "192.0.2.1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, http.StatusOK, request(handler, "192.0.2.1").Code)
+
+		rec = request(handler, "192.0.2.1")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("should count each client IP separately", func(t *testing.T) {
+		handler := limiter.Limit("test", 2, time.Minute)(ok)
+
+		assert.Equal(t, http.StatusOK, request(handler, "192.0.2.2").Code)
+	})
+
+	t.Run("should count each route group separately", func(t *testing.T) {
+		handler := limiter.Limit("other", 2, time.Minute)(ok)
+
+		assert.Equal(t, http.StatusOK, request(handler, "192.0.2.1").Code)
+	})
+
+	t.Run("should allow requests again after the window", func(t *testing.T) {
+		handler := limiter.Limit("short", 1, 50*time.Millisecond)(ok)
+
+		assert.Equal(t, http.StatusOK, request(handler, "192.0.2.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(handler, "192.0.2.1").Code)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, http.StatusOK, request(handler, "192.0.2.1").Code)
+	})
+
+	t.Run("should not limit without a limit or a store", func(t *testing.T) {
+		handler := limiter.Limit("unlimited", 0, time.Minute)(ok)
+		for range 3 {
+			assert.Equal(t, http.StatusOK, request(handler, "192.0.2.1").Code)
+		}
+
+		handler = NewRateLimiter(nil).Limit("test", 1, time.Minute)(ok)
+		for range 3 {
+			assert.Equal(t, http.StatusOK, request(handler, "192.0.2.1").Code)
+		}
+	})
+}
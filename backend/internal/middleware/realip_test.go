@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/sweetfish329/sabakan/backend/internal/redis"
+)
+
+func TestIPExtractor(t *testing.T) {
+	request := func(remoteAddr, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr + ":12345"
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		return req
+	}
+
+	t.Run("should ignore forwarded headers without trusted proxies", func(t *testing.T) {
+		extractor, err := IPExtractor(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "192.0.2.1", extractor(request("192.0.2.1", "198.51.100.1")))
+		assert.Equal(t, "127.0.0.1", extractor(request("127.0.0.1", "198.51.100.1")))
+	})
+
+	t.Run("should follow X-Forwarded-For through trusted proxies", func(t *testing.T) {
+		extractor, err := IPExtractor([]string{"10.0.0.0/8", "192.0.2.1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "198.51.100.1", extractor(request("10.1.2.3", "198.51.100.1")))
+		assert.Equal(t, "198.51.100.1", extractor(request("192.0.2.1", "203.0.113.1, 198.51.100.1, 10.1.2.3")))
+	})
+
+	t.Run("should ignore X-Forwarded-For from untrusted clients", func(t *testing.T) {
+		extractor, err := IPExtractor([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "192.0.2.2", extractor(request("192.0.2.2", "198.51.100.1")))
+		assert.Equal(t, "172.16.0.1", extractor(request("172.16.0.1", "198.51.100.1")))
+	})
+
+	t.Run("should reject invalid proxies", func(t *testing.T) {
+		_, err := IPExtractor([]string{"proxy.example"})
+		assert.Error(t, err)
+
+		_, err = IPExtractor([]string{"10.0.0.0/33"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should not let a spoofed X-Forwarded-For bypass the rate limit", func(t *testing.T) {
+		store := redis.NewMemorySessionStore(redis.DefaultCleanupInterval)
+		defer store.Close()
+		e := echo.New()
+		extractor, err := IPExtractor(nil)
+		require.NoError(t, err)
+		e.IPExtractor = extractor
+
+		handler := NewRateLimiter(store).Limit("test", 2, time.Minute)(func(c echo.Context) error {
+			return c.String(http.StatusOK, "OK")
+		})
+		codes := make([]int, 0, 3)
+		for _, spoofed := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+			rec := httptest.NewRecorder()
+			require.NoError(t, handler(e.NewContext(request("192.0.2.1", spoofed), rec)))
+			codes = append(codes, rec.Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+}
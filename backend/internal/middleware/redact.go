@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// redacted replaces the values of redacted query parameters.
+const redacted = "REDACTED"
+
+// RedactQuery returns a middleware that masks the values of the given query
+// parameters in the request URI written to the access log, such as the
+// access_token of WebSocket requests. Handlers still read the original values
+// from the parsed URL.
+func RedactQuery(params ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			query := req.URL.Query()
+			masked := false
+			for _, param := range params {
+				if query.Has(param) {
+					query.Set(param, redacted)
+					masked = true
+				}
+			}
+			if masked {
+				uri := *req.URL
+				uri.RawQuery = query.Encode()
+				req.RequestURI = uri.RequestURI()
+			}
+			return next(c)
+		}
+	}
+}
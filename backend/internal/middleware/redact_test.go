@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactQuery(t *testing.T) {
+	var logs bytes.Buffer
+	e := echo.New()
+	e.Pre(RedactQuery("access_token"))
+	e.Use(echoMiddleware.LoggerWithConfig(echoMiddleware.LoggerConfig{Format: "${uri}\n", Output: &logs}))
+
+	var token string
+	e.GET("/ws", func(c echo.Context) error {
+		token = c.QueryParam("access_token")
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("should keep the token out of the access log", func(t *testing.T) {
+		logs.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/ws?access_token=secret&tail=100", nil)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "secret", token)
+		assert.Equal(t, "/ws?access_token=REDACTED&tail=100\n", logs.String())
+	})
+
+	t.Run("should leave other requests untouched", func(t *testing.T) {
+		logs.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/ws?tail=100", nil)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, "/ws?tail=100\n", logs.String())
+	})
+}
@@ -18,8 +18,20 @@ const (
 	AuditLogActionStart AuditLogAction = "start"
 	// AuditLogActionStop indicates a stop action (for game servers).
 	AuditLogActionStop AuditLogAction = "stop"
+	// AuditLogActionRestart indicates a restart action (for game servers).
+	AuditLogActionRestart AuditLogAction = "restart"
+	// AuditLogActionRecreate indicates a container recreation (for game servers).
+	AuditLogActionRecreate AuditLogAction = "recreate"
+	// AuditLogActionUpgrade indicates an image upgrade (for game servers).
+	AuditLogActionUpgrade AuditLogAction = "upgrade"
+	// AuditLogActionConsole indicates a console session (for game servers).
+	AuditLogActionConsole AuditLogAction = "console"
+	// AuditLogActionRCON indicates an RCON command (for game servers).
+	AuditLogActionRCON AuditLogAction = "rcon"
 	// AuditLogActionLogin indicates a user login.
 	AuditLogActionLogin AuditLogAction = "login"
+	// AuditLogActionLoginFailed indicates a failed login attempt.
+	AuditLogActionLoginFailed AuditLogAction = "login_failed"
 	// AuditLogActionLogout indicates a user logout.
 	AuditLogActionLogout AuditLogAction = "logout"
 )
@@ -38,6 +50,8 @@ const (
 	AuditLogTargetRole AuditLogTargetType = "role"
 	// AuditLogTargetSession indicates a session target.
 	AuditLogTargetSession AuditLogTargetType = "session"
+	// AuditLogTargetAPIToken indicates a personal API token target.
+	AuditLogTargetAPIToken AuditLogTargetType = "api_token"
 )
 
 // AuditLog records user actions for auditing purposes.
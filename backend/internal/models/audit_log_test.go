@@ -14,6 +14,7 @@ func TestAuditLogAction_Constants(t *testing.T) {
 		assert.Equal(t, AuditLogAction("start"), AuditLogActionStart)
 		assert.Equal(t, AuditLogAction("stop"), AuditLogActionStop)
 		assert.Equal(t, AuditLogAction("login"), AuditLogActionLogin)
+		assert.Equal(t, AuditLogAction("login_failed"), AuditLogActionLoginFailed)
 		assert.Equal(t, AuditLogAction("logout"), AuditLogActionLogout)
 	})
 }
@@ -25,6 +26,7 @@ func TestAuditLogTargetType_Constants(t *testing.T) {
 		assert.Equal(t, AuditLogTargetType("mod"), AuditLogTargetMod)
 		assert.Equal(t, AuditLogTargetType("role"), AuditLogTargetRole)
 		assert.Equal(t, AuditLogTargetType("session"), AuditLogTargetSession)
+		assert.Equal(t, AuditLogTargetType("api_token"), AuditLogTargetAPIToken)
 	})
 }
 
@@ -41,8 +41,12 @@ type Container struct {
 	ID string `json:"id"`
 	// Name is the human-readable name of the container.
 	Name string `json:"name"`
+	// Node is the name of the node the container runs on.
+	Node string `json:"node,omitempty"`
 	// Image is the container image used.
 	Image string `json:"image"`
+	// ImageID is the ID of the image the container was created from.
+	ImageID string `json:"imageId,omitempty"`
 	// State is the current state of the container.
 	State ContainerState `json:"state"`
 	// Status is a human-readable status string.
@@ -53,6 +57,40 @@ type Container struct {
 	Ports []PortMapping `json:"ports"`
 	// Labels are container labels.
 	Labels map[string]string `json:"labels"`
+	// Mounts are the volumes and bind mounts of the container.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Env holds the environment variables of the container.
+	Env map[string]string `json:"env,omitempty"`
+	// RestartPolicy is the restart policy of the container (no, always, on-failure, ...).
+	RestartPolicy string `json:"restartPolicy,omitempty"`
+	// ExitCode is the exit code of the last run.
+	ExitCode int `json:"exitCode"`
+	// OOMKilled reports whether the last run was killed for running out of memory.
+	OOMKilled bool `json:"oomKilled"`
+	// Error is the error message of the last failed start, if any.
+	Error string `json:"error,omitempty"`
+	// RestartCount is how many times the container was restarted by its restart policy.
+	RestartCount int `json:"restartCount"`
+	// Health is the health check status (starting, healthy, unhealthy), empty without a health check.
+	Health string `json:"health,omitempty"`
+	// StartedAt is when the container was last started.
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	// FinishedAt is when the container last exited.
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Mount represents a volume or bind mount of a container.
+type Mount struct {
+	// Type is the mount type (volume, bind, tmpfs).
+	Type string `json:"type"`
+	// Name is the volume name for volume mounts.
+	Name string `json:"name,omitempty"`
+	// Source is the path on the host.
+	Source string `json:"source"`
+	// Destination is the path inside the container.
+	Destination string `json:"destination"`
+	// ReadWrite reports whether the mount is writable.
+	ReadWrite bool `json:"rw"`
 }
 
 // ContainerLogEntry represents a single log entry.
@@ -64,3 +102,47 @@ type ContainerLogEntry struct {
 	// Message is the log message content.
 	Message string `json:"message"`
 }
+
+// ContainerStats represents a resource usage sample of a container.
+type ContainerStats struct {
+	// ContainerID is the ID of the container.
+	ContainerID string `json:"containerId"`
+	// Timestamp is when the sample was taken.
+	Timestamp time.Time `json:"timestamp"`
+	// CPUPercent is the CPU usage in percent of one core.
+	CPUPercent float64 `json:"cpuPercent"`
+	// MemoryUsage is the memory usage in bytes.
+	MemoryUsage uint64 `json:"memoryUsage"`
+	// MemoryLimit is the memory limit in bytes.
+	MemoryLimit uint64 `json:"memoryLimit"`
+	// MemoryPercent is the memory usage in percent of the limit.
+	MemoryPercent float64 `json:"memoryPercent"`
+	// NetworkRx is the total number of bytes received.
+	NetworkRx uint64 `json:"networkRx"`
+	// NetworkTx is the total number of bytes sent.
+	NetworkTx uint64 `json:"networkTx"`
+	// BlockRead is the total number of bytes read from disk.
+	BlockRead uint64 `json:"blockRead"`
+	// BlockWrite is the total number of bytes written to disk.
+	BlockWrite uint64 `json:"blockWrite"`
+	// PIDs is the number of processes.
+	PIDs uint64 `json:"pids"`
+}
+
+// ContainerEvent represents a container lifecycle event reported by Podman.
+type ContainerEvent struct {
+	// ContainerID is the ID of the container the event is about.
+	ContainerID string `json:"containerId"`
+	// Name is the name of the container.
+	Name string `json:"name"`
+	// Action is the event type (start, died, oom, health_status, remove, ...).
+	Action string `json:"action"`
+	// Attributes are the event attributes, including the container labels.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// ExitCode is the exit code of the container for died events.
+	ExitCode int `json:"exitCode"`
+	// HealthStatus is the health check result for health_status events.
+	HealthStatus string `json:"healthStatus,omitempty"`
+	// Time is when the event occurred.
+	Time time.Time `json:"time"`
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,28 +13,98 @@ type GameServerStatus string
 const (
 	// GameServerStatusStopped indicates a stopped server.
 	GameServerStatusStopped GameServerStatus = "stopped"
-	// GameServerStatusRunning indicates a running server.
+	// GameServerStatusRunning indicates a running server whose game has no readiness probe.
 	GameServerStatusRunning GameServerStatus = "running"
+	// GameServerStatusStarting indicates a running server whose game does not accept players yet.
+	GameServerStatusStarting GameServerStatus = "starting"
+	// GameServerStatusReady indicates a running server whose game accepts players.
+	GameServerStatusReady GameServerStatus = "ready"
 	// GameServerStatusCreating indicates a server being created.
 	GameServerStatusCreating GameServerStatus = "creating"
 	// GameServerStatusError indicates a server in error state.
 	GameServerStatusError GameServerStatus = "error"
 )
 
+// RunningStatuses are the statuses of servers whose container is running.
+var RunningStatuses = []GameServerStatus{GameServerStatusRunning, GameServerStatusStarting, GameServerStatusReady}
+
+// IsRunning reports whether the server's container is running, whether or not
+// the game is ready.
+func (s GameServerStatus) IsRunning() bool {
+	return s == GameServerStatusRunning || s == GameServerStatusStarting || s == GameServerStatusReady
+}
+
 // GameServer represents a managed game server container.
 type GameServer struct {
 	gorm.Model
 	Slug        string           `gorm:"uniqueIndex;not null" json:"slug"`
 	Name        string           `gorm:"not null" json:"name"`
 	Description string           `json:"description,omitempty"`
+	Game        string           `gorm:"index" json:"game"`
 	Image       string           `gorm:"not null" json:"image"`
 	Status      GameServerStatus `gorm:"default:stopped" json:"status"`
 	ContainerID string           `json:"containerId,omitempty"`
 	OwnerID     uint             `gorm:"index" json:"ownerId"`
 	Owner       User             `json:"owner,omitempty"`
-	Ports       []GameServerPort `json:"ports,omitempty"`
-	Envs        []GameServerEnv  `json:"envs,omitempty"`
-	Mods        []GameServerMod  `json:"mods,omitempty"`
+	// NodeID is the node the server is placed on; nil places it on the local node.
+	NodeID  *uint              `gorm:"index" json:"nodeId,omitempty"`
+	Node    *Node              `json:"node,omitempty"`
+	Ports   []GameServerPort   `json:"ports,omitempty"`
+	Envs    []GameServerEnv    `json:"envs,omitempty"`
+	Volumes []GameServerVolume `json:"volumes,omitempty"`
+	Mods    []GameServerMod    `json:"mods,omitempty"`
+	// PinnedDigest pins the server to a specific image digest (sha256:...) instead of the tag in Image.
+	PinnedDigest string `json:"pinnedDigest,omitempty"`
+	// ImageDigest is the digest of the image the container was created from.
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// LatestDigest is the registry digest of Image at the last update check.
+	LatestDigest string `json:"latestDigest,omitempty"`
+	// UpdateAvailable reports whether the registry has a newer image than the container runs.
+	UpdateAvailable bool `gorm:"default:false" json:"updateAvailable"`
+	// UpdateCheckedAt is when the image was last checked for updates.
+	UpdateCheckedAt *time.Time `json:"updateCheckedAt,omitempty"`
+	// Resources limits what the server's container may use.
+	Resources ResourceLimits `gorm:"embedded" json:"resources"`
+	// RestartPolicy tells the container runtime when to restart the container:
+	// "no" (default), "on-failure", "always" or "unless-stopped".
+	RestartPolicy string `gorm:"default:no" json:"restartPolicy"`
+}
+
+// Restart policies supported by the container runtimes.
+const (
+	RestartPolicyNo            = "no"
+	RestartPolicyOnFailure     = "on-failure"
+	RestartPolicyAlways        = "always"
+	RestartPolicyUnlessStopped = "unless-stopped"
+)
+
+// ResourceLimits are the resource limits of a container. Zero values mean unlimited.
+type ResourceLimits struct {
+	// MemoryMB is the memory limit in MiB.
+	MemoryMB int `gorm:"default:0" json:"memoryMb"`
+	// CPUs is the number of CPUs the container may use (e.g. 1.5).
+	CPUs float64 `gorm:"default:0" json:"cpus"`
+	// CPUShares is the relative CPU weight under contention (default 1024).
+	CPUShares int `gorm:"default:0" json:"cpuShares"`
+	// PidsLimit is the maximum number of processes.
+	PidsLimit int64 `gorm:"default:0" json:"pidsLimit"`
+}
+
+// ImageRef returns the image reference containers of the server are created from.
+// A pinned server uses the repository of Image with the pinned digest.
+func (s *GameServer) ImageRef() string {
+	if s.PinnedDigest == "" {
+		return s.Image
+	}
+	repo := s.Image
+	if i := strings.Index(repo, "@"); i >= 0 {
+		repo = repo[:i]
+	}
+	// Strip the tag, taking care not to mistake a registry port for it
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return repo + "@" + s.PinnedDigest
 }
 
 // GameServerPort represents a port mapping for a game server.
@@ -54,6 +127,16 @@ type GameServerEnv struct {
 	IsSecret     bool       `gorm:"default:false" json:"isSecret"`
 }
 
+// GameServerVolume represents a named volume mounted into a game server container.
+type GameServerVolume struct {
+	gorm.Model
+	GameServerID uint       `gorm:"not null;index" json:"gameServerId"`
+	GameServer   GameServer `json:"-"`
+	Name         string     `gorm:"not null" json:"name"`
+	VolumeName   string     `gorm:"index;not null" json:"volumeName"`
+	MountPath    string     `gorm:"not null" json:"mountPath"`
+}
+
 // GetVisibleValue returns the value or a masked string if it's a secret.
 func (e *GameServerEnv) GetVisibleValue() string {
 	if e.IsSecret {
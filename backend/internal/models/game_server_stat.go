@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// StatResolution is the time span a stored stats sample covers.
+type StatResolution string
+
+const (
+	// StatResolutionMinute marks raw samples, kept for a day.
+	StatResolutionMinute StatResolution = "minute"
+	// StatResolutionHour marks hourly averages, kept for a week.
+	StatResolutionHour StatResolution = "hour"
+)
+
+// GameServerStat is a stored resource usage sample of a game server.
+type GameServerStat struct {
+	ID            uint           `gorm:"primaryKey" json:"-"`
+	GameServerID  uint           `gorm:"not null;index:idx_game_server_stats_lookup" json:"-"`
+	Resolution    StatResolution `gorm:"not null;index:idx_game_server_stats_lookup" json:"resolution"`
+	Timestamp     time.Time      `gorm:"not null;index:idx_game_server_stats_lookup" json:"timestamp"`
+	CPUPercent    float64        `json:"cpuPercent"`
+	MemoryUsage   uint64         `json:"memoryUsage"`
+	MemoryLimit   uint64         `json:"memoryLimit"`
+	MemoryPercent float64        `json:"memoryPercent"`
+	NetworkRx     uint64         `json:"networkRx"`
+	NetworkTx     uint64         `json:"networkTx"`
+	BlockRead     uint64         `json:"blockRead"`
+	BlockWrite    uint64         `json:"blockWrite"`
+}
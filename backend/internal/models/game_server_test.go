@@ -50,5 +50,38 @@ func TestGameServerStatus_Constants(t *testing.T) {
 		assert.Equal(t, GameServerStatus("running"), GameServerStatusRunning)
 		assert.Equal(t, GameServerStatus("creating"), GameServerStatusCreating)
 		assert.Equal(t, GameServerStatus("error"), GameServerStatusError)
+		assert.Equal(t, GameServerStatus("starting"), GameServerStatusStarting)
+		assert.Equal(t, GameServerStatus("ready"), GameServerStatusReady)
+	})
+}
+
+func TestGameServerStatus_IsRunning(t *testing.T) {
+	t.Run("should count starting and ready servers as running", func(t *testing.T) {
+		assert.True(t, GameServerStatusRunning.IsRunning())
+		assert.True(t, GameServerStatusStarting.IsRunning())
+		assert.True(t, GameServerStatusReady.IsRunning())
+	})
+
+	t.Run("should not count other statuses as running", func(t *testing.T) {
+		assert.False(t, GameServerStatusStopped.IsRunning())
+		assert.False(t, GameServerStatusCreating.IsRunning())
+		assert.False(t, GameServerStatusError.IsRunning())
+	})
+}
+
+func TestGameServer_ImageRef(t *testing.T) {
+	t.Run("should return the image when not pinned", func(t *testing.T) {
+		server := &GameServer{Image: "itzg/minecraft-server:latest"}
+		assert.Equal(t, "itzg/minecraft-server:latest", server.ImageRef())
+	})
+
+	t.Run("should replace the tag with the pinned digest", func(t *testing.T) {
+		server := &GameServer{Image: "itzg/minecraft-server:latest", PinnedDigest: "sha256:abc"}
+		assert.Equal(t, "itzg/minecraft-server@sha256:abc", server.ImageRef())
+	})
+
+	t.Run("should keep a registry port", func(t *testing.T) {
+		server := &GameServer{Image: "localhost:5000/game", PinnedDigest: "sha256:abc"}
+		assert.Equal(t, "localhost:5000/game@sha256:abc", server.ImageRef())
 	})
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Image represents a container image in the local store.
+type Image struct {
+	// ID is the unique identifier of the image.
+	ID string `json:"id"`
+	// Tags are the repository tags of the image (e.g. docker.io/itzg/minecraft-server:latest).
+	Tags []string `json:"tags"`
+	// Digest is the manifest digest the image was pulled with.
+	Digest string `json:"digest,omitempty"`
+	// Digests are the repository digests of the image.
+	Digests []string `json:"digests"`
+	// Size is the size of the image in bytes.
+	Size int64 `json:"size"`
+	// Created is when the image was built.
+	Created time.Time `json:"created"`
+	// Dangling reports whether the image has no tags.
+	Dangling bool `json:"dangling"`
+	// Containers is the number of containers using the image.
+	Containers int `json:"containers"`
+}
+
+// ImagePullProgress is a progress message of an image pull.
+type ImagePullProgress struct {
+	// Message is a human-readable progress line (e.g. "Copying blob sha256:...").
+	Message string `json:"message"`
+}
+
+// ImagePruneReport describes the result of pruning images.
+type ImagePruneReport struct {
+	// Deleted are the IDs of the removed images.
+	Deleted []string `json:"deleted"`
+	// ReclaimedSpace is the disk space freed in bytes.
+	ReclaimedSpace uint64 `json:"reclaimedSpace"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "gorm.io/gorm"
+
+// Node is a machine running a container engine that game servers can be placed on.
+// The machine configured in config.toml is the implicit "local" node and has no record.
+type Node struct {
+	gorm.Model
+	// Name identifies the node (e.g. "tokyo-1").
+	Name string `gorm:"uniqueIndex;not null" json:"name"`
+	// Runtime is the container engine of the node: "podman" or "docker".
+	Runtime string `gorm:"default:podman" json:"runtime"`
+	// Endpoint is the API URL (unix://, tcp://, http(s):// or ssh://user@host/path/to/socket).
+	Endpoint string `gorm:"not null" json:"endpoint"`
+	// TLSCACert is the CA certificate file used to verify https:// endpoints.
+	TLSCACert string `json:"tlsCaCert,omitempty"`
+	// TLSCert is the client certificate file for https:// endpoints.
+	TLSCert string `json:"tlsCert,omitempty"`
+	// TLSKey is the client key file for https:// endpoints.
+	TLSKey string `json:"tlsKey,omitempty"`
+	// TLSInsecure skips verification of the endpoint's certificate.
+	TLSInsecure bool `gorm:"default:false" json:"tlsInsecure"`
+	// SSHKeyPath is the private key file for ssh:// endpoints. The SSH agent is used when empty.
+	SSHKeyPath string `json:"sshKeyPath,omitempty"`
+	// SSHKnownHosts is the known_hosts file for ssh:// endpoints (default: ~/.ssh/known_hosts).
+	SSHKnownHosts string `json:"sshKnownHosts,omitempty"`
+	// Labels are free-form attributes used to select nodes (e.g. region=jp).
+	Labels map[string]string `gorm:"serializer:json" json:"labels"`
+	// MemoryMB is the memory available to game servers in MiB. When 0, the host's free memory is used.
+	MemoryMB int `gorm:"default:0" json:"memoryMb"`
+	// MaxServers limits how many game servers are placed on the node; 0 means no limit.
+	MaxServers int `gorm:"default:0" json:"maxServers"`
+	// Enabled reports whether new game servers are placed on the node automatically.
+	Enabled bool `gorm:"default:true" json:"enabled"`
+}
+
+// HostInfo describes the machine a container engine runs on.
+type HostInfo struct {
+	// Hostname is the host name of the machine.
+	Hostname string `json:"hostname"`
+	// OS is the operating system (e.g. linux).
+	OS string `json:"os"`
+	// Arch is the CPU architecture (e.g. amd64).
+	Arch string `json:"arch"`
+	// CPUs is the number of CPUs.
+	CPUs int `json:"cpus"`
+	// MemoryTotal is the total memory in bytes.
+	MemoryTotal uint64 `json:"memoryTotal"`
+	// MemoryFree is the free memory in bytes, 0 when the engine does not report it.
+	MemoryFree uint64 `json:"memoryFree,omitempty"`
+	// Version is the version of the container engine.
+	Version string `json:"version"`
+}
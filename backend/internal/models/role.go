@@ -46,6 +46,7 @@ func DefaultPermissions() []Permission {
 		{Resource: "game_server", Action: "delete", Description: "Delete servers"},
 		{Resource: "game_server", Action: "start", Description: "Start servers"},
 		{Resource: "game_server", Action: "stop", Description: "Stop servers"},
+		{Resource: "game_server", Action: "console", Description: "Use server console"},
 		{Resource: "mod", Action: "create", Description: "Add mods"},
 		{Resource: "mod", Action: "read", Description: "View mods"},
 		{Resource: "mod", Action: "update", Description: "Edit mods"},
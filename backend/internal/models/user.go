@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,9 +16,13 @@ type User struct {
 	RoleID        uint           `gorm:"not null;index" json:"roleId"`
 	Role          Role           `json:"role,omitempty"`
 	IsActive      bool           `gorm:"default:true" json:"isActive"`
+	TOTPSecret    string         `json:"-"` // Base32 secret, set while enrolling or enabled
+	TOTPEnabled   bool           `gorm:"default:false" json:"totpEnabled"`
+	TOTPLastStep  int64          `json:"-"` // Time step of the last accepted code, to reject replays
 	OAuthAccounts []OAuthAccount `json:"oauthAccounts,omitempty"`
 	APITokens     []APIToken     `json:"-"`
 	RefreshTokens []RefreshToken `json:"-"`
+	RecoveryCodes []RecoveryCode `json:"-"`
 	GameServers   []GameServer   `gorm:"foreignKey:OwnerID" json:"gameServers,omitempty"`
 }
 
@@ -49,6 +54,39 @@ type APIToken struct {
 	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
 }
 
+// ScopeList returns the scopes of the token, such as "game_server:read".
+func (t *APIToken) ScopeList() []string {
+	var scopes []string
+	if t.Scopes != "" {
+		_ = json.Unmarshal([]byte(t.Scopes), &scopes)
+	}
+	return scopes
+}
+
+// SetScopes stores the scopes of the token.
+func (t *APIToken) SetScopes(scopes []string) error {
+	data, err := json.Marshal(scopes)
+	if err != nil {
+		return err
+	}
+	t.Scopes = string(data)
+	return nil
+}
+
+// IsExpired returns true if the token has an expiry date that has passed.
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// RecoveryCode represents a one-time code for signing in without the authenticator app.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index" json:"-"`
+	User     User       `json:"-"`
+	CodeHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	UsedAt   *time.Time `json:"-"` // Null if not used
+}
+
 // RefreshToken represents a long-lived refresh token for JWT rotation.
 type RefreshToken struct {
 	gorm.Model
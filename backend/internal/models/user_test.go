@@ -75,3 +75,30 @@ func TestRefreshToken_IsValid(t *testing.T) {
 		assert.False(t, rt.IsValid())
 	})
 }
+
+func TestAPIToken_Scopes(t *testing.T) {
+	t.Run("should store and return the scopes", func(t *testing.T) {
+		token := &APIToken{}
+		assert.NoError(t, token.SetScopes([]string{"game_server:read", "game_server:start"}))
+		assert.Equal(t, `["game_server:read","game_server:start"]`, token.Scopes)
+		assert.Equal(t, []string{"game_server:read", "game_server:start"}, token.ScopeList())
+	})
+
+	t.Run("should return no scopes when none are stored", func(t *testing.T) {
+		token := &APIToken{}
+		assert.Empty(t, token.ScopeList())
+	})
+}
+
+func TestAPIToken_IsExpired(t *testing.T) {
+	t.Run("should never expire without an expiry date", func(t *testing.T) {
+		token := &APIToken{}
+		assert.False(t, token.IsExpired())
+	})
+
+	t.Run("should return true when the expiry date has passed", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		token := &APIToken{ExpiresAt: &expiresAt}
+		assert.True(t, token.IsExpired())
+	})
+}
@@ -0,0 +1,63 @@
+// Package rcon provides clients for the remote console protocols used by game servers.
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Protocol identifies a remote console protocol.
+type Protocol string
+
+const (
+	// ProtocolSource is the Source RCON protocol used by Minecraft, ARK, Palworld and Factorio.
+	ProtocolSource Protocol = "source"
+	// ProtocolWebRCON is the JSON-over-WebSocket protocol used by Rust.
+	ProtocolWebRCON Protocol = "webrcon"
+	// ProtocolTelnet is the line-based telnet console used by 7 Days to Die.
+	ProtocolTelnet Protocol = "telnet"
+)
+
+// DefaultTimeout is used for I/O when the context has no deadline.
+const DefaultTimeout = 10 * time.Second
+
+// ErrAuthFailed is returned when the server rejects the password.
+var ErrAuthFailed = errors.New("rcon: authentication failed")
+
+// Client is a connection to a remote console.
+type Client interface {
+	// Execute runs a command and returns the server's response.
+	Execute(ctx context.Context, command string) (string, error)
+	// Close closes the connection.
+	Close() error
+}
+
+// Dial connects and authenticates to a remote console at addr.
+func Dial(ctx context.Context, protocol Protocol, addr, password string) (Client, error) {
+	switch protocol {
+	case ProtocolSource:
+		return DialSource(ctx, addr, password)
+	case ProtocolWebRCON:
+		return DialWebRCON(ctx, addr, password)
+	case ProtocolTelnet:
+		return DialTelnet(ctx, addr, password)
+	default:
+		return nil, fmt.Errorf("rcon: unsupported protocol %q", protocol)
+	}
+}
+
+// deadline returns the deadline of ctx, or DefaultTimeout from now if it has none.
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(DefaultTimeout)
+}
+
+// watchContext closes conn when ctx is canceled before stop is called.
+func watchContext(ctx context.Context, conn net.Conn) (stop func() bool) {
+	return context.AfterFunc(ctx, func() { _ = conn.Close() })
+}
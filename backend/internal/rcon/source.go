@@ -0,0 +1,194 @@
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Source RCON packet types.
+const (
+	sourceTypeResponseValue int32 = 0
+	sourceTypeExecCommand   int32 = 2
+	sourceTypeAuthResponse  int32 = 2
+	sourceTypeAuth          int32 = 3
+)
+
+const (
+	// sourceHeaderSize is the size of the ID and type fields.
+	sourceHeaderSize = 8
+	// sourceMaxPacketSize is the largest packet accepted from a server.
+	sourceMaxPacketSize = 4096 + sourceHeaderSize + 2
+	// sourceMaxCommandSize is the largest command body servers are required to accept.
+	sourceMaxCommandSize = 1446
+	// sourceMaxResponseSize is the largest response accepted over all packets.
+	sourceMaxResponseSize = 1 << 20
+)
+
+// SourceClient is a client for the Source RCON protocol.
+type SourceClient struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int32
+}
+
+// DialSource connects to a Source RCON server and authenticates with password.
+func DialSource(ctx context.Context, addr, password string) (*SourceClient, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("rcon: failed to connect to %s: %w", addr, err)
+	}
+
+	c := &SourceClient{conn: conn, reader: bufio.NewReader(conn), nextID: 1}
+	if err := c.auth(ctx, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// auth sends the password and waits for the auth response.
+func (c *SourceClient) auth(ctx context.Context, password string) error {
+	stop := watchContext(ctx, c.conn)
+	defer stop()
+	_ = c.conn.SetDeadline(deadline(ctx))
+
+	id := c.id()
+	if err := c.write(id, sourceTypeAuth, password); err != nil {
+		return err
+	}
+
+	for {
+		respID, respType, _, err := c.read()
+		if err != nil {
+			return contextError(ctx, err)
+		}
+		// Some servers send an empty response value before the auth response
+		if respType != sourceTypeAuthResponse {
+			continue
+		}
+		if respID == -1 {
+			return ErrAuthFailed
+		}
+		if respID == id {
+			return nil
+		}
+	}
+}
+
+// Execute runs a command and returns the server's response.
+func (c *SourceClient) Execute(ctx context.Context, command string) (string, error) {
+	if len(command) > sourceMaxCommandSize {
+		return "", fmt.Errorf("rcon: command is longer than %d bytes", sourceMaxCommandSize)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stop := watchContext(ctx, c.conn)
+	defer stop()
+	_ = c.conn.SetDeadline(deadline(ctx))
+
+	id := c.id()
+	if err := c.write(id, sourceTypeExecCommand, command); err != nil {
+		return "", contextError(ctx, err)
+	}
+	// Long responses are split over several packets. Servers answer packets
+	// in order, so the response to an empty response value sent after the
+	// command marks the end of the command's response.
+	endID := c.id()
+	if err := c.write(endID, sourceTypeResponseValue, ""); err != nil {
+		return "", contextError(ctx, err)
+	}
+
+	var resp strings.Builder
+	for {
+		respID, respType, body, err := c.read()
+		if err != nil {
+			return "", contextError(ctx, err)
+		}
+		if respType != sourceTypeResponseValue {
+			continue
+		}
+		// Late responses to earlier commands are skipped
+		switch respID {
+		case endID:
+			return resp.String(), nil
+		case id:
+			if resp.Len()+len(body) > sourceMaxResponseSize {
+				return "", fmt.Errorf("rcon: response is longer than %d bytes", sourceMaxResponseSize)
+			}
+			resp.WriteString(body)
+		}
+	}
+}
+
+// Close closes the connection.
+func (c *SourceClient) Close() error {
+	return c.conn.Close()
+}
+
+// id returns a new packet ID.
+func (c *SourceClient) id() int32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+// write sends a single packet.
+func (c *SourceClient) write(id, packetType int32, body string) error {
+	var buf bytes.Buffer
+	size := int32(sourceHeaderSize + len(body) + 2)
+	_ = binary.Write(&buf, binary.LittleEndian, size)
+	_ = binary.Write(&buf, binary.LittleEndian, id)
+	_ = binary.Write(&buf, binary.LittleEndian, packetType)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("rcon: failed to send packet: %w", err)
+	}
+	return nil
+}
+
+// read receives a single packet.
+func (c *SourceClient) read() (id, packetType int32, body string, err error) {
+	var size int32
+	if err := binary.Read(c.reader, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: failed to read packet: %w", err)
+	}
+	if size < sourceHeaderSize+2 || size > sourceMaxPacketSize {
+		return 0, 0, "", fmt.Errorf("rcon: invalid packet size %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: failed to read packet: %w", err)
+	}
+
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	packetType = int32(binary.LittleEndian.Uint32(data[4:8]))
+	body = string(bytes.TrimRight(data[sourceHeaderSize:], "\x00"))
+	return id, packetType, body, nil
+}
+
+// contextError prefers the context error over I/O errors caused by cancellation.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
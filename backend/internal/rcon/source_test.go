@@ -0,0 +1,163 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSourceServer starts an in-process Source RCON server and returns its address.
+func fakeSourceServer(t *testing.T, password string, handle func(command string) string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSource(conn, password, handle)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSource(conn net.Conn, password string, handle func(string) string) {
+	defer conn.Close()
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.LittleEndian, &size); err != nil {
+			return
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+		id := int32(binary.LittleEndian.Uint32(data[0:4]))
+		packetType := int32(binary.LittleEndian.Uint32(data[4:8]))
+		body := string(bytes.TrimRight(data[8:], "\x00"))
+
+		switch packetType {
+		case sourceTypeAuth:
+			// Mimic servers that send an empty response value first
+			writeSourcePacket(conn, id, sourceTypeResponseValue, "")
+			if body != password {
+				id = -1
+			}
+			writeSourcePacket(conn, id, sourceTypeAuthResponse, "")
+		case sourceTypeExecCommand:
+			// Split long responses like Source servers do
+			resp := handle(body)
+			for len(resp) > 4096 {
+				writeSourcePacket(conn, id, sourceTypeResponseValue, resp[:4096])
+				resp = resp[4096:]
+			}
+			writeSourcePacket(conn, id, sourceTypeResponseValue, resp)
+		case sourceTypeResponseValue:
+			// Mirror the packet, followed by a packet that some servers send
+			writeSourcePacket(conn, id, sourceTypeResponseValue, "")
+			writeSourcePacket(conn, id, sourceTypeResponseValue, "\x00\x01")
+		}
+	}
+}
+
+func writeSourcePacket(w io.Writer, id, packetType int32, body string) {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	_ = binary.Write(&buf, binary.LittleEndian, id)
+	_ = binary.Write(&buf, binary.LittleEndian, packetType)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	_, _ = w.Write(buf.Bytes())
+}
+
+func TestSourceClient(t *testing.T) {
+	long := strings.Repeat("0123456789", 1000)
+	addr := fakeSourceServer(t, "secret", func(command string) string {
+		switch command {
+		case "list":
+			return "There are 0 of a max of 20 players online"
+		case "cvarlist":
+			return long
+		}
+		return "Unknown command"
+	})
+
+	t.Run("should execute commands after authenticating", func(t *testing.T) {
+		client, err := Dial(context.Background(), ProtocolSource, addr, "secret")
+		require.NoError(t, err)
+		defer client.Close()
+
+		resp, err := client.Execute(context.Background(), "list")
+		require.NoError(t, err)
+		assert.Equal(t, "There are 0 of a max of 20 players online", resp)
+
+		resp, err = client.Execute(context.Background(), "foo")
+		require.NoError(t, err)
+		assert.Equal(t, "Unknown command", resp)
+	})
+
+	t.Run("should join responses split over several packets", func(t *testing.T) {
+		client, err := Dial(context.Background(), ProtocolSource, addr, "secret")
+		require.NoError(t, err)
+		defer client.Close()
+
+		resp, err := client.Execute(context.Background(), "cvarlist")
+		require.NoError(t, err)
+		assert.Equal(t, long, resp)
+
+		resp, err = client.Execute(context.Background(), "list")
+		require.NoError(t, err)
+		assert.Equal(t, "There are 0 of a max of 20 players online", resp)
+	})
+
+	t.Run("should fail with wrong password", func(t *testing.T) {
+		_, err := Dial(context.Background(), ProtocolSource, addr, "wrong")
+		assert.ErrorIs(t, err, ErrAuthFailed)
+	})
+
+	t.Run("should reject commands that are too long", func(t *testing.T) {
+		client, err := DialSource(context.Background(), addr, "secret")
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, err = client.Execute(context.Background(), string(make([]byte, sourceMaxCommandSize+1)))
+		assert.Error(t, err)
+	})
+}
+
+func TestSourceClient_Timeout(t *testing.T) {
+	// A server that accepts connections but never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			_, _ = io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = DialSource(ctx, ln.Addr().String(), "secret")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDial_UnsupportedProtocol(t *testing.T) {
+	_, err := Dial(context.Background(), Protocol("telnet"), "127.0.0.1:1", "")
+	assert.Error(t, err)
+}
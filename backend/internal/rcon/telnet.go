@@ -0,0 +1,116 @@
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// telnetIdleTimeout is how long Execute waits for more output before returning.
+const telnetIdleTimeout = 500 * time.Millisecond
+
+// TelnetClient is a client for line-based telnet consoles such as the one of 7 Days to Die.
+type TelnetClient struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// DialTelnet connects to a telnet console and logs in with password.
+func DialTelnet(ctx context.Context, addr, password string) (*TelnetClient, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("rcon: failed to connect to %s: %w", addr, err)
+	}
+
+	c := &TelnetClient{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.login(ctx, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// login answers the password prompt and waits for the result.
+func (c *TelnetClient) login(ctx context.Context, password string) error {
+	stop := watchContext(ctx, c.conn)
+	defer stop()
+	_ = c.conn.SetDeadline(deadline(ctx))
+
+	// The prompt does not end with a newline, so read until it appears
+	var prompt strings.Builder
+	for !strings.Contains(strings.ToLower(prompt.String()), "password:") {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return contextError(ctx, fmt.Errorf("rcon: failed to read password prompt: %w", err))
+		}
+		prompt.WriteByte(b)
+	}
+
+	if _, err := fmt.Fprintf(c.conn, "%s\r\n", password); err != nil {
+		return contextError(ctx, fmt.Errorf("rcon: failed to send password: %w", err))
+	}
+
+	for {
+		line, err := c.reader.ReadString('\n')
+		lower := strings.ToLower(line)
+		switch {
+		case strings.Contains(lower, "incorrect") || strings.Contains(lower, "wrong"):
+			return ErrAuthFailed
+		case strings.Contains(lower, "logon successful"):
+			return nil
+		}
+		if err != nil {
+			return contextError(ctx, fmt.Errorf("rcon: failed to read login result: %w", err))
+		}
+	}
+}
+
+// Execute runs a command and returns the output received until the console
+// stays quiet for a short while. Telnet consoles do not delimit responses,
+// so the output may include unrelated log lines.
+func (c *TelnetClient) Execute(ctx context.Context, command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stop := watchContext(ctx, c.conn)
+	defer stop()
+	_ = c.conn.SetWriteDeadline(deadline(ctx))
+
+	if _, err := fmt.Fprintf(c.conn, "%s\r\n", command); err != nil {
+		return "", contextError(ctx, fmt.Errorf("rcon: failed to send command: %w", err))
+	}
+
+	var output strings.Builder
+	for {
+		idle := time.Now().Add(telnetIdleTimeout)
+		if d := deadline(ctx); d.Before(idle) {
+			idle = d
+		}
+		_ = c.conn.SetReadDeadline(idle)
+
+		line, err := c.reader.ReadString('\n')
+		output.WriteString(strings.TrimRight(line, "\r\n"))
+		if line != "" {
+			output.WriteByte('\n')
+		}
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+				return strings.TrimRight(output.String(), "\n"), nil
+			}
+			return "", contextError(ctx, fmt.Errorf("rcon: failed to read response: %w", err))
+		}
+	}
+}
+
+// Close closes the connection.
+func (c *TelnetClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,69 @@
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTelnetServer starts an in-process 7 Days to Die style telnet console.
+func fakeTelnetServer(t *testing.T, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				fmt.Fprint(conn, "*** Connected with 7DTD server.\r\nPlease enter password:")
+				line, _ := reader.ReadString('\n')
+				if strings.TrimSpace(line) != password {
+					fmt.Fprint(conn, "Password incorrect, please enter password:")
+					return
+				}
+				fmt.Fprint(conn, "Logon successful.\r\n")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fmt.Fprintf(conn, "INF Executing command '%s'\r\n", strings.TrimSpace(line))
+					fmt.Fprint(conn, "World saved\r\n")
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestTelnetClient(t *testing.T) {
+	addr := fakeTelnetServer(t, "secret")
+
+	t.Run("should execute commands after logging in", func(t *testing.T) {
+		client, err := Dial(context.Background(), ProtocolTelnet, addr, "secret")
+		require.NoError(t, err)
+		defer client.Close()
+
+		resp, err := client.Execute(context.Background(), "saveworld")
+		require.NoError(t, err)
+		assert.Equal(t, "INF Executing command 'saveworld'\nWorld saved", resp)
+	})
+
+	t.Run("should fail with wrong password", func(t *testing.T) {
+		_, err := DialTelnet(context.Background(), addr, "wrong")
+		assert.ErrorIs(t, err, ErrAuthFailed)
+	})
+}
@@ -0,0 +1,78 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// webRCONName is sent as the client name with every WebRCON message.
+const webRCONName = "WebRcon"
+
+// webRCONMessage is a WebRCON request or response.
+type webRCONMessage struct {
+	Identifier int    `json:"Identifier"`
+	Message    string `json:"Message"`
+	Name       string `json:"Name,omitempty"`
+	Type       string `json:"Type,omitempty"`
+}
+
+// WebRCONClient is a client for Rust's WebRCON protocol.
+type WebRCONClient struct {
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	nextID int
+}
+
+// DialWebRCON connects to a WebRCON server. The password is part of the URL,
+// so a wrong password makes the connection fail.
+func DialWebRCON(ctx context.Context, addr, password string) (*WebRCONClient, error) {
+	u := url.URL{Scheme: "ws", Host: addr, Path: "/" + password}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			return nil, ErrAuthFailed
+		}
+		return nil, fmt.Errorf("rcon: failed to connect to %s: %w", addr, err)
+	}
+	return &WebRCONClient{conn: conn, nextID: 1}, nil
+}
+
+// Execute runs a command and returns the server's response.
+func (c *WebRCONClient) Execute(ctx context.Context, command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stop := watchContext(ctx, c.conn.NetConn())
+	defer stop()
+	_ = c.conn.SetWriteDeadline(deadline(ctx))
+	_ = c.conn.SetReadDeadline(deadline(ctx))
+
+	id := c.nextID
+	c.nextID++
+
+	err := c.conn.WriteJSON(webRCONMessage{Identifier: id, Message: command, Name: webRCONName})
+	if err != nil {
+		return "", contextError(ctx, fmt.Errorf("rcon: failed to send command: %w", err))
+	}
+
+	for {
+		var msg webRCONMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return "", contextError(ctx, fmt.Errorf("rcon: failed to read response: %w", err))
+		}
+		// Skip broadcast messages such as chat and server log output
+		if msg.Identifier != id {
+			continue
+		}
+		return msg.Message, nil
+	}
+}
+
+// Close closes the connection.
+func (c *WebRCONClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,65 @@
+package rcon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebRCONServer starts an in-process WebRCON server and returns its address.
+func fakeWebRCONServer(t *testing.T, password string) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/") != password {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var req webRCONMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			// Broadcast an unrelated log line before answering
+			_ = conn.WriteJSON(webRCONMessage{Identifier: 0, Message: "[chat] hello", Type: "Chat"})
+			_ = conn.WriteJSON(webRCONMessage{Identifier: req.Identifier, Message: "ran " + req.Message, Type: "Generic"})
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func TestWebRCONClient(t *testing.T) {
+	addr := fakeWebRCONServer(t, "secret")
+
+	t.Run("should execute commands", func(t *testing.T) {
+		client, err := Dial(context.Background(), ProtocolWebRCON, addr, "secret")
+		require.NoError(t, err)
+		defer client.Close()
+
+		resp, err := client.Execute(context.Background(), "server.save")
+		require.NoError(t, err)
+		assert.Equal(t, "ran server.save", resp)
+
+		resp, err = client.Execute(context.Background(), "status")
+		require.NoError(t, err)
+		assert.Equal(t, "ran status", resp)
+	})
+
+	t.Run("should fail with wrong password", func(t *testing.T) {
+		_, err := DialWebRCON(context.Background(), addr, "wrong")
+		assert.ErrorIs(t, err, ErrAuthFailed)
+	})
+}
@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CounterStore keeps expiring counters and locks for rate limiting.
+// Keys are shared by all instances using the same Redis server.
+type CounterStore interface {
+	// Increment increments the counter under key and returns its new value and
+	// how long until it resets. A new counter resets after window, which must be positive.
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	// ResetCounter deletes the counter under key.
+	ResetCounter(ctx context.Context, key string) error
+	// Lock locks key for ttl, which must be positive, replacing an earlier lock.
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	// LockedFor returns how long key stays locked, or 0 if it is not locked.
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+}
+
+// counterKey returns the Redis key for a counter.
+func counterKey(key string) string {
+	return fmt.Sprintf("counter:%s", key)
+}
+
+// lockKey returns the Redis key for a lock.
+func lockKey(key string) string {
+	return fmt.Sprintf("lock:%s", key)
+}
+
+// Increment increments a counter in Redis.
+func (s *RedisSessionStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	pipe := s.client.rdb.Pipeline()
+	incr := pipe.Incr(ctx, counterKey(key))
+	ttl := pipe.PTTL(ctx, counterKey(key))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	// The counter was just created, or an earlier expiry was never set
+	resetIn := ttl.Val()
+	if resetIn < 0 {
+		if err := s.client.rdb.PExpire(ctx, counterKey(key), window).Err(); err != nil {
+			return 0, 0, err
+		}
+		resetIn = window
+	}
+	return incr.Val(), resetIn, nil
+}
+
+// ResetCounter deletes a counter from Redis.
+func (s *RedisSessionStore) ResetCounter(ctx context.Context, key string) error {
+	return s.client.rdb.Del(ctx, counterKey(key)).Err()
+}
+
+// Lock locks a key in Redis.
+func (s *RedisSessionStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.rdb.Set(ctx, lockKey(key), "1", ttl).Err()
+}
+
+// LockedFor returns how long a key stays locked in Redis.
+func (s *RedisSessionStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.rdb.PTTL(ctx, lockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Missing keys have a negative TTL
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,232 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCleanupInterval is how often a MemorySessionStore drops expired entries.
+const DefaultCleanupInterval = time.Minute
+
+// memorySession is a session kept by a MemorySessionStore.
+type memorySession struct {
+	data      SessionData
+	expiresAt time.Time
+}
+
+// memoryCounter is a counter kept by a MemorySessionStore.
+type memoryCounter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// MemorySessionStore implements SessionStore in process memory for
+// single-node installs without Redis. Sessions, revocations, counters and
+// locks are lost when the process restarts.
+type MemorySessionStore struct {
+	mu           sync.Mutex
+	sessions     map[string]memorySession
+	revoked      map[string]time.Time
+	userSessions map[uint]map[string]struct{}
+	counters     map[string]memoryCounter
+	locks        map[string]time.Time
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+// NewMemorySessionStore creates an in-memory session store whose janitor
+// drops expired entries every cleanupInterval until Close is called.
+func NewMemorySessionStore(cleanupInterval time.Duration) *MemorySessionStore {
+	s := &MemorySessionStore{
+		sessions:     make(map[string]memorySession),
+		revoked:      make(map[string]time.Time),
+		userSessions: make(map[uint]map[string]struct{}),
+		counters:     make(map[string]memoryCounter),
+		locks:        make(map[string]time.Time),
+		stop:         make(chan struct{}),
+	}
+	go s.janitor(cleanupInterval)
+	return s
+}
+
+// Close stops the janitor.
+func (s *MemorySessionStore) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// janitor periodically drops expired entries.
+func (s *MemorySessionStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.cleanup(now)
+		}
+	}
+}
+
+// cleanup drops the entries that expired before now.
+func (s *MemorySessionStore) cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jti, session := range s.sessions {
+		if expired(session.expiresAt, now) {
+			delete(s.sessions, jti)
+			s.forget(session.data.UserID, jti)
+		}
+	}
+	for jti, revokedUntil := range s.revoked {
+		if expired(revokedUntil, now) {
+			delete(s.revoked, jti)
+		}
+	}
+	for key, counter := range s.counters {
+		if expired(counter.expiresAt, now) {
+			delete(s.counters, key)
+		}
+	}
+	for key, lockedUntil := range s.locks {
+		if expired(lockedUntil, now) {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// forget removes a session from the sessions of its user.
+func (s *MemorySessionStore) forget(userID uint, jti string) {
+	delete(s.userSessions[userID], jti)
+	if len(s.userSessions[userID]) == 0 {
+		delete(s.userSessions, userID)
+	}
+}
+
+// expiresAt returns when an entry stored now with a TTL expires.
+// As with Redis, a TTL of zero or less never expires.
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// expired reports whether an entry with the given expiry has expired at now.
+func expired(expiresAt, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// StoreSession stores a session in memory.
+func (s *MemorySessionStore) StoreSession(_ context.Context, jti string, data *SessionData, expiry time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[jti] = memorySession{data: *data, expiresAt: expiresAt(expiry)}
+	if s.userSessions[data.UserID] == nil {
+		s.userSessions[data.UserID] = make(map[string]struct{})
+	}
+	s.userSessions[data.UserID][jti] = struct{}{}
+	return nil
+}
+
+// GetSession retrieves a session from memory.
+func (s *MemorySessionStore) GetSession(_ context.Context, jti string) (*SessionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[jti]
+	if !ok || expired(session.expiresAt, time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+	data := session.data
+	return &data, nil
+}
+
+// RevokeSession removes a session and adds it to the blacklist.
+func (s *MemorySessionStore) RevokeSession(_ context.Context, jti string, blacklistTTL time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[jti]; ok {
+		delete(s.sessions, jti)
+		s.forget(session.data.UserID, jti)
+	}
+	s.revoked[jti] = expiresAt(blacklistTTL)
+	return nil
+}
+
+// IsRevoked checks if a session has been revoked.
+func (s *MemorySessionStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revokedUntil, ok := s.revoked[jti]
+	return ok && !expired(revokedUntil, time.Now()), nil
+}
+
+// RevokeAllUserSessions revokes all sessions for a user.
+func (s *MemorySessionStore) RevokeAllUserSessions(_ context.Context, userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jti := range s.userSessions[userID] {
+		delete(s.sessions, jti)
+		s.revoked[jti] = expiresAt(revokeAllTTL)
+	}
+	delete(s.userSessions, userID)
+	return nil
+}
+
+// Increment increments a counter in memory.
+func (s *MemorySessionStore) Increment(_ context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	counter, ok := s.counters[key]
+	if !ok || expired(counter.expiresAt, now) {
+		counter = memoryCounter{expiresAt: expiresAt(window)}
+	}
+	counter.value++
+	s.counters[key] = counter
+
+	var resetIn time.Duration
+	if !counter.expiresAt.IsZero() {
+		resetIn = counter.expiresAt.Sub(now)
+	}
+	return counter.value, resetIn, nil
+}
+
+// ResetCounter deletes a counter from memory.
+func (s *MemorySessionStore) ResetCounter(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
+
+// Lock locks a key in memory.
+func (s *MemorySessionStore) Lock(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locks[key] = expiresAt(ttl)
+	return nil
+}
+
+// LockedFor returns how long a key stays locked in memory.
+func (s *MemorySessionStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockedUntil, ok := s.locks[key]
+	now := time.Now()
+	if !ok || lockedUntil.IsZero() || expired(lockedUntil, now) {
+		return 0, nil
+	}
+	return lockedUntil.Sub(now), nil
+}
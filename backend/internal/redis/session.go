@@ -13,6 +13,10 @@ import (
 // ErrSessionNotFound is returned when a session does not exist.
 var ErrSessionNotFound = errors.New("session not found")
 
+// revokeAllTTL is how long sessions revoked by RevokeAllUserSessions stay on
+// the blacklist, which outlives any access token.
+const revokeAllTTL = 24 * time.Hour
+
 // SessionData represents the data stored for a session.
 type SessionData struct {
 	UserID    uint   `json:"user_id"`
@@ -21,7 +25,9 @@ type SessionData struct {
 }
 
 // SessionStore defines the interface for session storage.
+// Its backend also keeps the counters used for rate limiting.
 type SessionStore interface {
+	CounterStore
 	StoreSession(ctx context.Context, jti string, data *SessionData, expiry time.Duration) error
 	GetSession(ctx context.Context, jti string) (*SessionData, error)
 	RevokeSession(ctx context.Context, jti string, blacklistTTL time.Duration) error
@@ -142,7 +148,7 @@ func (s *RedisSessionStore) RevokeAllUserSessions(ctx context.Context, userID ui
 	// Revoke each session
 	for _, jti := range jtis {
 		pipe.Del(ctx, sessionKey(jti))
-		pipe.Set(ctx, revokedKey(jti), "1", 24*time.Hour)
+		pipe.Set(ctx, revokedKey(jti), "1", revokeAllTTL)
 	}
 
 	// Clear user's session set
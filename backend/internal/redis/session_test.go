@@ -2,55 +2,15 @@ package redis
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// MockSessionStore is a mock implementation for testing without Redis.
-type MockSessionStore struct {
-	sessions map[string]*SessionData
-	revoked  map[string]bool
-}
-
-// NewMockSessionStore creates a new mock session store.
-func NewMockSessionStore() *MockSessionStore {
-	return &MockSessionStore{
-		sessions: make(map[string]*SessionData),
-		revoked:  make(map[string]bool),
-	}
-}
-
-func (m *MockSessionStore) StoreSession(_ context.Context, jti string, data *SessionData, _ time.Duration) error {
-	m.sessions[jti] = data
-	return nil
-}
-
-func (m *MockSessionStore) GetSession(_ context.Context, jti string) (*SessionData, error) {
-	data, exists := m.sessions[jti]
-	if !exists {
-		return nil, ErrSessionNotFound
-	}
-	return data, nil
-}
-
-func (m *MockSessionStore) RevokeSession(_ context.Context, jti string, _ time.Duration) error {
-	delete(m.sessions, jti)
-	m.revoked[jti] = true
-	return nil
-}
-
-func (m *MockSessionStore) IsRevoked(_ context.Context, jti string) (bool, error) {
-	return m.revoked[jti], nil
-}
-
-func (m *MockSessionStore) RevokeAllUserSessions(_ context.Context, _ uint) error {
-	m.sessions = make(map[string]*SessionData)
-	return nil
-}
-
 func TestSessionData(t *testing.T) {
 	t.Run("should create session data with required fields", func(t *testing.T) {
 		data := &SessionData{
@@ -65,91 +25,213 @@ func TestSessionData(t *testing.T) {
 	})
 }
 
-func TestMockSessionStore_StoreSession(t *testing.T) {
-	store := NewMockSessionStore()
+// testSessionStore runs the tests every SessionStore implementation must pass.
+// JTIs and user IDs are random so that the tests can share a Redis database.
+func testSessionStore(t *testing.T, store SessionStore) {
+	ctx := context.Background()
+	newJTI := func() string { return "test-" + uuid.New().String() }
+	newUserID := func() uint { return uint(uuid.New().ID()) }
 
-	t.Run("should store session successfully", func(t *testing.T) {
-		ctx := context.Background()
-		jti := "test-jti-123"
-		data := &SessionData{
-			UserID:    123,
-			IPAddress: "192.168.1.1",
-			UserAgent: "Mozilla/5.0",
-		}
+	t.Run("should store and get a session", func(t *testing.T) {
+		jti := newJTI()
+		data := &SessionData{UserID: 456, IPAddress: "10.0.0.1", UserAgent: "Chrome/100"}
 
-		err := store.StoreSession(ctx, jti, data, 15*time.Minute)
+		require.NoError(t, store.StoreSession(ctx, jti, data, 15*time.Minute))
 
+		result, err := store.GetSession(ctx, jti)
 		require.NoError(t, err)
+		assert.Equal(t, data, result)
 	})
-}
 
-func TestMockSessionStore_GetSession(t *testing.T) {
-	store := NewMockSessionStore()
-	ctx := context.Background()
+	t.Run("should return error for non-existent session", func(t *testing.T) {
+		result, err := store.GetSession(ctx, newJTI())
 
-	t.Run("should get existing session", func(t *testing.T) {
-		jti := "test-jti-456"
-		data := &SessionData{
-			UserID:    456,
-			IPAddress: "10.0.0.1",
-			UserAgent: "Chrome/100",
-		}
-		_ = store.StoreSession(ctx, jti, data, 15*time.Minute)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.Nil(t, result)
+	})
 
-		result, err := store.GetSession(ctx, jti)
+	t.Run("should expire sessions after their TTL", func(t *testing.T) {
+		jti := newJTI()
+		require.NoError(t, store.StoreSession(ctx, jti, &SessionData{UserID: 1}, 100*time.Millisecond))
+
+		time.Sleep(200 * time.Millisecond)
+
+		_, err := store.GetSession(ctx, jti)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("should return false for non-revoked session", func(t *testing.T) {
+		isRevoked, err := store.IsRevoked(ctx, newJTI())
 
 		require.NoError(t, err)
-		assert.Equal(t, uint(456), result.UserID)
-		assert.Equal(t, "10.0.0.1", result.IPAddress)
+		assert.False(t, isRevoked)
 	})
 
-	t.Run("should return error for non-existent session", func(t *testing.T) {
-		result, err := store.GetSession(ctx, "non-existent-jti")
+	t.Run("should remove and blacklist revoked sessions", func(t *testing.T) {
+		jti := newJTI()
+		require.NoError(t, store.StoreSession(ctx, jti, &SessionData{UserID: 789}, 15*time.Minute))
+
+		require.NoError(t, store.RevokeSession(ctx, jti, 24*time.Hour))
 
+		_, err := store.GetSession(ctx, jti)
 		assert.ErrorIs(t, err, ErrSessionNotFound)
-		assert.Nil(t, result)
+		isRevoked, err := store.IsRevoked(ctx, jti)
+		require.NoError(t, err)
+		assert.True(t, isRevoked)
 	})
-}
 
-func TestMockSessionStore_RevokeSession(t *testing.T) {
-	store := NewMockSessionStore()
-	ctx := context.Background()
+	t.Run("should revoke sessions that were never stored", func(t *testing.T) {
+		jti := newJTI()
 
-	t.Run("should revoke session successfully", func(t *testing.T) {
-		jti := "test-jti-revoke"
-		data := &SessionData{UserID: 789}
-		_ = store.StoreSession(ctx, jti, data, 15*time.Minute)
+		require.NoError(t, store.RevokeSession(ctx, jti, 24*time.Hour))
 
-		err := store.RevokeSession(ctx, jti, 24*time.Hour)
+		isRevoked, err := store.IsRevoked(ctx, jti)
+		require.NoError(t, err)
+		assert.True(t, isRevoked)
+	})
+
+	t.Run("should forget revocations after the blacklist TTL", func(t *testing.T) {
+		jti := newJTI()
+		require.NoError(t, store.RevokeSession(ctx, jti, 100*time.Millisecond))
 
+		time.Sleep(200 * time.Millisecond)
+
+		isRevoked, err := store.IsRevoked(ctx, jti)
 		require.NoError(t, err)
+		assert.False(t, isRevoked)
+	})
 
-		// Session should no longer exist
-		_, getErr := store.GetSession(ctx, jti)
-		assert.ErrorIs(t, getErr, ErrSessionNotFound)
+	t.Run("should revoke all sessions of a user", func(t *testing.T) {
+		userID, otherID := newUserID(), newUserID()
+		jtis := []string{newJTI(), newJTI()}
+		for _, jti := range jtis {
+			require.NoError(t, store.StoreSession(ctx, jti, &SessionData{UserID: userID}, 15*time.Minute))
+		}
+		otherJTI := newJTI()
+		require.NoError(t, store.StoreSession(ctx, otherJTI, &SessionData{UserID: otherID}, 15*time.Minute))
+
+		require.NoError(t, store.RevokeAllUserSessions(ctx, userID))
+
+		for _, jti := range jtis {
+			_, err := store.GetSession(ctx, jti)
+			assert.ErrorIs(t, err, ErrSessionNotFound)
+			isRevoked, err := store.IsRevoked(ctx, jti)
+			require.NoError(t, err)
+			assert.True(t, isRevoked, jti)
+		}
+
+		_, err := store.GetSession(ctx, otherJTI)
+		assert.NoError(t, err)
+		isRevoked, err := store.IsRevoked(ctx, otherJTI)
+		require.NoError(t, err)
+		assert.False(t, isRevoked)
 	})
 
-	t.Run("should mark session as revoked", func(t *testing.T) {
-		jti := "test-jti-revoked-check"
-		data := &SessionData{UserID: 999}
-		_ = store.StoreSession(ctx, jti, data, 15*time.Minute)
-		_ = store.RevokeSession(ctx, jti, 24*time.Hour)
+	t.Run("should do nothing for users without sessions", func(t *testing.T) {
+		assert.NoError(t, store.RevokeAllUserSessions(ctx, newUserID()))
+	})
 
-		isRevoked, err := store.IsRevoked(ctx, jti)
+	t.Run("should count within a window", func(t *testing.T) {
+		key := newJTI()
 
+		count, resetIn, err := store.Increment(ctx, key, time.Minute)
 		require.NoError(t, err)
-		assert.True(t, isRevoked)
+		assert.Equal(t, int64(1), count)
+		assert.InDelta(t, time.Minute, resetIn, float64(time.Second))
+
+		count, resetIn, err = store.Increment(ctx, key, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.LessOrEqual(t, resetIn, time.Minute, "the window of a counter is set when it is created")
+	})
+
+	t.Run("should restart counters after their window", func(t *testing.T) {
+		key := newJTI()
+		_, _, err := store.Increment(ctx, key, 50*time.Millisecond)
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+
+		count, _, err := store.Increment(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should reset counters", func(t *testing.T) {
+		key := newJTI()
+		_, _, err := store.Increment(ctx, key, time.Minute)
+		require.NoError(t, err)
+
+		require.NoError(t, store.ResetCounter(ctx, key))
+
+		count, _, err := store.Increment(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should lock keys until their TTL", func(t *testing.T) {
+		key := newJTI()
+		lockedFor, err := store.LockedFor(ctx, key)
+		require.NoError(t, err)
+		assert.Zero(t, lockedFor)
+
+		require.NoError(t, store.Lock(ctx, key, time.Minute))
+		lockedFor, err = store.LockedFor(ctx, key)
+		require.NoError(t, err)
+		assert.InDelta(t, time.Minute, lockedFor, float64(time.Second))
+
+		require.NoError(t, store.Lock(ctx, key, 50*time.Millisecond))
+		time.Sleep(100 * time.Millisecond)
+		lockedFor, err = store.LockedFor(ctx, key)
+		require.NoError(t, err)
+		assert.Zero(t, lockedFor)
 	})
 }
 
-func TestMockSessionStore_IsRevoked(t *testing.T) {
-	store := NewMockSessionStore()
-	ctx := context.Background()
+func TestMemorySessionStore(t *testing.T) {
+	store := NewMemorySessionStore(DefaultCleanupInterval)
+	defer store.Close()
 
-	t.Run("should return false for non-revoked session", func(t *testing.T) {
-		isRevoked, err := store.IsRevoked(ctx, "never-revoked-jti")
+	testSessionStore(t, store)
+}
 
+func TestMemorySessionStore_Janitor(t *testing.T) {
+	store := NewMemorySessionStore(10 * time.Millisecond)
+	defer store.Close()
+	ctx := context.Background()
+
+	t.Run("should drop expired entries", func(t *testing.T) {
+		require.NoError(t, store.StoreSession(ctx, "expiring", &SessionData{UserID: 1}, 20*time.Millisecond))
+		require.NoError(t, store.StoreSession(ctx, "kept", &SessionData{UserID: 2}, time.Hour))
+		require.NoError(t, store.RevokeSession(ctx, "revoked", 20*time.Millisecond))
+		_, _, err := store.Increment(ctx, "counter", 20*time.Millisecond)
 		require.NoError(t, err)
-		assert.False(t, isRevoked)
+		require.NoError(t, store.Lock(ctx, "lock", 20*time.Millisecond))
+
+		assert.Eventually(t, func() bool {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			return len(store.sessions) == 1 && len(store.revoked) == 0 && len(store.userSessions) == 1 &&
+				len(store.counters) == 0 && len(store.locks) == 0
+		}, time.Second, 10*time.Millisecond)
 	})
+
+	t.Run("should stop when closed", func(t *testing.T) {
+		store.Close()
+		store.Close()
+	})
+}
+
+// TestRedisSessionStore runs against the Redis server at REDIS_TEST_URL and is
+// skipped when it is not set.
+func TestRedisSessionStore(t *testing.T) {
+	redisURL := os.Getenv("REDIS_TEST_URL")
+	if redisURL == "" {
+		t.Skip("REDIS_TEST_URL is not set")
+	}
+	client, err := NewClientWithURL(redisURL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	testSessionStore(t, NewRedisSessionStore(client))
 }
@@ -2,6 +2,7 @@
 package server
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -18,17 +19,30 @@ import (
 
 // Dependencies holds all the dependencies needed by the server.
 type Dependencies struct {
-	ContainerService *container.Service
-	DB               *gorm.DB
-	Config           *config.SystemConfig
-	SessionStore     redis.SessionStore
+	Nodes        *container.Pool
+	DB           *gorm.DB
+	Config       *config.SystemConfig
+	SessionStore redis.SessionStore
+	// StatusBroker receives game server status changes for the event stream.
+	StatusBroker *container.StatusBroker
+	// Watcher hands started game servers to the readiness probes of the game server handler.
+	Watcher *container.Watcher
 }
 
 // New creates a new Echo server with all middleware and routes configured.
 func New(deps *Dependencies) *echo.Echo {
 	e := echo.New()
 
+	// Client IP for rate limits, login lockouts and the audit log
+	ipExtractor, err := middleware.IPExtractor(deps.Config.Server.TrustedProxies)
+	if err != nil {
+		slog.Error("Ignoring trusted proxies", "error", err)
+		ipExtractor = echo.ExtractIPDirect()
+	}
+	e.IPExtractor = ipExtractor
+
 	// Middleware
+	e.Pre(middleware.RedactQuery("access_token"))
 	e.Use(echoMiddleware.Logger())
 	e.Use(echoMiddleware.Recover())
 	e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
@@ -55,15 +69,21 @@ func New(deps *Dependencies) *echo.Echo {
 
 	// Create middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager, deps.SessionStore)
+	authMiddleware.SetDB(deps.DB)
 	permMiddleware := middleware.NewPermissionMiddleware(deps.DB)
+	rateLimiter := middleware.NewRateLimiter(deps.SessionStore)
 
 	// Auth routes (public)
 	authHandler := handlers.NewAuthHandler(deps.DB, jwtManager, deps.SessionStore)
-	authGroup := e.Group("/auth")
+	authHandler.SetTwoFactorPolicy(deps.Config.Auth.Require2FAMinPriority)
+	authGroup := e.Group("/auth", rateLimiter.Limit("auth", deps.Config.RateLimit.Auth, time.Minute))
 	authGroup.POST("/register", authHandler.Register)
 	authGroup.POST("/login", authHandler.Login)
 	authGroup.POST("/refresh", authHandler.Refresh)
 	authGroup.POST("/logout", authMiddleware.Authenticate(authHandler.Logout))
+	authGroup.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+	authGroup.POST("/2fa/enroll", authHandler.EnrollTwoFactor)
+	authGroup.POST("/2fa/enable", authHandler.EnableTwoFactor)
 
 	// OAuth routes (public)
 	oauthHandler := handlers.NewOAuthHandler(
@@ -73,21 +93,58 @@ func New(deps *Dependencies) *echo.Echo {
 		&deps.Config.OAuth,
 		"http://localhost:4200", // Frontend URL
 	)
+	oauthHandler.SetTwoFactorPolicy(deps.Config.Auth.Require2FAMinPriority)
 	authGroup.GET("/oauth/:provider", oauthHandler.Authorize)
 	authGroup.GET("/oauth/:provider/callback", oauthHandler.Callback)
 
 	// API routes (protected)
 	api := e.Group("/api")
+	api.Use(rateLimiter.Limit("api", deps.Config.RateLimit.API, time.Minute))
 	api.Use(authMiddleware.Authenticate)
 
+	// API token routes (own tokens of the current user)
+	apiTokenHandler := handlers.NewAPITokenHandler(deps.DB)
+	tokens := api.Group("/tokens")
+	tokens.GET("", apiTokenHandler.List)
+	tokens.POST("", apiTokenHandler.Create)
+	tokens.DELETE("/:id", apiTokenHandler.Delete)
+
+	// Two-factor authentication routes (own second factor of the current user)
+	twoFactor := api.Group("/2fa")
+	twoFactor.POST("/enroll", authHandler.EnrollTwoFactor)
+	twoFactor.POST("/enable", authHandler.EnableTwoFactor)
+	twoFactor.POST("/disable", authHandler.DisableTwoFactor)
+	twoFactor.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
 	// Container routes
-	containerHandler := handlers.NewContainerHandler(deps.ContainerService)
+	containerHandler := handlers.NewContainerHandler(deps.Nodes)
 	containers := api.Group("/containers")
 	containers.GET("", containerHandler.List, permMiddleware.RequirePermission("game_server", "read"))
 	containers.GET("/:id", containerHandler.Get, permMiddleware.RequirePermission("game_server", "read"))
 	containers.POST("/:id/start", containerHandler.Start, permMiddleware.RequirePermission("game_server", "start"))
 	containers.POST("/:id/stop", containerHandler.Stop, permMiddleware.RequirePermission("game_server", "stop"))
 	containers.GET("/:id/logs", containerHandler.Logs, permMiddleware.RequirePermission("game_server", "read"))
+	containers.GET("/:id/logs/stream", containerHandler.StreamLogs, permMiddleware.RequirePermission("game_server", "read"))
+	containers.GET("/:id/stats", containerHandler.Stats, permMiddleware.RequirePermission("game_server", "read"))
+	containers.GET("/:id/stats/stream", containerHandler.StreamStats, permMiddleware.RequirePermission("game_server", "read"))
+
+	// Image routes (admin only)
+	imageHandler := handlers.NewImageHandler(deps.Nodes)
+	images := api.Group("/images", permMiddleware.RequireAdmin())
+	images.GET("", imageHandler.List)
+	images.POST("/pull", imageHandler.Pull)
+	images.POST("/prune", imageHandler.Prune)
+	images.GET("/:id", imageHandler.Get)
+	images.DELETE("/:id", imageHandler.Delete)
+
+	// Node routes (admin only)
+	nodeHandler := handlers.NewNodeHandler(deps.DB, deps.Nodes)
+	nodes := api.Group("/nodes", permMiddleware.RequireAdmin())
+	nodes.GET("", nodeHandler.List)
+	nodes.POST("", nodeHandler.Create)
+	nodes.GET("/:id", nodeHandler.Get)
+	nodes.PUT("/:id", nodeHandler.Update)
+	nodes.DELETE("/:id", nodeHandler.Delete)
 
 	// Mod routes
 	modHandler := handlers.NewModHandler(deps.DB)
@@ -98,14 +155,40 @@ func New(deps *Dependencies) *echo.Echo {
 	mods.PUT("/:id", modHandler.Update, permMiddleware.RequirePermission("mod", "update"))
 	mods.DELETE("/:id", modHandler.Delete, permMiddleware.RequirePermission("mod", "delete"))
 
+	// Game routes
+	gamesHandler := handlers.NewGamesHandler()
+	gamesGroup := api.Group("/games")
+	gamesGroup.GET("", gamesHandler.List, permMiddleware.RequirePermission("game_server", "read"))
+	gamesGroup.GET("/:id", gamesHandler.Get, permMiddleware.RequirePermission("game_server", "read"))
+	gamesGroup.GET("/:id/schema", gamesHandler.Schema, permMiddleware.RequirePermission("game_server", "read"))
+
 	// Game Server routes
-	gameServerHandler := handlers.NewGameServerHandler(deps.DB)
+	gameServerHandler := handlers.NewGameServerHandler(deps.DB, deps.Nodes)
+	gameServerHandler.SetBackupDir(deps.Config.BackupsDir)
+	gameServerHandler.SetPortRange(deps.Config.Ports.RangeStart, deps.Config.Ports.RangeEnd)
+	gameServerHandler.SetStatusBroker(deps.StatusBroker)
+	gameServerHandler.SetAllowedOrigins(deps.Config.Server.AllowedOrigins)
+	if deps.Watcher != nil {
+		deps.Watcher.SetReadinessProber(gameServerHandler)
+	}
 	gameServers := api.Group("/game-servers")
 	gameServers.GET("", gameServerHandler.List, permMiddleware.RequirePermission("game_server", "read"))
 	gameServers.POST("", gameServerHandler.Create, permMiddleware.RequirePermission("game_server", "create"))
+	gameServers.GET("/events", gameServerHandler.Events, permMiddleware.RequirePermission("game_server", "read"))
 	gameServers.GET("/:slug", gameServerHandler.Get, permMiddleware.RequirePermission("game_server", "read"))
+	gameServers.GET("/:slug/stats", gameServerHandler.Stats, permMiddleware.RequirePermission("game_server", "read"))
 	gameServers.PUT("/:slug", gameServerHandler.Update, permMiddleware.RequirePermission("game_server", "update"))
 	gameServers.DELETE("/:slug", gameServerHandler.Delete, permMiddleware.RequirePermission("game_server", "delete"))
+	gameServers.POST("/:slug/start", gameServerHandler.Start, permMiddleware.RequirePermission("game_server", "start"))
+	gameServers.POST("/:slug/stop", gameServerHandler.Stop, permMiddleware.RequirePermission("game_server", "stop"))
+	gameServers.POST("/:slug/restart", gameServerHandler.Restart,
+		permMiddleware.RequirePermission("game_server", "stop"),
+		permMiddleware.RequirePermission("game_server", "start"),
+	)
+	gameServers.POST("/:slug/recreate", gameServerHandler.Recreate, permMiddleware.RequirePermission("game_server", "update"))
+	gameServers.POST("/:slug/upgrade", gameServerHandler.Upgrade, permMiddleware.RequirePermission("game_server", "update"))
+	gameServers.GET("/:slug/console", gameServerHandler.Console, permMiddleware.RequirePermission("game_server", "console"))
+	gameServers.POST("/:slug/rcon", gameServerHandler.RCON, permMiddleware.RequirePermission("game_server", "console"))
 
 	return e
 
@@ -147,10 +147,13 @@ describe("AuthService", () => {
 
   describe("logout", () => {
     it("should send logout request and clear tokens", () => {
+      localStorageMock.getItem.mockReturnValueOnce("stored-refresh-token");
+
       service.logout().subscribe();
 
       const req = httpMock.expectOne("/auth/logout");
       expect(req.request.method).toBe("POST");
+      expect(req.request.body).toEqual({ refresh_token: "stored-refresh-token" });
       req.flush({ message: "Logged out" });
 
       expect(localStorageMock.removeItem).toHaveBeenCalledWith("sabakan_access_token");
@@ -104,15 +104,20 @@ export class AuthService {
   }
 
   /**
-   * Logs out the current user.
+   * Logs out the current user and revokes the refresh token of this login.
    * @returns {Observable<{ message: string }>} Observable that completes on logout
    */
   logout(): Observable<{ message: string }> {
-    return this.http.post<{ message: string }>(`${this.baseUrl}/logout`, {}).pipe(
-      tap(() => {
-        this.clearTokens();
-      }),
-    );
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    return this.http
+      .post<{ message: string }>(`${this.baseUrl}/logout`, {
+        refresh_token: refreshToken,
+      })
+      .pipe(
+        tap(() => {
+          this.clearTokens();
+        }),
+      );
   }
 
   /**